@@ -0,0 +1,93 @@
+package pgxtypefaster
+
+import (
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Get returns the value of key, and true if key exists with a non-NULL value. A NULL value is
+// treated as missing: use HasKey or index the map to distinguish them.
+func (h Hstore) Get(key string) (string, bool) {
+	v := h[key]
+	return v.String, v.Valid
+}
+
+// GetOr returns the value of key, or defaultValue if key is missing or NULL.
+func (h Hstore) GetOr(key string, defaultValue string) string {
+	if v := h[key]; v.Valid {
+		return v.String
+	}
+	return defaultValue
+}
+
+// Has returns true if key exists with a non-NULL value.
+func (h Hstore) Has(key string) bool {
+	return h[key].Valid
+}
+
+// HasKey returns true if key exists, including with a NULL value.
+func (h Hstore) HasKey(key string) bool {
+	_, ok := h[key]
+	return ok
+}
+
+// ToMap returns the pairs as a map[string]string, converting NULL values according to policy:
+// NullSkip omits them, NullEmpty converts them to the empty string, and NullError returns a
+// *NullValueError. A nil Hstore returns a nil map.
+func (h Hstore) ToMap(policy NullPolicy) (map[string]string, error) {
+	return toStringMap(h, policy)
+}
+
+// Keys returns the keys of h in map iteration order, which is not deterministic.
+func (h Hstore) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// SortedKeys returns the keys of h in sorted order.
+func (h Hstore) SortedKeys() []string {
+	keys := h.Keys()
+	sort.Strings(keys)
+	return keys
+}
+
+// Values returns the values of h in map iteration order, which is not deterministic and does not
+// match the order of Keys. Use SortedKeys to access the values in key order.
+func (h Hstore) Values() []pgtype.Text {
+	values := make([]pgtype.Text, 0, len(h))
+	for _, v := range h {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Get returns the value of key, and true if key exists with a non-NULL value, like Hstore.Get.
+func (h HstoreCompat) Get(key string) (string, bool) {
+	if v := h[key]; v != nil {
+		return *v, true
+	}
+	return "", false
+}
+
+// GetOr returns the value of key, or defaultValue if key is missing or NULL.
+func (h HstoreCompat) GetOr(key string, defaultValue string) string {
+	if v := h[key]; v != nil {
+		return *v
+	}
+	return defaultValue
+}
+
+// Has returns true if key exists with a non-NULL value.
+func (h HstoreCompat) Has(key string) bool {
+	return h[key] != nil
+}
+
+// HasKey returns true if key exists, including with a NULL value.
+func (h HstoreCompat) HasKey(key string) bool {
+	_, ok := h[key]
+	return ok
+}
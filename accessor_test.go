@@ -0,0 +1,152 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreAccessors(t *testing.T) {
+	h := pgxtypefaster.Hstore{
+		"a":     pgxtypefaster.NewText("1"),
+		"empty": pgxtypefaster.NewText(""),
+		"null":  pgtype.Text{},
+	}
+	tests := []struct {
+		key    string
+		value  string
+		ok     bool
+		hasKey bool
+	}{
+		{"a", "1", true, true},
+		{"empty", "", true, true},
+		{"null", "", false, true},
+		{"missing", "", false, false},
+	}
+	for _, test := range tests {
+		value, ok := h.Get(test.key)
+		if value != test.value || ok != test.ok {
+			t.Errorf("Get(%#v)=%#v, %t; expected %#v, %t", test.key, value, ok, test.value, test.ok)
+		}
+		expectedOr := test.value
+		if !test.ok {
+			expectedOr = "default"
+		}
+		if v := h.GetOr(test.key, "default"); v != expectedOr {
+			t.Errorf("GetOr(%#v)=%#v; expected %#v", test.key, v, expectedOr)
+		}
+		if h.Has(test.key) != test.ok {
+			t.Errorf("Has(%#v)=%t", test.key, h.Has(test.key))
+		}
+		if h.HasKey(test.key) != test.hasKey {
+			t.Errorf("HasKey(%#v)=%t", test.key, h.HasKey(test.key))
+		}
+	}
+
+	// works on NULL
+	var null pgxtypefaster.Hstore
+	if null.Has("a") || null.HasKey("a") || null.GetOr("a", "x") != "x" {
+		t.Error("nil Hstore must not have keys")
+	}
+}
+
+func TestHstoreCompatAccessors(t *testing.T) {
+	h := pgxtypefaster.HstoreToCompat(pgxtypefaster.Hstore{
+		"a":     pgxtypefaster.NewText("1"),
+		"empty": pgxtypefaster.NewText(""),
+		"null":  pgtype.Text{},
+	})
+	tests := []struct {
+		key    string
+		value  string
+		ok     bool
+		hasKey bool
+	}{
+		{"a", "1", true, true},
+		{"empty", "", true, true},
+		{"null", "", false, true},
+		{"missing", "", false, false},
+	}
+	for _, test := range tests {
+		value, ok := h.Get(test.key)
+		if value != test.value || ok != test.ok {
+			t.Errorf("Get(%#v)=%#v, %t; expected %#v, %t", test.key, value, ok, test.value, test.ok)
+		}
+		expectedOr := test.value
+		if !test.ok {
+			expectedOr = "default"
+		}
+		if v := h.GetOr(test.key, "default"); v != expectedOr {
+			t.Errorf("GetOr(%#v)=%#v; expected %#v", test.key, v, expectedOr)
+		}
+		if h.Has(test.key) != test.ok {
+			t.Errorf("Has(%#v)=%t", test.key, h.Has(test.key))
+		}
+		if h.HasKey(test.key) != test.hasKey {
+			t.Errorf("HasKey(%#v)=%t", test.key, h.HasKey(test.key))
+		}
+	}
+
+	var null pgxtypefaster.HstoreCompat
+	if null.Has("a") || null.HasKey("a") || null.GetOr("a", "x") != "x" {
+		t.Error("nil HstoreCompat must not have keys")
+	}
+}
+
+func TestHstoreToMap(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": pgtype.Text{}}
+
+	m, err := h.ToMap(pgxtypefaster.NullSkip)
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "1"}) {
+		t.Errorf("ToMap(NullSkip)=%#v, %v", m, err)
+	}
+	m, err = h.ToMap(pgxtypefaster.NullEmpty)
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "1", "null": ""}) {
+		t.Errorf("ToMap(NullEmpty)=%#v, %v", m, err)
+	}
+	_, err = h.ToMap(pgxtypefaster.NullError)
+	var nullErr *pgxtypefaster.NullValueError
+	if !errors.As(err, &nullErr) || nullErr.Key != "null" {
+		t.Errorf("ToMap(NullError) err=%v; expected NullValueError", err)
+	}
+
+	m, err = pgxtypefaster.Hstore(nil).ToMap(pgxtypefaster.NullError)
+	if err != nil || m != nil {
+		t.Errorf("ToMap of nil=%#v, %v", m, err)
+	}
+}
+
+func TestHstoreKeysValues(t *testing.T) {
+	h := pgxtypefaster.Hstore{"c": pgxtypefaster.NewText("3"), "a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+
+	keys := h.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Errorf("Keys()=%#v", keys)
+	}
+	if sorted := h.SortedKeys(); !reflect.DeepEqual(sorted, []string{"a", "b", "c"}) {
+		t.Errorf("SortedKeys()=%#v", sorted)
+	}
+	values := h.Values()
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].String < values[j].String
+	})
+	if !reflect.DeepEqual(values, []pgtype.Text{{}, pgxtypefaster.NewText("1"), pgxtypefaster.NewText("3")}) {
+		t.Errorf("Values()=%#v", values)
+	}
+
+	if allocs := testing.AllocsPerRun(10, func() { h.Keys() }); allocs > 1 {
+		t.Errorf("Keys() allocs=%f; expected at most 1", allocs)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { h.Values() }); allocs > 1 {
+		t.Errorf("Values() allocs=%f; expected at most 1", allocs)
+	}
+
+	if keys := pgxtypefaster.Hstore(nil).Keys(); len(keys) != 0 {
+		t.Errorf("nil Keys()=%#v", keys)
+	}
+}
@@ -0,0 +1,216 @@
+package pgxtypefaster
+
+// Allocator allocates the values created by scanning. The default allocates each value on the
+// heap. A custom Allocator can be set with WithAllocator to route scan allocations through an
+// application's own memory management, such as an Arena that is reset after each batch of rows.
+// Strings that contain escapes in the text format are still allocated on the heap.
+type Allocator interface {
+	// AllocBytes returns a byte slice with length n.
+	AllocBytes(n int) []byte
+	// AllocString returns a string with the contents of b. b must not be retained, since it is
+	// reused by pgx after scanning.
+	AllocString(b []byte) string
+	// AllocHstore returns an empty Hstore with space for n pairs.
+	AllocHstore(n int) Hstore
+	// AllocHstoreCompat returns an empty HstoreCompat with space for n pairs.
+	AllocHstoreCompat(n int) HstoreCompat
+	// AllocStrings returns a string slice with length 0 and capacity n, used to store the values
+	// of HstoreCompat.
+	AllocStrings(n int) []string
+}
+
+// HeapAllocator allocates each value on the heap, using make. It is the default.
+type HeapAllocator struct{}
+
+func (HeapAllocator) AllocBytes(n int) []byte {
+	return make([]byte, n)
+}
+
+func (HeapAllocator) AllocString(b []byte) string {
+	return string(b)
+}
+
+func (HeapAllocator) AllocHstore(n int) Hstore {
+	return make(Hstore, n)
+}
+
+func (HeapAllocator) AllocHstoreCompat(n int) HstoreCompat {
+	return make(HstoreCompat, n)
+}
+
+func (HeapAllocator) AllocStrings(n int) []string {
+	return make([]string, 0, n)
+}
+
+// allocString, allocHstore, allocHstoreCompat, and allocStrings use the heap if alloc is nil,
+// which avoids the interface call for the default configuration.
+
+func allocString(alloc Allocator, b []byte) string {
+	if alloc == nil {
+		return string(b)
+	}
+	return alloc.AllocString(b)
+}
+
+func allocHstore(alloc Allocator, n int) Hstore {
+	if alloc == nil {
+		return make(Hstore, n)
+	}
+	return alloc.AllocHstore(n)
+}
+
+func allocHstoreCompat(alloc Allocator, n int) HstoreCompat {
+	if alloc == nil {
+		return make(HstoreCompat, n)
+	}
+	return alloc.AllocHstoreCompat(n)
+}
+
+func allocStrings(alloc Allocator, n int) []string {
+	if alloc == nil {
+		return make([]string, 0, n)
+	}
+	return alloc.AllocStrings(n)
+}
+
+const defaultArenaChunkSize = 64 * 1024
+
+// stringHeaderSize is the size of a string header on 64-bit platforms, used to size the chunks
+// of strings.
+const stringHeaderSize = 16
+
+// Arena is an Allocator that allocates bytes and strings from large chunks, and reuses the chunks
+// and maps after Reset. This reduces the number of allocations when scanning many rows, such as
+// processing a large query in batches. Values allocated from an Arena must not be used after
+// Reset: maps are cleared and reused, and with the pgxtypefasterunsafe build tag, strings share
+// the byte chunks, so they change when the chunks are reused. Without the build tag, AllocString
+// copies to the heap. An Arena must not be used concurrently.
+type Arena struct {
+	chunkSize int
+
+	byteChunks [][]byte
+	usedBytes  int // number of byteChunks in use
+	bytes      []byte
+	bytesOff   int
+
+	stringChunks [][]string
+	usedStrings  int // number of stringChunks in use
+	strings      []string
+	stringsOff   int
+
+	hstores           []Hstore
+	freeHstores       []Hstore
+	hstoreCompats     []HstoreCompat
+	freeHstoreCompats []HstoreCompat
+}
+
+// NewArena returns an Arena that allocates chunks of chunkSize bytes. Larger values are allocated
+// on the heap. If chunkSize is <= 0, it uses 64 KiB.
+func NewArena(chunkSize int) *Arena {
+	if chunkSize <= 0 {
+		chunkSize = defaultArenaChunkSize
+	}
+	return &Arena{chunkSize: chunkSize}
+}
+
+func (a *Arena) AllocBytes(n int) []byte {
+	if n > a.chunkSize {
+		return make([]byte, n)
+	}
+	if len(a.bytes)-a.bytesOff < n {
+		if a.usedBytes == len(a.byteChunks) {
+			a.byteChunks = append(a.byteChunks, make([]byte, a.chunkSize))
+		}
+		a.bytes = a.byteChunks[a.usedBytes]
+		a.usedBytes++
+		a.bytesOff = 0
+	}
+	b := a.bytes[a.bytesOff : a.bytesOff+n : a.bytesOff+n]
+	a.bytesOff += n
+	return b
+}
+
+func (a *Arena) AllocString(b []byte) string {
+	if !UnsafeOptimizations {
+		return string(b)
+	}
+	buf := a.AllocBytes(len(b))
+	copy(buf, b)
+	// buf is only reused after Reset, which invalidates all values
+	return ownedBytesToString(buf)
+}
+
+func (a *Arena) AllocHstore(n int) Hstore {
+	var h Hstore
+	if last := len(a.freeHstores) - 1; last >= 0 {
+		h = a.freeHstores[last]
+		a.freeHstores = a.freeHstores[:last]
+	} else {
+		h = make(Hstore, n)
+	}
+	a.hstores = append(a.hstores, h)
+	return h
+}
+
+func (a *Arena) AllocHstoreCompat(n int) HstoreCompat {
+	var h HstoreCompat
+	if last := len(a.freeHstoreCompats) - 1; last >= 0 {
+		h = a.freeHstoreCompats[last]
+		a.freeHstoreCompats = a.freeHstoreCompats[:last]
+	} else {
+		h = make(HstoreCompat, n)
+	}
+	a.hstoreCompats = append(a.hstoreCompats, h)
+	return h
+}
+
+func (a *Arena) AllocStrings(n int) []string {
+	chunkLen := a.chunkSize / stringHeaderSize
+	if n > chunkLen {
+		return make([]string, 0, n)
+	}
+	if len(a.strings)-a.stringsOff < n {
+		if a.usedStrings == len(a.stringChunks) {
+			a.stringChunks = append(a.stringChunks, make([]string, chunkLen))
+		}
+		a.strings = a.stringChunks[a.usedStrings]
+		a.usedStrings++
+		a.stringsOff = 0
+	}
+	s := a.strings[a.stringsOff : a.stringsOff : a.stringsOff+n]
+	a.stringsOff += n
+	return s
+}
+
+// Reset makes all memory allocated by the Arena available for reuse. Values previously allocated
+// from the Arena must not be used after Reset.
+func (a *Arena) Reset() {
+	a.usedBytes = 0
+	a.bytes = nil
+	a.bytesOff = 0
+
+	// clear the strings so they do not keep their contents from being garbage collected
+	for _, chunk := range a.stringChunks[:a.usedStrings] {
+		for i := range chunk {
+			chunk[i] = ""
+		}
+	}
+	a.usedStrings = 0
+	a.strings = nil
+	a.stringsOff = 0
+
+	for _, h := range a.hstores {
+		for k := range h {
+			delete(h, k)
+		}
+	}
+	a.freeHstores = append(a.freeHstores, a.hstores...)
+	a.hstores = a.hstores[:0]
+	for _, h := range a.hstoreCompats {
+		for k := range h {
+			delete(h, k)
+		}
+	}
+	a.freeHstoreCompats = append(a.freeHstoreCompats, a.hstoreCompats...)
+	a.hstoreCompats = a.hstoreCompats[:0]
+}
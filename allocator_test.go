@@ -0,0 +1,123 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+// countingAllocator counts the calls to each method.
+type countingAllocator struct {
+	pgxtypefaster.HeapAllocator
+	strings      int
+	hstores      int
+	compats      int
+	stringSlices int
+}
+
+func (a *countingAllocator) AllocString(b []byte) string {
+	a.strings++
+	return a.HeapAllocator.AllocString(b)
+}
+
+func (a *countingAllocator) AllocHstore(n int) pgxtypefaster.Hstore {
+	a.hstores++
+	return a.HeapAllocator.AllocHstore(n)
+}
+
+func (a *countingAllocator) AllocHstoreCompat(n int) pgxtypefaster.HstoreCompat {
+	a.compats++
+	return a.HeapAllocator.AllocHstoreCompat(n)
+}
+
+func (a *countingAllocator) AllocStrings(n int) []string {
+	a.stringSlices++
+	return a.HeapAllocator.AllocStrings(n)
+}
+
+func TestWithAllocator(t *testing.T) {
+	alloc := &countingAllocator{}
+	m := newOptionsTypeMap(pgxtypefaster.WithAllocator(alloc))
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": {}}
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		*alloc = countingAllocator{}
+		var out pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, h) {
+			t.Errorf("format=%d: got %#v; expected %#v", format, out, h)
+		}
+		if alloc.strings != 1 || alloc.hstores != 1 {
+			t.Errorf("format=%d: Hstore allocations: %#v", format, alloc)
+		}
+
+		*alloc = countingAllocator{}
+		var compat pgxtypefaster.HstoreCompat
+		if err := m.Scan(testHstoreOID+1, format, buf, &compat); err != nil {
+			t.Fatal(err)
+		}
+		if len(compat) != 2 || *compat["a"] != "b" || compat["c"] != nil {
+			t.Errorf("format=%d: got %#v", format, compat)
+		}
+		if alloc.strings != 1 || alloc.compats != 1 || alloc.stringSlices != 1 {
+			t.Errorf("format=%d: HstoreCompat allocations: %#v", format, alloc)
+		}
+	}
+}
+
+func TestArena(t *testing.T) {
+	arena := pgxtypefaster.NewArena(16)
+
+	b1 := arena.AllocBytes(10)
+	b2 := arena.AllocBytes(10)
+	if len(b1) != 10 || len(b2) != 10 || cap(b1) != 10 {
+		t.Fatalf("len(b1)=%d cap(b1)=%d len(b2)=%d", len(b1), cap(b1), len(b2))
+	}
+	if large := arena.AllocBytes(100); len(large) != 100 {
+		t.Errorf("len(large)=%d", len(large))
+	}
+	if s := arena.AllocString([]byte("hello")); s != "hello" {
+		t.Errorf("AllocString=%#v", s)
+	}
+	strs := arena.AllocStrings(1)
+	if len(strs) != 0 || cap(strs) != 1 {
+		t.Errorf("AllocStrings: len=%d cap=%d", len(strs), cap(strs))
+	}
+
+	h := arena.AllocHstore(1)
+	h["k"] = pgxtypefaster.NewText("v")
+	arena.Reset()
+	if len(h) != 0 {
+		t.Errorf("Reset must clear allocated maps: %#v", h)
+	}
+	h2 := arena.AllocHstore(1)
+	if reflect.ValueOf(h2).Pointer() != reflect.ValueOf(h).Pointer() {
+		t.Error("AllocHstore after Reset must reuse the map")
+	}
+
+	// scan with an Arena
+	m := newOptionsTypeMap(pgxtypefaster.WithAllocator(arena))
+	in := pgxtypefaster.Hstore{"key": pgxtypefaster.NewText("value")}
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, in, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("format=%d: got %#v; expected %#v", format, out, in)
+		}
+		arena.Reset()
+	}
+}
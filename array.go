@@ -1,7 +1,396 @@
 package pgxtypefaster
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
+
+	"github.com/evanj/pgxtypefaster/internal/parsing"
+	"github.com/evanj/pgxtypefaster/pgio"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var quoteArrayReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// maxArrayDimensions is the maximum number of array dimensions supported by Postgres (MAXDIM).
+const maxArrayDimensions = 6
+
+// ArrayHeader describes an array parsed by ParseArrayBinary or ParseArrayText.
+type ArrayHeader struct {
+	// Dimensions are the dimensions of the array, from outermost to innermost. It is empty for an
+	// empty array. The parse functions reuse the slice's capacity, so parsing many arrays with the
+	// same ArrayHeader does not allocate.
+	Dimensions []pgtype.ArrayDimension
+	// ContainsNull is the binary format flag that is set if any element is NULL. The text parser
+	// sets it if it finds a NULL element.
+	ContainsNull bool
+	// ElementOID is the element type OID from the binary format. It is zero for the text format.
+	ElementOID uint32
+}
+
+// ElementCount returns the total number of elements in the array.
+func (h *ArrayHeader) ElementCount() int {
+	if len(h.Dimensions) == 0 {
+		return 0
+	}
+	count := 1
+	for _, dim := range h.Dimensions {
+		count *= int(dim.Length)
+	}
+	return count
+}
+
+// ArrayBinaryElementFunc is called by ParseArrayBinary for each element, in order. index is the
+// position of the element in the flattened array. src is nil for NULL elements, and is a sub-slice
+// of the array, so it must be copied if it is retained.
+type ArrayBinaryElementFunc func(index int, src []byte) error
+
+// ParseArrayBinary parses an array in the Postgres binary format, storing the dimensions and
+// flags in header, then calling fn for each element. header is filled in before fn is called, so
+// fn can use header.ElementCount() to allocate the output when index is 0.
+func ParseArrayBinary(src []byte, header *ArrayHeader, fn ArrayBinaryElementFunc) error {
+	const dimensionLen = 8
+	const minElementLen = 4
+
+	var r pgio.Reader
+	r.Reset(src)
+	numDims := int(r.ReadInt32())
+	header.ContainsNull = r.ReadInt32() != 0
+	header.ElementOID = r.ReadUint32()
+	if r.Err() != nil {
+		return fmt.Errorf("array header too short: %w", r.Err())
+	}
+	if numDims < 0 || numDims > maxArrayDimensions {
+		return fmt.Errorf("invalid number of array dimensions: %d", numDims)
+	}
+	if r.Remaining() < numDims*dimensionLen {
+		return fmt.Errorf("array dimensions too short: %d bytes for %d dimensions", r.Remaining(), numDims)
+	}
+
+	header.Dimensions = header.Dimensions[:0]
+	count := 0
+	if numDims > 0 {
+		count = 1
+	}
+	// each element has at least a length, which limits the number of elements
+	maxCount := (r.Remaining() - numDims*dimensionLen) / minElementLen
+	for i := 0; i < numDims; i++ {
+		dim := pgtype.ArrayDimension{Length: r.ReadInt32(), LowerBound: r.ReadInt32()}
+		if dim.Length < 0 {
+			return fmt.Errorf("invalid array dimension length: %d", dim.Length)
+		}
+		header.Dimensions = append(header.Dimensions, dim)
+		if dim.Length > 0 && count > maxCount/int(dim.Length) {
+			return fmt.Errorf("array has more elements than its length permits")
+		}
+		count *= int(dim.Length)
+	}
+	if count > maxCount {
+		return fmt.Errorf("array has more elements than its length permits")
+	}
+
+	for i := 0; i < count; i++ {
+		elemSrc := r.ReadLengthPrefixed()
+		if r.Err() != nil {
+			return fmt.Errorf("array element %d: %w", i, r.Err())
+		}
+		if err := fn(i, elemSrc); err != nil {
+			return err
+		}
+	}
+	if r.Remaining() != 0 {
+		return fmt.Errorf("array has %d unexpected trailing bytes", r.Remaining())
+	}
+	return nil
+}
+
+// ArrayTextElementFunc is called by ParseArrayText for each element, in order. index is the
+// position of the element in the flattened array. valid is false for NULL elements. Elements that
+// do not contain escapes are substrings of the input, so parsing does not allocate for them.
+type ArrayTextElementFunc func(index int, src string, valid bool) error
+
+var errArrayNotRectangular = errors.New("multidimensional arrays must have sub-arrays with matching dimensions")
+
+// ParseArrayText parses an array in the Postgres text format with elements separated by
+// delimiter, which is ',' for all built-in types except box. It calls fn for each element, then
+// stores the dimensions in header. Since the dimensions are not known until the end of the array,
+// fn cannot use them.
+func ParseArrayText(src string, delimiter byte, header *ArrayHeader, fn ArrayTextElementFunc) error {
+	header.ContainsNull = false
+	header.ElementOID = 0
+
+	var bounds [maxArrayDimensions]pgtype.ArrayDimension
+	numBounds := 0
+	p := parsing.New(src)
+	skipArrayWhitespace(&p)
+	if b, _ := p.Peek(); b == '[' {
+		var err error
+		numBounds, err = parseArrayBounds(&p, &bounds)
+		if err != nil {
+			return err
+		}
+	}
+
+	unquotedDelimiters := ",}"
+	if delimiter != ',' {
+		unquotedDelimiters = string([]byte{delimiter, '}'})
+	}
+
+	// lengths are the dimension lengths; zero means not known yet
+	var lengths [maxArrayDimensions]int32
+	var counts [maxArrayDimensions]int32
+	numDims := 0
+	depth := 0
+	index := 0
+	expectElement := true
+	for {
+		skipArrayWhitespace(&p)
+		b, end := p.Peek()
+		if end {
+			return fmt.Errorf("array not terminated: %#v", src)
+		}
+
+		switch {
+		case b == '{':
+			if !expectElement || (numDims != 0 && depth >= numDims) {
+				return errArrayNotRectangular
+			}
+			if depth >= maxArrayDimensions {
+				return fmt.Errorf("array has more than %d dimensions", maxArrayDimensions)
+			}
+			depth++
+			counts[depth-1] = 0
+			p.Skip(1)
+			continue
+
+		case b == '}':
+			if depth == 0 {
+				return p.ErrorAt(p.Pos(), parsing.QuoteByte('{'))
+			}
+			if expectElement && counts[depth-1] > 0 {
+				return fmt.Errorf("array has a delimiter before '}': %#v", src)
+			}
+			if numDims == 0 {
+				if depth != 1 {
+					return errArrayNotRectangular
+				}
+				// empty array: must be the entire input
+				p.Skip(1)
+				if err := checkArrayEnd(&p, src); err != nil {
+					return err
+				}
+				if numBounds != 0 {
+					return fmt.Errorf("empty array cannot have dimensions: %#v", src)
+				}
+				header.Dimensions = header.Dimensions[:0]
+				return nil
+			}
+			if lengths[depth-1] == 0 {
+				lengths[depth-1] = counts[depth-1]
+			} else if lengths[depth-1] != counts[depth-1] {
+				return errArrayNotRectangular
+			}
+			depth--
+			p.Skip(1)
+			if depth == 0 {
+				return finishArrayText(&p, src, header, numDims, &lengths, numBounds, &bounds)
+			}
+			counts[depth-1]++
+			expectElement = false
+			continue
+
+		case b == delimiter:
+			if expectElement {
+				return fmt.Errorf("array has an unexpected delimiter: %#v", src)
+			}
+			p.Skip(1)
+			expectElement = true
+			continue
+		}
+
+		if !expectElement || depth == 0 {
+			return fmt.Errorf("array has an unexpected element: %#v", src)
+		}
+		if numDims == 0 {
+			numDims = depth
+		} else if depth != numDims {
+			return errArrayNotRectangular
+		}
+
+		var elem string
+		var err error
+		valid := true
+		if b == '"' {
+			p.Skip(1)
+			elem, err = p.ConsumeDoubleQuotedAnyEscape()
+		} else if isArrayNull(p.Remaining(), delimiter) {
+			p.Skip(len("NULL"))
+			valid = false
+			header.ContainsNull = true
+		} else {
+			start := p.Pos()
+			elem, err = p.ConsumeUnquoted(unquotedDelimiters)
+			// unescaped trailing whitespace is copied to the end of elem unchanged
+			elem = elem[:len(elem)-unescapedTrailingWhitespace(src[start:p.Pos()])]
+			if hasUnescapedQuoteOrBrace(src[start:p.Pos()]) {
+				return fmt.Errorf("array has an invalid unquoted element %#v", src[start:p.Pos()])
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(index, elem, valid); err != nil {
+			return err
+		}
+		index++
+		counts[depth-1]++
+		expectElement = false
+	}
+}
+
+// arrayWhitespace is the whitespace that Postgres ignores around array elements.
+const arrayWhitespace = " \t\n\r\v\f"
+
+func skipArrayWhitespace(p *parsing.Parser) {
+	for {
+		b, end := p.Peek()
+		if end || strings.IndexByte(arrayWhitespace, b) == -1 {
+			return
+		}
+		p.Skip(1)
+	}
+}
+
+// unescapedTrailingWhitespace returns the number of whitespace bytes at the end of raw that are
+// not escaped with a backslash, which Postgres ignores.
+func unescapedTrailingWhitespace(raw string) int {
+	end := len(raw)
+	for end > 0 && strings.IndexByte(arrayWhitespace, raw[end-1]) != -1 {
+		// the byte is escaped if it follows an odd number of backslashes
+		backslashes := 0
+		for i := end - 2; i >= 0 && raw[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			break
+		}
+		end--
+	}
+	return len(raw) - end
+}
+
+// hasUnescapedQuoteOrBrace returns true if raw contains a double quote or opening brace that is
+// not escaped with a backslash.
+func hasUnescapedQuoteOrBrace(raw string) bool {
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"', '{':
+			return true
+		}
+	}
+	return false
+}
+
+// isArrayNull returns true if s starts with an unquoted NULL element (case-insensitive).
+func isArrayNull(s string, delimiter byte) bool {
+	const null = "NULL"
+	if len(s) < len(null) || !strings.EqualFold(s[:len(null)], null) {
+		return false
+	}
+	if len(s) == len(null) {
+		return true
+	}
+	next := s[len(null)]
+	return next == delimiter || next == '}' || strings.IndexByte(arrayWhitespace, next) != -1
+}
+
+// parseArrayBounds parses the optional dimension decoration, like "[0:1][1:3]=", into bounds
+// and returns the number of dimensions.
+func parseArrayBounds(p *parsing.Parser, bounds *[maxArrayDimensions]pgtype.ArrayDimension) (int, error) {
+	numBounds := 0
+	for {
+		b, _ := p.Peek()
+		if b == '=' && numBounds > 0 {
+			p.Skip(1)
+			skipArrayWhitespace(p)
+			return numBounds, nil
+		}
+		if numBounds >= maxArrayDimensions {
+			return 0, fmt.Errorf("array has more than %d dimensions", maxArrayDimensions)
+		}
+		if err := p.ConsumeExpectedByte('['); err != nil {
+			return 0, err
+		}
+		lower, err := parseArrayBound(p, ':')
+		if err != nil {
+			return 0, err
+		}
+		upper, err := parseArrayBound(p, ']')
+		if err != nil {
+			return 0, err
+		}
+		length := int64(upper) - int64(lower) + 1
+		if length <= 0 || length > math.MaxInt32 {
+			return 0, fmt.Errorf("invalid array bounds [%d:%d]", lower, upper)
+		}
+		bounds[numBounds] = pgtype.ArrayDimension{Length: int32(length), LowerBound: lower}
+		numBounds++
+	}
+}
+
+// parseArrayBound parses a signed integer followed by end.
+func parseArrayBound(p *parsing.Parser, end byte) (int32, error) {
+	s, err := p.ConsumeUnquoted(string(end))
+	if err != nil {
+		return 0, err
+	}
+	if err := p.ConsumeExpectedByte(end); err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array bound %#v: %w", s, err)
+	}
+	return int32(n), nil
+}
+
+// checkArrayEnd returns an error if p contains anything other than whitespace.
+func checkArrayEnd(p *parsing.Parser, src string) error {
+	skipArrayWhitespace(p)
+	if !p.AtEnd() {
+		return fmt.Errorf("array has unexpected data after '}': %#v", src)
+	}
+	return nil
+}
+
+// finishArrayText checks the end of the array and the dimension decoration, then stores the
+// dimensions in header.
+func finishArrayText(
+	p *parsing.Parser, src string, header *ArrayHeader, numDims int,
+	lengths *[maxArrayDimensions]int32, numBounds int, bounds *[maxArrayDimensions]pgtype.ArrayDimension,
+) error {
+	if err := checkArrayEnd(p, src); err != nil {
+		return err
+	}
+	if numBounds != 0 && numBounds != numDims {
+		return fmt.Errorf("array has %d dimensions but %d bounds: %#v", numDims, numBounds, src)
+	}
+
+	header.Dimensions = header.Dimensions[:0]
+	for i := 0; i < numDims; i++ {
+		dim := pgtype.ArrayDimension{Length: lengths[i], LowerBound: 1}
+		if numBounds != 0 {
+			if bounds[i].Length != dim.Length {
+				return fmt.Errorf("array dimension %d has length %d but bounds [%d:%d]: %#v",
+					i+1, dim.Length, bounds[i].LowerBound, bounds[i].LowerBound+bounds[i].Length-1, src)
+			}
+			dim.LowerBound = bounds[i].LowerBound
+		}
+		header.Dimensions = append(header.Dimensions, dim)
+	}
+	return nil
+}
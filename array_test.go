@@ -0,0 +1,203 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// parseArrayText returns the result of ParseArrayText as a pgtype.Array, so it can be compared
+// with pgtype.
+func parseArrayText(t *testing.T, src string) (pgtype.Array[pgtype.Text], error) {
+	t.Helper()
+	var header pgxtypefaster.ArrayHeader
+	var elements []pgtype.Text
+	err := pgxtypefaster.ParseArrayText(src, ',', &header, func(index int, src string, valid bool) error {
+		if index != len(elements) {
+			t.Fatalf("index=%d; expected %d", index, len(elements))
+		}
+		elements = append(elements, pgtype.Text{String: src, Valid: valid})
+		return nil
+	})
+	return pgtype.Array[pgtype.Text]{Elements: elements, Dims: header.Dimensions, Valid: true}, err
+}
+
+func parseArrayBinary(src []byte) (pgtype.Array[pgtype.Text], error) {
+	var header pgxtypefaster.ArrayHeader
+	var elements []pgtype.Text
+	err := pgxtypefaster.ParseArrayBinary(src, &header, func(index int, src []byte) error {
+		if index == 0 {
+			elements = make([]pgtype.Text, 0, header.ElementCount())
+		}
+		elements = append(elements, pgtype.Text{String: string(src), Valid: src != nil})
+		return nil
+	})
+	return pgtype.Array[pgtype.Text]{Elements: elements, Dims: header.Dimensions, Valid: true}, err
+}
+
+// normalizeEmpty makes empty arrays from pgtype and the parse functions equal.
+func normalizeEmpty(a pgtype.Array[pgtype.Text]) pgtype.Array[pgtype.Text] {
+	if len(a.Elements) == 0 {
+		a.Elements = nil
+	}
+	if len(a.Dims) == 0 {
+		a.Dims = nil
+	}
+	return a
+}
+
+// TestParseArrayDifferential checks that the parse functions return the same results as pgtype.
+func TestParseArrayDifferential(t *testing.T) {
+	m := pgtype.NewMap()
+	values := []any{
+		[]pgtype.Text{},
+		[]pgtype.Text{{String: "a", Valid: true}},
+		[]pgtype.Text{{String: `with "quotes", \backslashes\ {braces}`, Valid: true}, {}, {String: "NULL", Valid: true}},
+		[]pgtype.Text{{String: "", Valid: true}, {String: " spaces ", Valid: true}, {String: "é", Valid: true}},
+		textArray([]pgtype.ArrayDimension{{Length: 2, LowerBound: 1}, {Length: 3, LowerBound: 1}},
+			"a", "b", "c", "d", "e", "f"),
+		textArray([]pgtype.ArrayDimension{{Length: 2, LowerBound: 1}, {Length: 2, LowerBound: 0}, {Length: 1, LowerBound: 1}},
+			"a", "b", "c", "d"),
+		textArray([]pgtype.ArrayDimension{{Length: 2, LowerBound: -3}}, "x", "y"),
+	}
+
+	for _, value := range values {
+		for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+			buf, err := m.Encode(pgtype.TextArrayOID, format, value, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expected pgtype.Array[pgtype.Text]
+			err = m.Scan(pgtype.TextArrayOID, format, buf, &expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var output pgtype.Array[pgtype.Text]
+			if format == pgtype.TextFormatCode {
+				output, err = parseArrayText(t, string(buf))
+			} else {
+				output, err = parseArrayBinary(buf)
+			}
+			if err != nil {
+				t.Fatalf("format=%d %#v: %s", format, string(buf), err)
+			}
+			if !reflect.DeepEqual(normalizeEmpty(output), normalizeEmpty(expected)) {
+				t.Errorf("format=%d %#v: output=%#v; expected %#v", format, string(buf), output, expected)
+			}
+		}
+	}
+}
+
+// textArray returns a pgtype.Array with dims and non-NULL elements.
+func textArray(dims []pgtype.ArrayDimension, elements ...string) pgtype.Array[pgtype.Text] {
+	a := pgtype.Array[pgtype.Text]{Dims: dims, Valid: true}
+	for _, element := range elements {
+		a.Elements = append(a.Elements, pgtype.Text{String: element, Valid: true})
+	}
+	return a
+}
+
+func TestParseArrayText(t *testing.T) {
+	// inputs that Postgres accepts but that pgtype does not generate or parse the same way
+	oneDim := func(length int32) []pgtype.ArrayDimension {
+		return []pgtype.ArrayDimension{{Length: length, LowerBound: 1}}
+	}
+	withNull := textArray(oneDim(4), "a", "b", "", "NULLx")
+	withNull.Elements[2] = pgtype.Text{}
+	for _, test := range []struct {
+		input    string
+		expected pgtype.Array[pgtype.Text]
+	}{
+		{` { a , "b" , null , NULLx } `, withNull},
+		{`[0:1][-1:0]={{a,b},{c,d}}`,
+			textArray([]pgtype.ArrayDimension{{Length: 2, LowerBound: 0}, {Length: 2, LowerBound: -1}}, "a", "b", "c", "d")},
+		{`{\"escaped\,unquoted\}}`, textArray(oneDim(1), `"escaped,unquoted}`)},
+		{`{"NULL",inner space}`, textArray(oneDim(2), "NULL", "inner space")},
+		// escaped trailing whitespace is kept: pgtype does not unescape unquoted elements
+		{`{a\ }`, textArray(oneDim(1), "a ")},
+		{`{a\\ }`, textArray(oneDim(1), `a\`)},
+		{`{ \ a\ \  , b}`, textArray(oneDim(2), " a  ", "b")},
+	} {
+		output, err := parseArrayText(t, test.input)
+		if err != nil {
+			t.Fatalf("%#v: %s", test.input, err)
+		}
+		if !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("%#v: output=%#v; expected %#v", test.input, output, test.expected)
+		}
+	}
+
+	for _, invalid := range []string{
+		``, `{`, `{a`, `{a,}`, `{,a}`, `{a}x`, `{{a},b}`, `{{a},{b,c}}`, `{a,{b}}`, `{{}}`,
+		`[1:2]={a}`, `[1:1]={}`, `[2:1]={a}`, `[1:1]`, `{"a}`, `{a"b}`, `{{{{{{{a}}}}}}}`,
+	} {
+		if _, err := parseArrayText(t, invalid); err == nil {
+			t.Errorf("ParseArrayText(%#v) expected error", invalid)
+		}
+	}
+}
+
+// TestParseArrayTextPostgres checks that ParseArrayText returns the same results as Postgres for
+// inputs that pgtype does not parse the same way.
+func TestParseArrayTextPostgres(t *testing.T) {
+	conn := newTestConn(t)
+	for _, input := range []string{
+		` { a , "b" , null , NULLx } `, `{\"escaped\,unquoted\}}`, `{a\ }`, `{a\\ }`, `{ \ a\ \  , b}`,
+		"{\ta\\\t\t}", `[0:1][-1:0]={{a,b},{c,d}}`,
+	} {
+		var expected pgtype.Array[pgtype.Text]
+		err := conn.QueryRow(context.Background(), `select $1::text::text[]`, input).Scan(&expected)
+		if err != nil {
+			t.Fatalf("%#v: %s", input, err)
+		}
+		output, err := parseArrayText(t, input)
+		if err != nil {
+			t.Fatalf("%#v: %s", input, err)
+		}
+		if !reflect.DeepEqual(normalizeEmpty(output), normalizeEmpty(expected)) {
+			t.Errorf("%#v: output=%#v; expected %#v", input, output, expected)
+		}
+	}
+}
+
+func TestParseArrayBinaryInvalid(t *testing.T) {
+	m := pgtype.NewMap()
+	valid, err := m.Encode(pgtype.TextArrayOID, pgtype.BinaryFormatCode, [][]string{{"a", "b"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(valid); i++ {
+		if _, err := parseArrayBinary(valid[:i]); err == nil {
+			t.Errorf("ParseArrayBinary(%#v) expected error for truncated input", valid[:i])
+		}
+	}
+	if _, err := parseArrayBinary(append(valid, 0)); err == nil {
+		t.Error("ParseArrayBinary expected error for trailing data")
+	}
+
+	// dimensions that overflow int
+	huge := []byte{0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 25, 0x7f, 0xff, 0xff, 0xff, 0, 0, 0, 1, 0x7f, 0xff, 0xff, 0xff, 0, 0, 0, 1}
+	if _, err := parseArrayBinary(huge); err == nil {
+		t.Error("ParseArrayBinary expected error for huge dimensions")
+	}
+}
+
+func BenchmarkParseArrayText(b *testing.B) {
+	const input = `{alpha,beta,"gamma delta",NULL,epsilon,zeta,eta,theta}`
+	var header pgxtypefaster.ArrayHeader
+	count := 0
+	fn := func(index int, src string, valid bool) error {
+		count++
+		return nil
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := pgxtypefaster.ParseArrayText(input, ',', &header, fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
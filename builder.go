@@ -0,0 +1,74 @@
+package pgxtypefaster
+
+import (
+	"database/sql/driver"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// HstoreBuilder builds hstore values to encode without allocating a map. Reset reuses its memory,
+// so a writer that builds many values with one HstoreBuilder and appends them to a reused buffer
+// does not allocate once the buffers have grown, except to escape keys or values that contain
+// '"' or '\'. Pairs are encoded in the order they are added. Adding a key more than once encodes
+// all the pairs, and Postgres keeps only one of them. The zero value is an empty builder.
+//
+// A *HstoreBuilder can be passed directly as a query argument: it implements HstoreValuer for
+// HstoreCodec, which allocates a map, and driver.Valuer, which allocates the text format.
+type HstoreBuilder struct {
+	pairs []HstorePair
+}
+
+// Ensure a *HstoreBuilder can be used as a query argument with pgx and database/sql.
+var _ HstoreValuer = (*HstoreBuilder)(nil)
+var _ driver.Valuer = (*HstoreBuilder)(nil)
+
+// Add adds a pair with a non-NULL value.
+func (b *HstoreBuilder) Add(key string, value string) {
+	b.pairs = append(b.pairs, HstorePair{key, NewText(value)})
+}
+
+// AddNull adds a pair with a NULL value.
+func (b *HstoreBuilder) AddNull(key string) {
+	b.pairs = append(b.pairs, HstorePair{key, pgtype.Text{}})
+}
+
+// Len returns the number of pairs that have been added.
+func (b *HstoreBuilder) Len() int {
+	return len(b.pairs)
+}
+
+// EncodeTextAppend appends the pairs to dst in the hstore text format and returns the result.
+func (b *HstoreBuilder) EncodeTextAppend(dst []byte) []byte {
+	return appendPairsText(dst, b.pairs)
+}
+
+// EncodeBinaryAppend appends the pairs to dst in the hstore binary format and returns the result.
+func (b *HstoreBuilder) EncodeBinaryAppend(dst []byte) []byte {
+	return appendPairsBinary(dst, b.pairs)
+}
+
+// Reset removes all pairs, keeping the memory to reuse. It does not retain the strings.
+func (b *HstoreBuilder) Reset() {
+	for i := range b.pairs {
+		b.pairs[i] = HstorePair{}
+	}
+	b.pairs = b.pairs[:0]
+}
+
+// HstoreValue returns the pairs as an Hstore. If a key was added more than once, the first value is
+// used, like Postgres.
+func (b *HstoreBuilder) HstoreValue() (Hstore, error) {
+	h := make(Hstore, len(b.pairs))
+	for _, pair := range b.pairs {
+		if _, exists := h[pair.Key]; !exists {
+			h[pair.Key] = pair.Value
+		}
+	}
+	return h, nil
+}
+
+// Value implements the database/sql/driver Valuer interface. It returns the text format.
+func (b *HstoreBuilder) Value() (driver.Value, error) {
+	// buf was allocated here and is not used again
+	return ownedBytesToString(appendPairsText(nil, b.pairs)), nil
+}
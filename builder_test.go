@@ -0,0 +1,98 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreBuilder(t *testing.T) {
+	var b pgxtypefaster.HstoreBuilder
+	b.Add("a", "1")
+	b.AddNull("b")
+	b.Add(`k"\`, `v"\`)
+	if b.Len() != 3 {
+		t.Errorf("Len()=%d; expected 3", b.Len())
+	}
+
+	text := b.EncodeTextAppend([]byte("prefix:"))
+	expectedText := `prefix:"a"=>"1", "b"=>NULL, "k\"\\"=>"v\"\\"`
+	if string(text) != expectedText {
+		t.Errorf("EncodeTextAppend=%#v; expected %#v", string(text), expectedText)
+	}
+
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}, `k"\`: pgxtypefaster.NewText(`v"\`)}
+	m := newTestTypeMap()
+	bufs := map[int16][]byte{
+		pgtype.TextFormatCode:   text[len("prefix:"):],
+		pgtype.BinaryFormatCode: b.EncodeBinaryAppend(nil),
+	}
+	for format, buf := range bufs {
+		var h pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &h); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(h, expected) {
+			t.Errorf("format=%d: scanned %#v; expected %#v", format, h, expected)
+		}
+	}
+
+	b.Reset()
+	if b.Len() != 0 || len(b.EncodeTextAppend(nil)) != 0 {
+		t.Error("Reset must remove all pairs")
+	}
+
+	buf := make([]byte, 0, 100)
+	allocs := testing.AllocsPerRun(10, func() {
+		b.Reset()
+		b.Add("key1", "value1")
+		b.AddNull("key2")
+		buf = b.EncodeTextAppend(buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("reusing the builder allocated %f times; expected 0", allocs)
+	}
+}
+
+func TestHstoreBuilderQueryArgument(t *testing.T) {
+	var b pgxtypefaster.HstoreBuilder
+	b.Add("a", "1")
+	b.AddNull("b")
+	b.Add("a", "2")
+
+	// Postgres keeps the first value for duplicate keys
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+	h, err := b.HstoreValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("HstoreValue()=%#v; expected %#v", h, expected)
+	}
+
+	value, err := b.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedText := `"a"=>"1", "b"=>NULL, "a"=>"2"`
+	if value != expectedText {
+		t.Errorf("Value()=%#v; expected %#v", value, expectedText)
+	}
+
+	m := newTestTypeMap()
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		buf, err := m.Encode(testHstoreOID, format, &b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var scanned pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &scanned); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(scanned, expected) {
+			t.Errorf("format=%d: encoded %#v; expected %#v", format, scanned, expected)
+		}
+	}
+}
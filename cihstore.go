@@ -0,0 +1,97 @@
+package pgxtypefaster
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CIHstore is an hstore with case-insensitive keys. Keys are normalized when they are scanned,
+// set, and looked up, so lookups match keys with any case. It is scanned and encoded by
+// HstoreCodec, since it implements HstoreScanner and HstoreValuer. A nil Hstore is NULL.
+type CIHstore struct {
+	// Hstore contains the pairs with normalized keys.
+	Hstore Hstore
+	// Normalize folds keys. The default (nil) is strings.ToLower. Set it before scanning.
+	Normalize func(key string) string
+}
+
+func (h CIHstore) normalize(key string) string {
+	if h.Normalize == nil {
+		return strings.ToLower(key)
+	}
+	return h.Normalize(key)
+}
+
+// ScanHstore sets the pairs from v with normalized keys. It returns an error if two keys are equal
+// after they are normalized, since only one of the values could be kept.
+func (h *CIHstore) ScanHstore(v Hstore) error {
+	changed := false
+	for k := range v {
+		if h.normalize(k) != k {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		// all keys are already normalized: use v without copying it
+		h.Hstore = v
+		return nil
+	}
+
+	normalized := make(Hstore, len(v))
+	for k, value := range v {
+		key := h.normalize(k)
+		if _, exists := normalized[key]; exists {
+			return fmt.Errorf("hstore has multiple keys that normalize to %#v", key)
+		}
+		normalized[key] = value
+	}
+	h.Hstore = normalized
+	return nil
+}
+
+func (h CIHstore) HstoreValue() (Hstore, error) {
+	return h.Hstore, nil
+}
+
+// Get returns the value of key, and true if the normalized key exists.
+func (h CIHstore) Get(key string) (pgtype.Text, bool) {
+	value, ok := h.Hstore[h.normalize(key)]
+	return value, ok
+}
+
+// Set sets the value of the normalized key. If the Hstore is nil, it is allocated.
+func (h *CIHstore) Set(key string, value pgtype.Text) {
+	if h.Hstore == nil {
+		h.Hstore = make(Hstore)
+	}
+	h.Hstore[h.normalize(key)] = value
+}
+
+// Delete removes the normalized key.
+func (h *CIHstore) Delete(key string) {
+	delete(h.Hstore, h.normalize(key))
+}
+
+// Ensure CIHstore works with database/sql and libraries built on it, such as sqlx.
+var _ sql.Scanner = (*CIHstore)(nil)
+var _ driver.Valuer = CIHstore{}
+
+// Scan implements the database/sql Scanner interface. It accepts the text format as a string or
+// a []byte.
+func (h *CIHstore) Scan(src any) error {
+	var scanned Hstore
+	if err := scanned.Scan(src); err != nil {
+		return err
+	}
+	return h.ScanHstore(scanned)
+}
+
+// Value implements the database/sql/driver Valuer interface.
+func (h CIHstore) Value() (driver.Value, error) {
+	return h.Hstore.Value()
+}
@@ -0,0 +1,75 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCIHstore(t *testing.T) {
+	m := newTestTypeMap()
+	input := pgxtypefaster.Hstore{"Key": pgxtypefaster.NewText("v"), "other": pgtype.Text{}}
+	expected := pgxtypefaster.Hstore{"key": pgxtypefaster.NewText("v"), "other": pgtype.Text{}}
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h pgxtypefaster.CIHstore
+		if err := m.Scan(testHstoreOID, format, buf, &h); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(h.Hstore, expected) {
+			t.Errorf("format=%d: scanned %#v; expected %#v", format, h.Hstore, expected)
+		}
+		for _, key := range []string{"key", "KEY", "kEy"} {
+			if v, ok := h.Get(key); !ok || v != pgxtypefaster.NewText("v") {
+				t.Errorf("format=%d: Get(%#v)=%#v, %t", format, key, v, ok)
+			}
+		}
+
+		// round trip the normalized keys
+		buf, err = m.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, expected) {
+			t.Errorf("format=%d: encoded %#v; expected %#v", format, out, expected)
+		}
+
+		// NULL
+		h = pgxtypefaster.CIHstore{}
+		if err := m.Scan(testHstoreOID, format, nil, &h); err != nil || h.Hstore != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, h.Hstore, err)
+		}
+	}
+
+	// keys that are equal after normalizing
+	var h pgxtypefaster.CIHstore
+	err := h.Scan(`"a"=>"1", "A"=>"2"`)
+	if err == nil || !strings.Contains(err.Error(), "normalize") {
+		t.Errorf("Scan with duplicate normalized keys must fail: %v", err)
+	}
+
+	h = pgxtypefaster.CIHstore{Normalize: strings.ToUpper}
+	if err := h.Scan(`"a"=>"1"`); err != nil {
+		t.Fatal(err)
+	}
+	h.Set("b", pgxtypefaster.NewText("2"))
+	if _, ok := h.Hstore["B"]; !ok {
+		t.Errorf("Set must use Normalize: %#v", h.Hstore)
+	}
+	h.Delete("A")
+	value, err := h.Value()
+	if err != nil || value != `"B"=>"2"` {
+		t.Errorf("Value()=%#v, %v", value, err)
+	}
+}
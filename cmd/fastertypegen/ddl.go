@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namePattern matches a possibly schema-qualified name, where each part may be double-quoted.
+const namePattern = `((?:"(?:[^"]|"")*"|[^\s"(.]+)(?:\.(?:"(?:[^"]|"")*"|[^\s"(.]+))?)`
+
+var (
+	createEnumPattern = regexp.MustCompile(
+		`(?is)^CREATE\s+TYPE\s+` + namePattern + `\s+AS\s+ENUM\s*\((.*)\)$`)
+	createCompositePattern = regexp.MustCompile(
+		`(?is)^CREATE\s+TYPE\s+` + namePattern + `\s+AS\s*\((.*)\)$`)
+	createDomainPattern = regexp.MustCompile(
+		`(?is)^CREATE\s+DOMAIN\s+` + namePattern + `\s+(?:AS\s+)?(.+?)(?:\s+(?:COLLATE|DEFAULT|CONSTRAINT|NOT|NULL|CHECK)\b.*)?$`)
+	collatePattern = regexp.MustCompile(`(?is)\s+COLLATE\s+\S+`)
+)
+
+// parseDDL returns the enum, composite, and domain types created by the SQL statements in ddl.
+// Other statements are ignored.
+func parseDDL(ddl string) ([]*typeDef, error) {
+	statements, err := splitStatements(ddl)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*typeDef
+	for _, statement := range statements {
+		var def *typeDef
+		if match := createEnumPattern.FindStringSubmatch(statement); match != nil {
+			labels, err := parseEnumLabels(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("enum %s: %w", match[1], err)
+			}
+			def = &typeDef{SQLName: unqualifiedName(match[1]), Kind: enumKind, Labels: labels}
+		} else if match := createCompositePattern.FindStringSubmatch(statement); match != nil {
+			fields, err := parseCompositeFields(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("composite type %s: %w", match[1], err)
+			}
+			def = &typeDef{SQLName: unqualifiedName(match[1]), Kind: compositeKind, Fields: fields}
+		} else if match := createDomainPattern.FindStringSubmatch(statement); match != nil {
+			base, err := lookupPGType(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("domain %s: %w", match[1], err)
+			}
+			def = &typeDef{SQLName: unqualifiedName(match[1]), Kind: domainKind, Base: base}
+		} else {
+			continue
+		}
+		def.GoName = goName(def.SQLName)
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// splitStatements splits ddl into statements separated by semicolons, removing comments and
+// surrounding whitespace. It handles single-quoted strings and double-quoted identifiers, but not
+// dollar-quoted strings.
+func splitStatements(ddl string) ([]string, error) {
+	var statements []string
+	var current strings.Builder
+	for i := 0; i < len(ddl); i++ {
+		b := ddl[i]
+		switch {
+		case b == '-' && strings.HasPrefix(ddl[i:], "--"):
+			end := strings.IndexByte(ddl[i:], '\n')
+			if end == -1 {
+				i = len(ddl)
+			} else {
+				i += end
+			}
+			current.WriteByte(' ')
+		case b == '/' && strings.HasPrefix(ddl[i:], "/*"):
+			end := strings.Index(ddl[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += end + 3
+			current.WriteByte(' ')
+		case b == '\'' || b == '"':
+			end := quotedEnd(ddl, i)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quote at offset %d", i)
+			}
+			current.WriteString(ddl[i:end])
+			i = end - 1
+		case b == ';':
+			if statement := strings.TrimSpace(current.String()); statement != "" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+		default:
+			current.WriteByte(b)
+		}
+	}
+	if statement := strings.TrimSpace(current.String()); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+// quotedEnd returns the offset after the quoted string starting at s[start], where the quote
+// character is escaped by doubling it. It returns -1 if the quote is not terminated.
+func quotedEnd(s string, start int) int {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// parseEnumLabels parses a comma-separated list of single-quoted strings.
+func parseEnumLabels(list string) ([]string, error) {
+	var labels []string
+	for _, item := range splitTopLevel(list) {
+		if len(item) < 2 || item[0] != '\'' || quotedEnd(item, 0) != len(item) {
+			return nil, fmt.Errorf("invalid enum label %#v", item)
+		}
+		labels = append(labels, strings.ReplaceAll(item[1:len(item)-1], "''", "'"))
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("enum has no labels")
+	}
+	return labels, nil
+}
+
+// parseCompositeFields parses a comma-separated list of "name type" field definitions.
+func parseCompositeFields(list string) ([]fieldDef, error) {
+	var fields []fieldDef
+	for _, item := range splitTopLevel(list) {
+		item = collatePattern.ReplaceAllString(item, "")
+		var name, typeName string
+		if strings.HasPrefix(item, `"`) {
+			end := quotedEnd(item, 0)
+			if end == -1 {
+				return nil, fmt.Errorf("invalid field %#v", item)
+			}
+			name = strings.ReplaceAll(item[1:end-1], `""`, `"`)
+			typeName = strings.TrimSpace(item[end:])
+		} else {
+			var ok bool
+			name, typeName, ok = strings.Cut(item, " ")
+			if !ok {
+				return nil, fmt.Errorf("invalid field %#v", item)
+			}
+			name = strings.ToLower(name)
+		}
+
+		t, err := lookupPGType(typeName)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		if !t.CompositeField {
+			return nil, fmt.Errorf("field %s: type %s is not supported in composite types", name, typeName)
+		}
+		fields = append(fields, fieldDef{SQLName: name, GoName: goName(name), Type: t})
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("composite type has no fields")
+	}
+	return fields, nil
+}
+
+// splitTopLevel splits list on commas that are not inside parentheses or quotes, trimming
+// whitespace from each item.
+func splitTopLevel(list string) []string {
+	var items []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '\'', '"':
+			if end := quotedEnd(list, i); end != -1 {
+				i = end - 1
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(list[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+// unqualifiedName removes the schema and quotes from a type name.
+func unqualifiedName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+	if strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) && len(name) >= 2 {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return strings.ToLower(name)
+}
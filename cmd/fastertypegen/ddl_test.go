@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDDL(t *testing.T) {
+	const ddl = `
+-- comment; with a semicolon
+CREATE TABLE ignored (id integer);
+create type public."Weird Enum" as enum ('a', 'it''s', 'b;c');
+/* block
+comment */
+CREATE TYPE item AS (
+	"Quoted Name" text COLLATE "C",
+	count INTEGER,
+	label character varying(20)
+);
+CREATE DOMAIN positive AS bigint CHECK (VALUE > 0)`
+
+	defs, err := parseDDL(ddl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*typeDef{
+		{SQLName: "Weird Enum", GoName: "WeirdEnum", Kind: enumKind, Labels: []string{"a", "it's", "b;c"}},
+		{SQLName: "item", GoName: "Item", Kind: compositeKind, Fields: []fieldDef{
+			{"Quoted Name", "QuotedName", pgTypes["text"]},
+			{"count", "Count", pgTypes["int4"]},
+			{"label", "Label", pgTypes["varchar"]},
+		}},
+		{SQLName: "positive", GoName: "Positive", Kind: domainKind, Base: pgTypes["int8"]},
+	}
+	if !reflect.DeepEqual(defs, expected) {
+		t.Errorf("parseDDL()=%#v; expected %#v", defs, expected)
+	}
+
+	for _, invalid := range []string{
+		`CREATE TYPE e AS ENUM ()`,
+		`CREATE TYPE e AS ENUM (a)`,
+		`CREATE TYPE c AS (x numeric)`,
+		`CREATE TYPE c AS (x hstore)`,
+		`CREATE DOMAIN d AS numeric`,
+		`CREATE TYPE e AS ENUM ('a`,
+		`/* unterminated`,
+	} {
+		if _, err := parseDDL(invalid); err == nil {
+			t.Errorf("parseDDL(%#v) expected error", invalid)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected string
+	}{
+		{"supplier_id", "SupplierID"},
+		{"very happy", "VeryHappy"},
+		{"HTTPStatus", "Httpstatus"},
+		{"2fa", "X2fa"},
+		{"---", ""},
+	} {
+		if output := goName(test.input); output != test.expected {
+			t.Errorf("goName(%#v)=%#v; expected %#v", test.input, output, test.expected)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package example contains code generated by fastertypegen from types.sql. It is used to test the
+// generator: the generated tests check that the codecs round trip values.
+package example
+
+//go:generate go run .. -ddl types.sql -out types.go
@@ -0,0 +1,36 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TestScanPostgresText checks the composite text parser with output from Postgres.
+func TestScanPostgresText(t *testing.T) {
+	// select row('a "b", \c', 1, 'Infinity', true, null, null, null, '')::inventory_item
+	const input = `("a ""b"", \\c",1,Infinity,t,,,,"")`
+	var item InventoryItem
+	if err := item.Scan(input); err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != (pgtype.Text{String: `a "b", \c`, Valid: true}) {
+		t.Errorf("Name=%#v", item.Name)
+	}
+	if item.Price.Float64 <= 1e308 || item.Shelf.Valid || item.Sku != (pgtype.Text{Valid: true}) {
+		t.Errorf("item=%#v", item)
+	}
+	value, err := item.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != input {
+		t.Errorf("Value()=%#v; expected %#v", value, input)
+	}
+
+	for _, invalid := range []string{``, `(`, `(a)`, `("a",1,2,t,1,2,3,x,y)`, `(,,,,,,,) x`, `(x,y,,,,,,)`} {
+		if err := item.Scan(invalid); err == nil {
+			t.Errorf("Scan(%#v) expected error", invalid)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+-- Example types used to test fastertypegen. Run go generate to regenerate types.go.
+CREATE EXTENSION IF NOT EXISTS hstore;
+
+CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy', 'very happy');
+
+CREATE TYPE inventory_item AS (
+    name text,
+    supplier_id integer,
+    price double precision,
+    active boolean,
+    shelf smallint,
+    serial bigint,
+    weight real,
+    sku varchar(20)
+);
+
+CREATE DOMAIN email AS text CHECK (VALUE LIKE '%@%');
+
+CREATE DOMAIN attributes AS hstore NOT NULL;
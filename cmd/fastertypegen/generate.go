@@ -0,0 +1,609 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// generateConfig contains the inputs for generating code.
+type generateConfig struct {
+	// Package is the Go package name for the generated code.
+	Package string
+	// RegisterFunc is the name of the generated registration function.
+	RegisterFunc string
+	// Source describes where the type definitions came from, for the header comment.
+	Source string
+	Types  []*typeDef
+}
+
+// enumConst is a Go constant for an enum label.
+type enumConst struct {
+	Name  string
+	Label string
+}
+
+// EnumConsts returns the Go constants for an enum's labels. Labels that do not produce a valid
+// unique Go name are named by their position.
+func (t *typeDef) EnumConsts() []enumConst {
+	consts := make([]enumConst, len(t.Labels))
+	used := map[string]bool{}
+	for i, label := range t.Labels {
+		name := t.GoName + goName(label)
+		if name == t.GoName || used[name] {
+			name = t.GoName + "Label" + strconv.Itoa(i)
+		}
+		used[name] = true
+		consts[i] = enumConst{name, label}
+	}
+	return consts
+}
+
+// generatedFiles is the output of generate.
+type generatedFiles struct {
+	Code []byte
+	Test []byte
+}
+
+func generate(config generateConfig) (*generatedFiles, error) {
+	if len(config.Types) == 0 {
+		return nil, fmt.Errorf("no types to generate")
+	}
+	goNames := map[string]string{}
+	for _, t := range config.Types {
+		if t.GoName == "" {
+			return nil, fmt.Errorf("type %s: cannot create a Go name", t.SQLName)
+		}
+		if other, exists := goNames[t.GoName]; exists {
+			return nil, fmt.Errorf("types %s and %s have the same Go name %s", other, t.SQLName, t.GoName)
+		}
+		goNames[t.GoName] = t.SQLName
+		for i, field := range t.Fields {
+			if field.GoName == "" {
+				return nil, fmt.Errorf("type %s field %s: cannot create a Go name", t.SQLName, field.SQLName)
+			}
+			for _, other := range t.Fields[:i] {
+				if other.GoName == field.GoName {
+					return nil, fmt.Errorf("type %s: fields %s and %s have the same Go name %s",
+						t.SQLName, other.SQLName, field.SQLName, field.GoName)
+				}
+			}
+		}
+	}
+
+	data := newTemplateData(config)
+	code, err := executeTemplate(codeTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	test, err := executeTemplate(testTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	return &generatedFiles{code, test}, nil
+}
+
+// templateData is the data passed to the templates.
+type templateData struct {
+	generateConfig
+	// StdImports and Imports are the standard library and other imports of the generated code.
+	StdImports  []string
+	Imports     []string
+	TestImports []string
+	// Helpers is the Go source for the runtime helpers needed by the composite types.
+	Helpers string
+}
+
+func newTemplateData(config generateConfig) *templateData {
+	imports := map[string]bool{
+		"context":                        true,
+		"fmt":                            true,
+		"github.com/jackc/pgx/v5":        true,
+		"github.com/jackc/pgx/v5/pgtype": true,
+		"database/sql/driver":            false,
+		"encoding/binary":                false,
+		"math":                           false,
+		"strconv":                        false,
+		"strings":                        false,
+		"github.com/evanj/pgxtypefaster": false,
+	}
+	testImports := []string{"reflect", "testing", "github.com/jackc/pgx/v5/pgtype"}
+
+	usedKinds := map[string]bool{}
+	hasEnumOrComposite := false
+	for _, t := range config.Types {
+		switch t.Kind {
+		case enumKind:
+			imports["database/sql/driver"] = true
+			hasEnumOrComposite = true
+		case compositeKind:
+			hasEnumOrComposite = true
+			for _, field := range t.Fields {
+				usedKinds[field.Type.Kind] = true
+			}
+		case domainKind:
+			if t.Base.Kind == "Hstore" {
+				imports["github.com/evanj/pgxtypefaster"] = true
+			}
+		}
+	}
+	if !hasEnumOrComposite {
+		testImports = nil
+	}
+
+	var helpers strings.Builder
+	if len(usedKinds) > 0 {
+		imports["database/sql/driver"] = true
+		imports["encoding/binary"] = true
+		imports["strings"] = true
+		helpers.WriteString(compositeHelpers)
+
+		kinds := make([]string, 0, len(usedKinds))
+		for kind := range usedKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			helpers.WriteString(compositeKindHelpers[kind])
+			switch kind {
+			case "Int2", "Int4", "Int8", "Float4", "Float8":
+				imports["strconv"] = true
+			}
+			switch kind {
+			case "Float4", "Float8":
+				imports["math"] = true
+			}
+		}
+		if usedKinds["Float4"] || usedKinds["Float8"] {
+			helpers.WriteString(compositeFloatHelpers)
+		}
+	}
+
+	data := &templateData{generateConfig: config, TestImports: testImports, Helpers: helpers.String()}
+	for path, used := range imports {
+		if !used {
+			continue
+		}
+		if strings.Contains(path, ".") {
+			data.Imports = append(data.Imports, path)
+		} else {
+			data.StdImports = append(data.StdImports, path)
+		}
+	}
+	sort.Strings(data.StdImports)
+	sort.Strings(data.Imports)
+	return data
+}
+
+func executeTemplate(tmpl *template.Template, data *templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %w\n%s", err, buf.String())
+	}
+	return formatted, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+}
+
+var codeTemplate = template.Must(template.New("code").Funcs(templateFuncs).Parse(`// Code generated by fastertypegen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	{{quote .}}
+{{- end}}
+
+{{range .Imports}}
+	{{quote .}}
+{{- end}}
+)
+
+// {{.RegisterFunc}} registers the generated types with conn's type map. It queries the database
+// for the type OIDs, since they can be different in each database.
+func {{.RegisterFunc}}(ctx context.Context, conn *pgx.Conn) error {
+	types := []*pgtype.Type{
+{{- range .Types}}
+	{{- if eq .Kind 2}}
+		{Codec: {{.Base.Codec}}, Name: {{quote .SQLName}}},
+	{{- else}}
+		{Codec: {{.GoName}}Codec{}, Name: {{quote .SQLName}}},
+	{{- end}}
+{{- end}}
+	}
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.Name
+	}
+
+	rows, err := conn.Query(ctx, "select typname::text, oid from pg_type where typname = any($1)", names)
+	if err != nil {
+		return err
+	}
+	oids := map[string]uint32{}
+	var name string
+	var oid uint32
+	_, err = pgx.ForEachRow(rows, []any{&name, &oid}, func() error {
+		oids[name] = oid
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, t := range types {
+		oid, ok := oids[t.Name]
+		if !ok {
+			return fmt.Errorf("postgres type %s does not exist", t.Name)
+		}
+		t.OID = oid
+		conn.TypeMap().RegisterType(t)
+	}
+	return nil
+}
+{{range .Types}}
+{{- $t := .}}
+{{- if eq .Kind 0}}
+// {{.GoName}} represents the Postgres enum type {{.SQLName}}.
+type {{.GoName}} string
+
+const (
+{{- range .EnumConsts}}
+	{{.Name}} {{$t.GoName}} = {{quote .Label}}
+{{- end}}
+)
+
+
+// {{.GoName}}Codec is the pgtype.Codec for {{.GoName}}. Scanning returns the constants, so it does
+// not allocate.
+type {{.GoName}}Codec struct{}
+
+func ({{.GoName}}Codec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func ({{.GoName}}Codec) PreferredFormat() int16 {
+	return pgtype.TextFormatCode
+}
+
+func ({{.GoName}}Codec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if _, ok := value.({{.GoName}}); !ok {
+		return nil
+	}
+	// the binary format is the same as the text format
+	return encodePlan{{.GoName}}Codec{}
+}
+
+type encodePlan{{.GoName}}Codec struct{}
+
+func (encodePlan{{.GoName}}Codec) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	return append(buf, value.({{.GoName}})...), nil
+}
+
+func ({{.GoName}}Codec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(*{{.GoName}}); !ok {
+		return nil
+	}
+	return scanPlan{{.GoName}}Codec{}
+}
+
+type scanPlan{{.GoName}}Codec struct{}
+
+func (scanPlan{{.GoName}}Codec) Scan(src []byte, dst any) error {
+	if src == nil {
+		return fmt.Errorf("cannot scan NULL into *{{.GoName}}")
+	}
+	value, err := parse{{.GoName}}(src)
+	if err != nil {
+		return err
+	}
+	*(dst.(*{{.GoName}})) = value
+	return nil
+}
+
+func parse{{.GoName}}(src []byte) ({{.GoName}}, error) {
+	switch string(src) {
+{{- range .EnumConsts}}
+	case string({{.Name}}):
+		return {{.Name}}, nil
+{{- end}}
+	}
+	return "", fmt.Errorf("invalid {{.SQLName}} label %#v", string(src))
+}
+
+func ({{.GoName}}Codec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	if src == nil {
+		return nil, nil
+	}
+	value, err := parse{{.GoName}}(src)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func ({{.GoName}}Codec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	if src == nil {
+		return nil, nil
+	}
+	return parse{{.GoName}}(src)
+}
+{{- else if eq .Kind 1}}
+// {{.GoName}} represents the Postgres composite type {{.SQLName}}.
+type {{.GoName}} struct {
+{{- range .Fields}}
+	{{.GoName}} {{.Type.GoType}}
+{{- end}}
+}
+
+// Scan implements the database/sql Scanner interface.
+func (v *{{.GoName}}) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return scanText{{.GoName}}(src, v)
+	case []byte:
+		return scanText{{.GoName}}(string(src), v)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into *{{.GoName}}")
+	}
+	return fmt.Errorf("cannot scan %T", src)
+}
+
+// Value implements the database/sql/driver Valuer interface.
+func (v {{.GoName}}) Value() (driver.Value, error) {
+	buf, err := encodePlan{{.GoName}}CodecText{}.Encode(v, nil)
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+// {{.GoName}}Codec is the pgtype.Codec for {{.GoName}}.
+type {{.GoName}}Codec struct{}
+
+func ({{.GoName}}Codec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func ({{.GoName}}Codec) PreferredFormat() int16 {
+	return pgtype.BinaryFormatCode
+}
+
+func ({{.GoName}}Codec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if _, ok := value.({{.GoName}}); !ok {
+		return nil
+	}
+
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return encodePlan{{.GoName}}CodecBinary{}
+	case pgtype.TextFormatCode:
+		return encodePlan{{.GoName}}CodecText{}
+	}
+
+	return nil
+}
+
+type encodePlan{{.GoName}}CodecBinary struct{}
+
+func (encodePlan{{.GoName}}CodecBinary) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	v := value.({{.GoName}})
+	buf = binary.BigEndian.AppendUint32(buf, {{len .Fields}})
+{{- range .Fields}}
+	buf = appendCompositeBinary{{.Type.Kind}}(buf, {{.Type.OIDConst}}, v.{{.GoName}})
+{{- end}}
+	return buf, nil
+}
+
+type encodePlan{{.GoName}}CodecText struct{}
+
+func (encodePlan{{.GoName}}CodecText) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	v := value.({{.GoName}})
+	buf = append(buf, '(')
+{{- range $i, $field := .Fields}}
+{{- if $i}}
+	buf = append(buf, ',')
+{{- end}}
+	buf = appendCompositeText{{.Type.Kind}}(buf, v.{{.GoName}})
+{{- end}}
+	return append(buf, ')'), nil
+}
+
+func ({{.GoName}}Codec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(*{{.GoName}}); !ok {
+		return nil
+	}
+
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return scanPlan{{.GoName}}CodecBinary{}
+	case pgtype.TextFormatCode:
+		return scanPlan{{.GoName}}CodecText{}
+	}
+
+	return nil
+}
+
+type scanPlan{{.GoName}}CodecBinary struct{}
+
+func (scanPlan{{.GoName}}CodecBinary) Scan(src []byte, dst any) error {
+	if src == nil {
+		return fmt.Errorf("cannot scan NULL into *{{.GoName}}")
+	}
+
+	reader, err := readCompositeBinaryHeader(src, {{len .Fields}})
+	if err != nil {
+		return err
+	}
+	var v {{.GoName}}
+	var fieldSrc []byte
+{{- range .Fields}}
+	fieldSrc, err = reader.next()
+	if err != nil {
+		return err
+	}
+	v.{{.GoName}}, err = scanCompositeBinary{{.Type.Kind}}(fieldSrc)
+	if err != nil {
+		return fmt.Errorf("{{$t.SQLName}}.{{.SQLName}}: %w", err)
+	}
+{{- end}}
+	*(dst.(*{{.GoName}})) = v
+	return nil
+}
+
+type scanPlan{{.GoName}}CodecText struct{}
+
+func (scanPlan{{.GoName}}CodecText) Scan(src []byte, dst any) error {
+	if src == nil {
+		return fmt.Errorf("cannot scan NULL into *{{.GoName}}")
+	}
+	return scanText{{.GoName}}(string(src), dst.(*{{.GoName}}))
+}
+
+func scanText{{.GoName}}(src string, dst *{{.GoName}}) error {
+	var fields [{{len .Fields}}]compositeTextField
+	err := parseCompositeText(src, fields[:])
+	if err != nil {
+		return err
+	}
+	var v {{.GoName}}
+{{- range $i, $field := .Fields}}
+	v.{{.GoName}}, err = scanCompositeText{{.Type.Kind}}(fields[{{$i}}])
+	if err != nil {
+		return fmt.Errorf("{{$t.SQLName}}.{{.SQLName}}: %w", err)
+	}
+{{- end}}
+	*dst = v
+	return nil
+}
+
+func (c {{.GoName}}Codec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	if src == nil {
+		return nil, nil
+	}
+	if format == pgtype.TextFormatCode {
+		return string(src), nil
+	}
+	value, err := c.DecodeValue(m, oid, format, src)
+	if err != nil {
+		return nil, err
+	}
+	return value.({{.GoName}}).Value()
+}
+
+func (c {{.GoName}}Codec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	if src == nil {
+		return nil, nil
+	}
+	var v {{.GoName}}
+	err := c.PlanScan(m, oid, format, &v).Scan(src, &v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+{{- else}}
+// {{.GoName}} represents the Postgres domain {{.SQLName}}.
+type {{.GoName}} = {{.Base.GoType}}
+{{- end}}
+{{end}}
+{{.Helpers}}`))
+
+var testTemplate = template.Must(template.New("test").Funcs(templateFuncs).Parse(`// Code generated by fastertypegen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+{{if .TestImports}}
+import (
+{{- range .TestImports}}
+	{{quote .}}
+{{- end}}
+)
+
+// roundTrip encodes value with codec in both formats, scans it into a new value, and returns an
+// error if it is different.
+func roundTrip(t *testing.T, codec pgtype.Codec, value any, newTarget func() any) {
+	t.Helper()
+	const oid = 1000000
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: codec, Name: "test_type", OID: oid})
+
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		buf, err := m.Encode(oid, format, value, nil)
+		if err != nil {
+			t.Fatalf("%#v format=%d: Encode failed: %s", value, format, err)
+		}
+		target := newTarget()
+		err = m.Scan(oid, format, buf, target)
+		if err != nil {
+			t.Fatalf("%#v format=%d: Scan %#v failed: %s", value, format, string(buf), err)
+		}
+		output := reflect.ValueOf(target).Elem().Interface()
+		if !reflect.DeepEqual(output, value) {
+			t.Errorf("format=%d: output=%#v; expected %#v", format, output, value)
+		}
+
+		decoded, err := codec.DecodeValue(m, oid, format, buf)
+		if err != nil {
+			t.Fatalf("%#v format=%d: DecodeValue failed: %s", value, format, err)
+		}
+		if !reflect.DeepEqual(decoded, value) {
+			t.Errorf("format=%d: DecodeValue=%#v; expected %#v", format, decoded, value)
+		}
+	}
+}
+{{end}}
+{{- range .Types}}
+{{- if eq .Kind 0}}
+func Test{{.GoName}}Codec(t *testing.T) {
+	for _, value := range []{{.GoName}}{
+{{- range .EnumConsts}}
+		{{.Name}},
+{{- end}}
+	} {
+		roundTrip(t, {{.GoName}}Codec{}, value, func() any { return new({{.GoName}}) })
+	}
+
+	var value {{.GoName}}
+	if err := ({{.GoName}}Codec{}).PlanScan(nil, 0, pgtype.TextFormatCode, &value).Scan([]byte("\x00invalid"), &value); err == nil {
+		t.Error("expected error for an invalid label")
+	}
+}
+{{else if eq .Kind 1}}
+func Test{{.GoName}}Codec(t *testing.T) {
+	values := []{{.GoName}}{
+		{},
+		{
+{{- range .Fields}}
+			{{.GoName}}: {{.Type.GoType}}{ {{- .Type.ValueField}}: {{.Type.TestValue}}, Valid: true},
+{{- end}}
+		},
+	}
+	for _, value := range values {
+		roundTrip(t, {{.GoName}}Codec{}, value, func() any { return new({{.GoName}}) })
+
+		sqlValue, err := value.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sqlOutput {{.GoName}}
+		err = sqlOutput.Scan(sqlValue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sqlOutput, value) {
+			t.Errorf("database/sql output=%#v; expected %#v", sqlOutput, value)
+		}
+	}
+}
+{{end}}
+{{- end}}`))
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestGenerateExample checks that the generated code in the example directory is up to date.
+func TestGenerateExample(t *testing.T) {
+	ddl, err := os.ReadFile("example/types.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs, err := parseDDL(string(ddl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := generate(generateConfig{
+		Package: "example", RegisterFunc: "RegisterTypes", Source: "types.sql", Types: defs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, generated := range map[string][]byte{
+		"example/types.go":      files.Code,
+		"example/types_test.go": files.Test,
+	} {
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(existing, generated) {
+			t.Errorf("%s is out of date: run go generate ./...", path)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := generate(generateConfig{Package: "p", RegisterFunc: "Register"}); err == nil {
+		t.Error("expected error for no types")
+	}
+
+	duplicates := []*typeDef{
+		{SQLName: "a_b", GoName: "AB", Kind: enumKind, Labels: []string{"x"}},
+		{SQLName: "a b", GoName: "AB", Kind: enumKind, Labels: []string{"x"}},
+	}
+	if _, err := generate(generateConfig{Package: "p", RegisterFunc: "Register", Types: duplicates}); err == nil {
+		t.Error("expected error for duplicate Go names")
+	}
+}
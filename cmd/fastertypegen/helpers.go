@@ -0,0 +1,346 @@
+package main
+
+import "strings"
+
+// compositeHelpers is the Go source for the runtime helpers used by all composite types.
+const compositeHelpers = `
+// compositeBinaryReader reads fields from the binary format of a composite type.
+type compositeBinaryReader struct {
+	src []byte
+	rp  int
+}
+
+// readCompositeBinaryHeader reads the field count from the binary format of a composite type.
+func readCompositeBinaryHeader(src []byte, fieldCount int) (compositeBinaryReader, error) {
+	const uint32Len = 4
+	if len(src) < uint32Len {
+		return compositeBinaryReader{}, fmt.Errorf("composite incomplete %v", src)
+	}
+	count := int(int32(binary.BigEndian.Uint32(src)))
+	if count != fieldCount {
+		return compositeBinaryReader{}, fmt.Errorf("composite has %d fields; expected %d", count, fieldCount)
+	}
+	return compositeBinaryReader{src: src, rp: uint32Len}, nil
+}
+
+// next returns the value of the next field, or nil if it is NULL. The field type OID is ignored.
+func (r *compositeBinaryReader) next() ([]byte, error) {
+	const uint32Len = 4
+	if len(r.src[r.rp:]) < 2*uint32Len {
+		return nil, fmt.Errorf("composite incomplete %v", r.src)
+	}
+	r.rp += uint32Len
+	length := int(int32(binary.BigEndian.Uint32(r.src[r.rp:])))
+	r.rp += uint32Len
+	if length < 0 {
+		return nil, nil
+	}
+	if len(r.src[r.rp:]) < length {
+		return nil, fmt.Errorf("composite incomplete %v", r.src)
+	}
+	value := r.src[r.rp : r.rp+length]
+	r.rp += length
+	return value, nil
+}
+
+// appendCompositeBinaryNull appends the length of a NULL composite field.
+func appendCompositeBinaryNull(buf []byte) []byte {
+	return binary.BigEndian.AppendUint32(buf, 0xffffffff)
+}
+
+// compositeTextField is a field parsed from the text format of a composite type.
+type compositeTextField struct {
+	value string
+	valid bool
+}
+
+// parseCompositeText parses the text format of a composite type into fields, which must have the
+// same length as the number of fields in src. Values that do not contain quotes or backslashes
+// are substrings of src.
+func parseCompositeText(src string, fields []compositeTextField) error {
+	if len(src) == 0 || src[0] != '(' {
+		return fmt.Errorf("composite must start with '(': %#v", src)
+	}
+	pos := 1
+	for i := range fields {
+		if i > 0 {
+			if pos >= len(src) || src[pos] != ',' {
+				return fmt.Errorf("composite has too few fields; expected %d: %#v", len(fields), src)
+			}
+			pos++
+		}
+		if pos >= len(src) {
+			return fmt.Errorf("composite incomplete: %#v", src)
+		}
+		if src[pos] == ',' || src[pos] == ')' {
+			// an empty unquoted field is NULL
+			fields[i] = compositeTextField{}
+			continue
+		}
+
+		end, err := compositeTextFieldEnd(src, pos)
+		if err != nil {
+			return err
+		}
+		raw := src[pos:end]
+		if strings.ContainsAny(raw, "\"\\") {
+			raw = unescapeCompositeTextField(raw)
+		}
+		fields[i] = compositeTextField{raw, true}
+		pos = end
+	}
+	if pos >= len(src) || src[pos] != ')' {
+		return fmt.Errorf("composite has too many fields; expected %d: %#v", len(fields), src)
+	}
+	if strings.TrimSpace(src[pos+1:]) != "" {
+		return fmt.Errorf("unexpected data after composite: %#v", src)
+	}
+	return nil
+}
+
+// compositeTextFieldEnd returns the offset of the ',' or ')' that ends the field starting at pos.
+func compositeTextFieldEnd(src string, pos int) (int, error) {
+	inQuotes := false
+	for ; pos < len(src); pos++ {
+		switch src[pos] {
+		case '\\':
+			pos++
+		case '"':
+			if inQuotes && pos+1 < len(src) && src[pos+1] == '"' {
+				pos++
+			} else {
+				inQuotes = !inQuotes
+			}
+		case ',', ')':
+			if !inQuotes {
+				return pos, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("composite incomplete: %#v", src)
+}
+
+// unescapeCompositeTextField removes quotes and escapes from a composite field.
+func unescapeCompositeTextField(raw string) string {
+	var builder strings.Builder
+	builder.Grow(len(raw))
+	inQuotes := false
+	for i := 0; i < len(raw); i++ {
+		switch b := raw[i]; {
+		case b == '\\' && i+1 < len(raw):
+			i++
+			builder.WriteByte(raw[i])
+		case b == '"' && inQuotes && i+1 < len(raw) && raw[i+1] == '"':
+			i++
+			builder.WriteByte('"')
+		case b == '"':
+			inQuotes = !inQuotes
+		default:
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
+
+// appendCompositeTextField appends s as a field in the text format of a composite type, quoting
+// it the same way as Postgres.
+func appendCompositeTextField(buf []byte, s string) []byte {
+	if s != "" && !strings.ContainsAny(s, "\"\\(), \t\n\r\v\f") {
+		return append(buf, s...)
+	}
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			buf = append(buf, s[i])
+		}
+		buf = append(buf, s[i])
+	}
+	return append(buf, '"')
+}
+`
+
+// compositeFloatHelpers is the Go source for helpers used by float composite fields.
+const compositeFloatHelpers = `
+// appendCompositeTextFloat appends f in a format that Postgres accepts.
+func appendCompositeTextFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsInf(f, 1):
+		return append(buf, "Infinity"...)
+	case math.IsInf(f, -1):
+		return append(buf, "-Infinity"...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+`
+
+// compositeKindHelpers contains the Go source for the helpers for each pgType.Kind.
+var compositeKindHelpers = map[string]string{
+	"Text": `
+func appendCompositeBinaryText(buf []byte, oid uint32, v pgtype.Text) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, oid)
+	if !v.Valid {
+		return appendCompositeBinaryNull(buf)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(v.String)))
+	return append(buf, v.String...)
+}
+
+func scanCompositeBinaryText(src []byte) (pgtype.Text, error) {
+	if src == nil {
+		return pgtype.Text{}, nil
+	}
+	return pgtype.Text{String: string(src), Valid: true}, nil
+}
+
+func appendCompositeTextText(buf []byte, v pgtype.Text) []byte {
+	if !v.Valid {
+		return buf
+	}
+	return appendCompositeTextField(buf, v.String)
+}
+
+func scanCompositeTextText(field compositeTextField) (pgtype.Text, error) {
+	return pgtype.Text{String: field.value, Valid: field.valid}, nil
+}
+`,
+	"Int2": intHelpers("Int2", "Int16", "int16", "16", "2", "AppendUint16", "Uint16", "uint16"),
+	"Int4": intHelpers("Int4", "Int32", "int32", "32", "4", "AppendUint32", "Uint32", "uint32"),
+	"Int8": intHelpers("Int8", "Int64", "int64", "64", "8", "AppendUint64", "Uint64", "uint64"),
+	"Bool": `
+func appendCompositeBinaryBool(buf []byte, oid uint32, v pgtype.Bool) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, oid)
+	if !v.Valid {
+		return appendCompositeBinaryNull(buf)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, 1)
+	if v.Bool {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+func scanCompositeBinaryBool(src []byte) (pgtype.Bool, error) {
+	if src == nil {
+		return pgtype.Bool{}, nil
+	}
+	if len(src) != 1 {
+		return pgtype.Bool{}, fmt.Errorf("invalid length for bool: %d", len(src))
+	}
+	return pgtype.Bool{Bool: src[0] != 0, Valid: true}, nil
+}
+
+func appendCompositeTextBool(buf []byte, v pgtype.Bool) []byte {
+	if !v.Valid {
+		return buf
+	}
+	if v.Bool {
+		return append(buf, 't')
+	}
+	return append(buf, 'f')
+}
+
+func scanCompositeTextBool(field compositeTextField) (pgtype.Bool, error) {
+	if !field.valid {
+		return pgtype.Bool{}, nil
+	}
+	switch field.value {
+	case "t":
+		return pgtype.Bool{Bool: true, Valid: true}, nil
+	case "f":
+		return pgtype.Bool{Bool: false, Valid: true}, nil
+	}
+	return pgtype.Bool{}, fmt.Errorf("invalid bool: %#v", field.value)
+}
+`,
+	"Float4": floatHelpers("Float4", "Float32", "float32", "32", "4", "AppendUint32", "Uint32", "math.Float32bits", "math.Float32frombits"),
+	"Float8": floatHelpers("Float8", "Float64", "float64", "64", "8", "AppendUint64", "Uint64", "math.Float64bits", "math.Float64frombits"),
+}
+
+func intHelpers(kind, field, goType, bits, size, appendFunc, readFunc, unsignedType string) string {
+	return replaceAll(`
+func appendCompositeBinaryKIND(buf []byte, oid uint32, v pgtype.KIND) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, oid)
+	if !v.Valid {
+		return appendCompositeBinaryNull(buf)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, SIZE)
+	return binary.BigEndian.APPEND(buf, UNSIGNED(v.FIELD))
+}
+
+func scanCompositeBinaryKIND(src []byte) (pgtype.KIND, error) {
+	if src == nil {
+		return pgtype.KIND{}, nil
+	}
+	if len(src) != SIZE {
+		return pgtype.KIND{}, fmt.Errorf("invalid length for GOTYPE: %d", len(src))
+	}
+	return pgtype.KIND{FIELD: GOTYPE(binary.BigEndian.READ(src)), Valid: true}, nil
+}
+
+func appendCompositeTextKIND(buf []byte, v pgtype.KIND) []byte {
+	if !v.Valid {
+		return buf
+	}
+	return strconv.AppendInt(buf, int64(v.FIELD), 10)
+}
+
+func scanCompositeTextKIND(field compositeTextField) (pgtype.KIND, error) {
+	if !field.valid {
+		return pgtype.KIND{}, nil
+	}
+	n, err := strconv.ParseInt(field.value, 10, BITS)
+	if err != nil {
+		return pgtype.KIND{}, err
+	}
+	return pgtype.KIND{FIELD: GOTYPE(n), Valid: true}, nil
+}
+`, "KIND", kind, "FIELD", field, "GOTYPE", goType, "BITS", bits, "SIZE", size,
+		"APPEND", appendFunc, "READ", readFunc, "UNSIGNED", unsignedType)
+}
+
+func floatHelpers(kind, field, goType, bits, size, appendFunc, readFunc, toBits, fromBits string) string {
+	return replaceAll(`
+func appendCompositeBinaryKIND(buf []byte, oid uint32, v pgtype.KIND) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, oid)
+	if !v.Valid {
+		return appendCompositeBinaryNull(buf)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, SIZE)
+	return binary.BigEndian.APPEND(buf, TOBITS(v.FIELD))
+}
+
+func scanCompositeBinaryKIND(src []byte) (pgtype.KIND, error) {
+	if src == nil {
+		return pgtype.KIND{}, nil
+	}
+	if len(src) != SIZE {
+		return pgtype.KIND{}, fmt.Errorf("invalid length for GOTYPE: %d", len(src))
+	}
+	return pgtype.KIND{FIELD: FROMBITS(binary.BigEndian.READ(src)), Valid: true}, nil
+}
+
+func appendCompositeTextKIND(buf []byte, v pgtype.KIND) []byte {
+	if !v.Valid {
+		return buf
+	}
+	return appendCompositeTextFloat(buf, float64(v.FIELD), BITS)
+}
+
+func scanCompositeTextKIND(field compositeTextField) (pgtype.KIND, error) {
+	if !field.valid {
+		return pgtype.KIND{}, nil
+	}
+	f, err := strconv.ParseFloat(field.value, BITS)
+	if err != nil {
+		return pgtype.KIND{}, err
+	}
+	return pgtype.KIND{FIELD: GOTYPE(f), Valid: true}, nil
+}
+`, "KIND", kind, "FIELD", field, "GOTYPE", goType, "BITS", bits, "SIZE", size,
+		"APPEND", appendFunc, "READ", readFunc, "TOBITS", toBits, "FROMBITS", fromBits)
+}
+
+// replaceAll replaces pairs of old, new strings in s.
+func replaceAll(s string, oldNew ...string) string {
+	return strings.NewReplacer(oldNew...).Replace(s)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// introspectTypes queries the database for the definitions of the types with names.
+func introspectTypes(ctx context.Context, conn *pgx.Conn, names []string) ([]*typeDef, error) {
+	var defs []*typeDef
+	for _, name := range names {
+		def, err := introspectType(ctx, conn, name)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", name, err)
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+func introspectType(ctx context.Context, conn *pgx.Conn, name string) (*typeDef, error) {
+	var typeType string
+	var baseType string
+	err := conn.QueryRow(ctx,
+		`select typtype::text, coalesce(format_type(nullif(typbasetype, 0), typtypmod), '')
+		from pg_type where typname = $1`, name).Scan(&typeType, &baseType)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("type does not exist")
+		}
+		return nil, err
+	}
+
+	def := &typeDef{SQLName: name, GoName: goName(name)}
+	switch typeType {
+	case "e":
+		def.Kind = enumKind
+		rows, err := conn.Query(ctx,
+			`select e.enumlabel from pg_enum e join pg_type t on t.oid = e.enumtypid
+			where t.typname = $1 order by e.enumsortorder`, name)
+		if err != nil {
+			return nil, err
+		}
+		def.Labels, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return nil, err
+		}
+
+	case "c":
+		def.Kind = compositeKind
+		rows, err := conn.Query(ctx,
+			`select a.attname::text, format_type(a.atttypid, a.atttypmod)
+			from pg_type t join pg_attribute a on a.attrelid = t.typrelid
+			where t.typname = $1 and a.attnum > 0 and not a.attisdropped
+			order by a.attnum`, name)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var fieldName, fieldType string
+			if err := rows.Scan(&fieldName, &fieldType); err != nil {
+				return nil, err
+			}
+			t, err := lookupPGType(fieldType)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", fieldName, err)
+			}
+			if !t.CompositeField {
+				return nil, fmt.Errorf("field %s: type %s is not supported in composite types", fieldName, fieldType)
+			}
+			def.Fields = append(def.Fields, fieldDef{SQLName: fieldName, GoName: goName(fieldName), Type: t})
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+	case "d":
+		def.Kind = domainKind
+		def.Base, err = lookupPGType(baseType)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported typtype=%#v: must be an enum, composite, or domain", typeType)
+	}
+	return def, nil
+}
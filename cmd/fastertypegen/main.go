@@ -0,0 +1,119 @@
+// Command fastertypegen generates pgx codecs for Postgres enum, composite, and domain types. It
+// reads the type definitions from a DDL file or a database, and writes a Go file with the types,
+// encode and scan plans, and a function to register them, and a test file for the generated code.
+//
+// It is intended to be used with go:generate:
+//
+//	//go:generate go run github.com/evanj/pgxtypefaster/cmd/fastertypegen -ddl types.sql -out types.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func main() {
+	ddlPath := flag.String("ddl", "", "path to a SQL file containing CREATE TYPE and CREATE DOMAIN statements")
+	dsn := flag.String("dsn", "", "Postgres connection string to read the type definitions from")
+	typeList := flag.String("types", "", "comma-separated list of type names to generate (required with -dsn)")
+	out := flag.String("out", "types.go", "path to the generated Go file; tests are written to the same path with _test.go")
+	pkg := flag.String("package", "", "Go package name (default: the name of the output directory)")
+	registerFunc := flag.String("register", "RegisterTypes", "name of the generated registration function")
+	flag.Parse()
+
+	if (*ddlPath == "") == (*dsn == "") {
+		fmt.Fprintln(os.Stderr, "fastertypegen: exactly one of -ddl or -dsn is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*ddlPath, *dsn, *typeList, *out, *pkg, *registerFunc); err != nil {
+		fmt.Fprintf(os.Stderr, "fastertypegen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ddlPath string, dsn string, typeList string, out string, pkg string, registerFunc string) error {
+	var names []string
+	if typeList != "" {
+		names = strings.Split(typeList, ",")
+		for i := range names {
+			names[i] = strings.TrimSpace(names[i])
+		}
+	}
+
+	config := generateConfig{Package: pkg, RegisterFunc: registerFunc}
+	if config.Package == "" {
+		absOut, err := filepath.Abs(out)
+		if err != nil {
+			return err
+		}
+		config.Package = filepath.Base(filepath.Dir(absOut))
+	}
+
+	if ddlPath != "" {
+		ddl, err := os.ReadFile(ddlPath)
+		if err != nil {
+			return err
+		}
+		defs, err := parseDDL(string(ddl))
+		if err != nil {
+			return fmt.Errorf("%s: %w", ddlPath, err)
+		}
+		config.Types, err = filterTypes(defs, names)
+		if err != nil {
+			return err
+		}
+		config.Source = filepath.Base(ddlPath)
+	} else {
+		if len(names) == 0 {
+			return fmt.Errorf("-types is required with -dsn")
+		}
+		ctx := context.Background()
+		conn, err := pgx.Connect(ctx, dsn)
+		if err != nil {
+			return err
+		}
+		defer conn.Close(ctx)
+		config.Types, err = introspectTypes(ctx, conn, names)
+		if err != nil {
+			return err
+		}
+		config.Source = "the database"
+	}
+
+	files, err := generate(config)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, files.Code, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(out, ".go")+"_test.go", files.Test, 0o644)
+}
+
+// filterTypes returns the types in defs with names, in the order of names. If names is empty, it
+// returns all of defs.
+func filterTypes(defs []*typeDef, names []string) ([]*typeDef, error) {
+	if len(names) == 0 {
+		return defs, nil
+	}
+	byName := map[string]*typeDef{}
+	for _, def := range defs {
+		byName[def.SQLName] = def
+	}
+	filtered := make([]*typeDef, len(names))
+	for i, name := range names {
+		def, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s is not defined", name)
+		}
+		filtered[i] = def
+	}
+	return filtered, nil
+}
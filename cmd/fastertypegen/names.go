@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// commonInitialisms are capitalized as a unit, following Go naming conventions.
+var commonInitialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "ip": true, "json": true, "sql": true,
+	"uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// goName converts a Postgres identifier like "supplier_id" to an exported Go name like
+// "SupplierID". Characters that are not letters or digits separate words. It returns the empty
+// string if name does not contain any letters or digits.
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var builder strings.Builder
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		if commonInitialisms[lower] {
+			builder.WriteString(strings.ToUpper(lower))
+			continue
+		}
+		runes := []rune(lower)
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+	out := builder.String()
+	if out != "" && unicode.IsDigit(rune(out[0])) {
+		out = "X" + out
+	}
+	return out
+}
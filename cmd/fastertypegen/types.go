@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type typeKind int
+
+const (
+	enumKind typeKind = iota
+	compositeKind
+	domainKind
+)
+
+// typeDef is a Postgres type definition to generate code for.
+type typeDef struct {
+	// SQLName is the Postgres type name, without the schema (pg_type.typname).
+	SQLName string
+	GoName  string
+	Kind    typeKind
+
+	// Labels are the enum labels, in order.
+	Labels []string
+	// Fields are the composite type fields, in order.
+	Fields []fieldDef
+	// Base is the base type of a domain.
+	Base *pgType
+}
+
+type fieldDef struct {
+	SQLName string
+	GoName  string
+	Type    *pgType
+}
+
+// pgType is a Postgres type that generated code can use as a composite field or domain base type.
+type pgType struct {
+	// Kind is the suffix of the generated helper functions and the pgtype type (e.g. Int4).
+	Kind string
+	// GoType is the Go type used for values.
+	GoType string
+	// OIDConst is the pgtype OID constant. It is empty for types without a fixed OID.
+	OIDConst string
+	// Codec is the Go expression for the pgtype.Codec used for domains over this type.
+	Codec string
+	// ValueField is the field of GoType that contains the value.
+	ValueField string
+	// TestValue is a Go expression for a valid test value of the ValueField's type.
+	TestValue string
+	// CompositeField is true if the type can be used in a composite type.
+	CompositeField bool
+}
+
+var pgTypes = map[string]*pgType{
+	"text": {"Text", "pgtype.Text", "pgtype.TextOID", "pgtype.TextCodec{}", "String",
+		"`a \"quoted\", \\back\\slash (paren)`", true},
+	"varchar": {"Text", "pgtype.Text", "pgtype.VarcharOID", "pgtype.TextCodec{}", "String",
+		"`a \"quoted\", \\back\\slash (paren)`", true},
+	"int2":   {"Int2", "pgtype.Int2", "pgtype.Int2OID", "pgtype.Int2Codec{}", "Int16", "-2", true},
+	"int4":   {"Int4", "pgtype.Int4", "pgtype.Int4OID", "pgtype.Int4Codec{}", "Int32", "-4", true},
+	"int8":   {"Int8", "pgtype.Int8", "pgtype.Int8OID", "pgtype.Int8Codec{}", "Int64", "-8", true},
+	"bool":   {"Bool", "pgtype.Bool", "pgtype.BoolOID", "pgtype.BoolCodec{}", "Bool", "true", true},
+	"float4": {"Float4", "pgtype.Float4", "pgtype.Float4OID", "pgtype.Float4Codec{}", "Float32", "1.5", true},
+	"float8": {"Float8", "pgtype.Float8", "pgtype.Float8OID", "pgtype.Float8Codec{}", "Float64", "-2.25", true},
+	// hstore does not have a fixed OID, so it can only be the base type of a domain
+	"hstore": {"Hstore", "pgxtypefaster.Hstore", "", "pgxtypefaster.HstoreCodec{}", "", "", false},
+}
+
+var pgTypeAliases = map[string]string{
+	"character varying": "varchar",
+	"smallint":          "int2",
+	"integer":           "int4",
+	"int":               "int4",
+	"bigint":            "int8",
+	"boolean":           "bool",
+	"real":              "float4",
+	"double precision":  "float8",
+}
+
+var typeModifierPattern = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// lookupPGType returns the pgType for a Postgres type name as written in DDL or returned by
+// format_type, such as "character varying(20)" or "public.hstore".
+func lookupPGType(name string) (*pgType, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	normalized = typeModifierPattern.ReplaceAllString(normalized, "")
+	if i := strings.LastIndexByte(normalized, '.'); i != -1 {
+		normalized = normalized[i+1:]
+	}
+	normalized = strings.Trim(normalized, `"`)
+	if alias, ok := pgTypeAliases[normalized]; ok {
+		normalized = alias
+	}
+	t, ok := pgTypes[normalized]
+	if !ok {
+		return nil, fmt.Errorf("unsupported Postgres type %#v", name)
+	}
+	return t, nil
+}
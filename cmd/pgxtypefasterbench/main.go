@@ -0,0 +1,147 @@
+// Command pgxtypefasterbench benchmarks and tunes the hstore codecs for an application's data. Both
+// modes read rows from a table over a live connection. The sample mode reports which codec and
+// format scan them fastest, to guide the choice of pgx.QueryExecMode: the simple protocol only
+// uses the text format. The profile mode writes a pgxtypefaster.HstoreProfile as JSON, which can be
+// loaded with pgxtypefaster.LoadHstoreProfile and used with pgxtypefaster.WithProfile. The stats
+// mode reads all rows and reports the frequency, value sizes, NULL rate, and distinct values of each
+// key, to decide which keys should be promoted to columns.
+//
+//	pgxtypefasterbench sample -dsn postgres://... -table items -column attrs -rows 1000
+//	pgxtypefasterbench profile -dsn postgres://... -table items -column attrs -out profile.json
+//	pgxtypefasterbench stats -dsn postgres://... -table items -column attrs -top 20
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: pgxtypefasterbench (sample|profile|stats) [flags]")
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "sample":
+		err = runSample(os.Args[2:])
+	case "profile":
+		err = runProfile(os.Args[2:])
+	case "stats":
+		err = runStats(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "pgxtypefasterbench: unknown mode %#v; supported modes: sample, profile, stats\n", os.Args[1])
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pgxtypefasterbench: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// sampleFlags are the flags shared by all modes to select the rows to sample.
+type sampleFlags struct {
+	dsn    *string
+	table  *string
+	column *string
+	rows   *int
+}
+
+func addSampleFlags(flags *flag.FlagSet) sampleFlags {
+	return sampleFlags{
+		dsn:    flags.String("dsn", "", "Postgres connection string"),
+		table:  flags.String("table", "", "table to sample; may be schema-qualified (schema.table)"),
+		column: flags.String("column", "", "hstore column to sample"),
+		rows:   flags.Int("rows", 1000, "number of rows to sample"),
+	}
+}
+
+// sample connects to the database and samples the rows selected by the flags.
+func (f sampleFlags) sample(mode string) (*sample, error) {
+	if *f.dsn == "" || *f.table == "" || *f.column == "" {
+		return nil, fmt.Errorf("%s: -dsn, -table, and -column are required", mode)
+	}
+	if *f.rows <= 0 {
+		return nil, fmt.Errorf("%s: -rows must be > 0", mode)
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *f.dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+	return sampleColumn(ctx, conn, pgx.Identifier(strings.Split(*f.table, ".")), *f.column, *f.rows)
+}
+
+func runSample(args []string) error {
+	flags := flag.NewFlagSet("sample", flag.ExitOnError)
+	sf := addSampleFlags(flags)
+	flags.Parse(args)
+
+	s, err := sf.sample("sample")
+	if err != nil {
+		return err
+	}
+	results := benchmarkSample(s)
+	writeReport(os.Stdout, s, results)
+	return nil
+}
+
+func runProfile(args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	sf := addSampleFlags(flags)
+	out := flags.String("out", "", "path to write the profile as JSON (default: stdout)")
+	flags.Parse(args)
+
+	s, err := sf.sample("profile")
+	if err != nil {
+		return err
+	}
+	data, err := profileSample(s)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0o644)
+}
+
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	dsn := flags.String("dsn", "", "Postgres connection string")
+	table := flags.String("table", "", "table to analyze; may be schema-qualified (schema.table)")
+	column := flags.String("column", "", "hstore column to analyze")
+	top := flags.Int("top", 20, "number of keys to report")
+	flags.Parse(args)
+
+	if *dsn == "" || *table == "" || *column == "" {
+		return fmt.Errorf("stats: -dsn, -table, and -column are required")
+	}
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, *dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	if err := pgxtypefaster.RegisterHstore(ctx, conn); err != nil {
+		return err
+	}
+
+	stats, err := pgxtypefaster.CollectHstoreStats(ctx, conn, pgx.Identifier(strings.Split(*table, ".")), *column)
+	if err != nil {
+		return err
+	}
+	writeStatsReport(os.Stdout, stats, *top)
+	return nil
+}
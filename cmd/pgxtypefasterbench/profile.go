@@ -0,0 +1,24 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+// profileSample returns the profile of the text values in s as indented JSON.
+func profileSample(s *sample) ([]byte, error) {
+	texts := make([]string, len(s.text))
+	for i, text := range s.text {
+		texts[i] = string(text)
+	}
+	profile, err := pgxtypefaster.ProfileHstores(texts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
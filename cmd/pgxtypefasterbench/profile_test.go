@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestProfileSample(t *testing.T) {
+	s := &sample{text: [][]byte{[]byte(`"a"=>"1", "b"=>NULL`), []byte(`"c"=>"2"`)}}
+	data, err := profileSample(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profile pgxtypefaster.HstoreProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		t.Fatal(err)
+	}
+	if profile.Samples != 2 || profile.MaxPairs != 2 || profile.MeanPairs != 1.5 {
+		t.Errorf("unexpected profile: %s", data)
+	}
+
+	s.text = append(s.text, []byte("invalid"))
+	if _, err := profileSample(s); err == nil {
+		t.Error("invalid value expected error")
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// sample contains the same values in both formats. NULL values are omitted.
+type sample struct {
+	oid    uint32
+	text   [][]byte
+	binary [][]byte
+}
+
+// sampleColumn reads up to limit non-NULL values of column in both the text and binary formats.
+func sampleColumn(ctx context.Context, conn *pgx.Conn, table pgx.Identifier, column string, limit int) (*sample, error) {
+	sql := fmt.Sprintf("select %s from %s where %s is not null limit %d",
+		pgx.Identifier{column}.Sanitize(), table.Sanitize(), pgx.Identifier{column}.Sanitize(), limit)
+
+	s := &sample{}
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		result := conn.PgConn().ExecParams(ctx, sql, nil, nil, nil, []int16{format}).Read()
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		s.oid = result.FieldDescriptions[0].DataTypeOID
+		values := make([][]byte, len(result.Rows))
+		for i, row := range result.Rows {
+			values[i] = row[0]
+		}
+		if format == pgtype.TextFormatCode {
+			s.text = values
+		} else {
+			s.binary = values
+		}
+	}
+
+	var hstoreOID uint32
+	err := conn.QueryRow(ctx, `select oid from pg_type where typname = 'hstore'`).Scan(&hstoreOID)
+	if err != nil {
+		return nil, fmt.Errorf("querying hstore OID: %w", err)
+	}
+	if s.oid != hstoreOID {
+		return nil, fmt.Errorf("column %s has type OID %d: only hstore (OID %d) is supported", column, s.oid, hstoreOID)
+	}
+	if len(s.text) == 0 {
+		return nil, fmt.Errorf("column %s has no non-NULL values", column)
+	}
+	return s, nil
+}
+
+// benchmarkCodec is a codec to benchmark, and a function returning a new scan target for it.
+type benchmarkCodec struct {
+	name      string
+	codec     pgtype.Codec
+	newTarget func() any
+}
+
+var benchmarkCodecs = []benchmarkCodec{
+	{"pgtype.HstoreCodec", pgtype.HstoreCodec{}, func() any { return &pgtype.Hstore{} }},
+	{"pgxtypefaster.HstoreCodec", pgxtypefaster.HstoreCodec{}, func() any { return &pgxtypefaster.Hstore{} }},
+	{"pgxtypefaster.HstoreCompatCodec", pgxtypefaster.HstoreCompatCodec{},
+		func() any { return &pgxtypefaster.HstoreCompat{} }},
+}
+
+// benchmarkResult is the cost of scanning one row, or the error if the codec cannot scan the
+// values.
+type benchmarkResult struct {
+	codec       string
+	format      int16
+	err         error
+	nsPerRow    float64
+	allocsPerOp float64
+	bytesPerRow float64
+	wireBytes   float64
+}
+
+func formatName(format int16) string {
+	if format == pgtype.BinaryFormatCode {
+		return "binary"
+	}
+	return "text"
+}
+
+// benchmarkSample scans all values in s with each codec and format. The results are sorted from
+// fastest to slowest, followed by errors.
+func benchmarkSample(s *sample) []benchmarkResult {
+	var results []benchmarkResult
+	for _, c := range benchmarkCodecs {
+		m := pgtype.NewMap()
+		m.RegisterType(&pgtype.Type{Codec: c.codec, Name: "hstore", OID: s.oid})
+		for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+			values := s.text
+			if format == pgtype.BinaryFormatCode {
+				values = s.binary
+			}
+			target := c.newTarget()
+			plan := m.PlanScan(s.oid, format, target)
+
+			var scanErr error
+			r := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					for _, value := range values {
+						if err := plan.Scan(value, target); err != nil {
+							scanErr = err
+							return
+						}
+					}
+				}
+			})
+			if scanErr != nil {
+				// report the codec as unusable instead of failing the whole comparison
+				results = append(results, benchmarkResult{codec: c.name, format: format, err: scanErr})
+				continue
+			}
+
+			wireBytes := 0
+			for _, value := range values {
+				wireBytes += len(value)
+			}
+			rows := float64(len(values))
+			results = append(results, benchmarkResult{
+				codec:       c.name,
+				format:      format,
+				nsPerRow:    float64(r.NsPerOp()) / rows,
+				allocsPerOp: float64(r.AllocsPerOp()) / rows,
+				bytesPerRow: float64(r.AllocedBytesPerOp()) / rows,
+				wireBytes:   float64(wireBytes) / rows,
+			})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].err == nil) != (results[j].err == nil) {
+			return results[i].err == nil
+		}
+		return results[i].nsPerRow < results[j].nsPerRow
+	})
+	return results
+}
+
+// writeReport writes the results as a table, and a recommendation.
+func writeReport(w io.Writer, s *sample, results []benchmarkResult) {
+	fmt.Fprintf(w, "sampled %d rows\n\n", len(s.text))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "codec\tformat\tns/row\tallocs/row\tB/row\twire B/row\t")
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(tw, "%s\t%s\tscan failed: %s\t\t\t\t\n", r.codec, formatName(r.format), r.err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.0f\t%.1f\t%.0f\t%.0f\t\n",
+			r.codec, formatName(r.format), r.nsPerRow, r.allocsPerOp, r.bytesPerRow, r.wireBytes)
+	}
+	tw.Flush()
+	if len(results) == 0 || results[0].err != nil {
+		return
+	}
+
+	best := results[0]
+	fmt.Fprintf(w, "\nfastest: %s with the %s format\n", best.codec, formatName(best.format))
+	if best.format == pgtype.BinaryFormatCode {
+		fmt.Fprintln(w, "use a QueryExecMode that uses the extended protocol, such as the default"+
+			" QueryExecModeCacheStatement; QueryExecModeSimpleProtocol uses the text format")
+	} else {
+		fmt.Fprintln(w, "the text format is used by QueryExecModeSimpleProtocol and database/sql")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestBenchmarkSample(t *testing.T) {
+	// testing.Benchmark uses the -test.benchtime flag: make the test fast
+	benchtime := flag.Lookup("test.benchtime")
+	previous := benchtime.Value.String()
+	if err := benchtime.Value.Set("10ms"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { benchtime.Value.Set(previous) })
+
+	const oid = 100000
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstoreCodec{}, Name: "hstore", OID: oid})
+	s := &sample{oid: oid}
+	for _, h := range []pgxtypefaster.Hstore{
+		{"a": pgxtypefaster.NewText("1"), "b": {}},
+		{},
+		{"key with \"quotes\"": pgxtypefaster.NewText("value")},
+	} {
+		text, err := m.Encode(oid, pgtype.TextFormatCode, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		binary, err := m.Encode(oid, pgtype.BinaryFormatCode, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.text = append(s.text, text)
+		s.binary = append(s.binary, binary)
+	}
+
+	results := benchmarkSample(s)
+	if len(results) != len(benchmarkCodecs)*2 {
+		t.Fatalf("expected a result for each codec and format: %#v", results)
+	}
+	for i, r := range results {
+		if r.err != nil {
+			t.Errorf("%s %s: %s", r.codec, formatName(r.format), r.err)
+		}
+		if i > 0 && r.nsPerRow < results[i-1].nsPerRow {
+			t.Errorf("results not sorted: %#v", results)
+		}
+	}
+
+	var out bytes.Buffer
+	writeReport(&out, s, results)
+	if !strings.Contains(out.String(), "sampled 3 rows") || !strings.Contains(out.String(), "fastest: ") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+
+	// invalid values are reported as errors
+	s.binary[0] = []byte{0, 0}
+	results = benchmarkSample(s)
+	if last := results[len(results)-1]; last.err == nil || last.format != pgtype.BinaryFormatCode {
+		t.Errorf("expected binary error last: %#v", last)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+// writeStatsReport writes stats, including the top keys by frequency.
+func writeStatsReport(w io.Writer, stats pgxtypefaster.HstoreStats, top int) {
+	nullRate := 0.0
+	if stats.Rows > 0 {
+		nullRate = float64(stats.NullRows) / float64(stats.Rows)
+	}
+	fmt.Fprintf(w, "rows: %d (%.1f%% NULL)\n", stats.Rows, 100*nullRate)
+	fmt.Fprintf(w, "distinct keys: %d", len(stats.Keys))
+	if stats.UntrackedPairs > 0 {
+		fmt.Fprintf(w, " (limit reached: %d pairs with other keys)", stats.UntrackedPairs)
+	}
+	fmt.Fprintln(w)
+
+	writeHistogram(w, "pairs per row", stats.PairCounts)
+	writeHistogram(w, "value bytes", stats.ValueSizes)
+
+	fmt.Fprintln(w, "\nkeys:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "key\tfrequency\tNULL values\tmean bytes\tmax bytes\tdistinct values\t")
+	for i, key := range stats.Keys {
+		if i >= top {
+			break
+		}
+		nonNull := key.Rows - key.NullValues
+		meanBytes := 0.0
+		if nonNull > 0 {
+			meanBytes = float64(key.ValueBytes) / float64(nonNull)
+		}
+		distinct := fmt.Sprint(key.DistinctValues)
+		if key.DistinctSaturated() {
+			distinct = ">=" + distinct
+		}
+		fmt.Fprintf(tw, "%s\t%.1f%%\t%.1f%%\t%.1f\t%d\t%s\t\n", key.Key, 100*stats.KeyFrequency(key),
+			100*float64(key.NullValues)/float64(key.Rows), meanBytes, key.MaxValueBytes, distinct)
+	}
+	tw.Flush()
+	if len(stats.Keys) > top {
+		fmt.Fprintf(w, "(%d more keys)\n", len(stats.Keys)-top)
+	}
+}
+
+func writeHistogram(w io.Writer, name string, h pgxtypefaster.SizeHistogram) {
+	fmt.Fprintf(w, "\n%s:\n", name)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for bucket, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		min, max := h.BucketRange(bucket)
+		fmt.Fprintf(tw, "%d-%d\t%d\t\n", min, max, count)
+	}
+	tw.Flush()
+}
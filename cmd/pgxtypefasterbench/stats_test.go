@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestWriteStatsReport(t *testing.T) {
+	c := pgxtypefaster.NewHstoreStatsCollector()
+	c.Add(pgxtypefaster.Hstore{"color": pgxtypefaster.NewText("red"), "size": {}})
+	c.Add(pgxtypefaster.Hstore{"color": pgxtypefaster.NewText("blue")})
+	c.Add(nil)
+
+	var out bytes.Buffer
+	writeStatsReport(&out, c.Stats(), 1)
+	for _, expected := range []string{"rows: 3 (33.3% NULL)", "distinct keys: 2", "color", "(1 more keys)"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("report does not contain %#v:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "size") {
+		t.Errorf("report must only contain the top key:\n%s", out.String())
+	}
+}
@@ -0,0 +1,135 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CollectHstoreColumn reads all rows, returning the Hstore values of the column with index
+// column. NULL values are returned as nil Hstores. It plans the scan once, then decodes each
+// row's raw value directly. It closes rows. The result is not preallocated: pgx reads rows as they
+// arrive, so the row count is only known after the last row, and the result grows like
+// pgx.CollectRows.
+func CollectHstoreColumn(rows pgx.Rows, column int) ([]Hstore, error) {
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	if column < 0 || column >= len(fieldDescs) {
+		return nil, fmt.Errorf("hstore column index %d out of range: rows have %d columns", column, len(fieldDescs))
+	}
+
+	var plan pgtype.ScanPlan
+	if conn := rows.Conn(); conn != nil {
+		var h Hstore
+		plan = conn.TypeMap().PlanScan(fieldDescs[column].DataTypeOID, fieldDescs[column].Format, &h)
+	}
+	var scanTargets []any
+
+	result := []Hstore{}
+	for rows.Next() {
+		var h Hstore
+		var err error
+		if plan != nil {
+			err = plan.Scan(rows.RawValues()[column], &h)
+		} else {
+			if scanTargets == nil {
+				scanTargets = make([]any, len(fieldDescs))
+			}
+			scanTargets[column] = &h
+			err = rows.Scan(scanTargets...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hstore column %s: %w", fieldDescs[column].Name, err)
+		}
+		result = append(result, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CollectKeyedHstores reads all rows, returning a map from the value of column keyColumn to the
+// Hstore value of column hstoreColumn. If multiple rows have the same key, the last row is
+// returned. It closes rows. Like CollectHstoreColumn, the map is not preallocated, since the row
+// count is not known until all rows are read.
+func CollectKeyedHstores[K comparable](rows pgx.Rows, keyColumn int, hstoreColumn int) (map[K]Hstore, error) {
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	for _, column := range []int{keyColumn, hstoreColumn} {
+		if column < 0 || column >= len(fieldDescs) {
+			return nil, fmt.Errorf("column index %d out of range: rows have %d columns", column, len(fieldDescs))
+		}
+	}
+	if keyColumn == hstoreColumn {
+		return nil, fmt.Errorf("keyColumn and hstoreColumn must be different; both are %d", keyColumn)
+	}
+
+	// reuse the same targets for all rows: other columns are skipped with nil
+	scanTargets := make([]any, len(fieldDescs))
+	result := map[K]Hstore{}
+	for rows.Next() {
+		var key K
+		var h Hstore
+		scanTargets[keyColumn] = &key
+		scanTargets[hstoreColumn] = &h
+		err := rows.Scan(scanTargets...)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = h
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RowToHstore scans a row with a single hstore column. It is a pgx.RowToFunc:
+//
+//	hstores, err := pgx.CollectRows(rows, pgxtypefaster.RowToHstore)
+func RowToHstore(row pgx.CollectableRow) (Hstore, error) {
+	var h Hstore
+	err := row.Scan(&h)
+	return h, err
+}
+
+// KeyedHstore is the value of a key column and of an hstore column of one row.
+type KeyedHstore[K any] struct {
+	Key    K
+	Hstore Hstore
+}
+
+// RowToKeyedHstore returns a pgx.RowToFunc that scans the column with index keyColumn and the
+// hstore column with index hstoreColumn, skipping other columns. Use CollectKeyedHstores to
+// collect them in a map. Use a new function for each query, since it is not safe to use
+// concurrently:
+//
+//	keyed, err := pgx.CollectRows(rows, pgxtypefaster.RowToKeyedHstore[int64](0, 1))
+func RowToKeyedHstore[K any](keyColumn int, hstoreColumn int) pgx.RowToFunc[KeyedHstore[K]] {
+	var scanTargets []any
+	return func(row pgx.CollectableRow) (KeyedHstore[K], error) {
+		var keyed KeyedHstore[K]
+		columns := len(row.FieldDescriptions())
+		for _, column := range []int{keyColumn, hstoreColumn} {
+			if column < 0 || column >= columns {
+				return keyed, fmt.Errorf("column index %d out of range: rows have %d columns", column, columns)
+			}
+		}
+		if keyColumn == hstoreColumn {
+			return keyed, fmt.Errorf("keyColumn and hstoreColumn must be different; both are %d", keyColumn)
+		}
+
+		// reuse the same targets for all rows: other columns are skipped with nil
+		if len(scanTargets) != columns {
+			scanTargets = make([]any, columns)
+		}
+		scanTargets[keyColumn] = &keyed.Key
+		scanTargets[hstoreColumn] = &keyed.Hstore
+		err := row.Scan(scanTargets...)
+		return keyed, err
+	}
+}
@@ -0,0 +1,140 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeRows implements pgx.Rows with text format values, without a connection.
+type fakeRows struct {
+	fakeRow
+	rows   [][][]byte
+	pos    int
+	closed bool
+}
+
+func newFakeRows(fieldDescs []pgconn.FieldDescription, rows ...[]string) *fakeRows {
+	r := &fakeRows{fakeRow: fakeRow{typeMap: newTestTypeMap(), fieldDescs: fieldDescs}}
+	for _, row := range rows {
+		values := make([][]byte, len(row))
+		for i, v := range row {
+			if v != "NULL" {
+				values[i] = []byte(v)
+			}
+		}
+		r.rows = append(r.rows, values)
+	}
+	return r
+}
+
+func (r *fakeRows) Close()                        { r.closed = true }
+func (r *fakeRows) Err() error                    { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+func (r *fakeRows) Conn() *pgx.Conn               { return nil }
+
+func (r *fakeRows) Next() bool {
+	if r.pos >= len(r.rows) {
+		r.Close()
+		return false
+	}
+	r.values = r.rows[r.pos]
+	r.pos++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		desc := r.fieldDescs[i]
+		err := r.typeMap.Scan(desc.DataTypeOID, desc.Format, r.values[i], d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var collectFieldDescs = []pgconn.FieldDescription{
+	{Name: "id", DataTypeOID: pgtype.Int4OID, Format: pgtype.TextFormatCode},
+	{Name: "attrs", DataTypeOID: testHstoreOID, Format: pgtype.TextFormatCode},
+}
+
+func TestCollectHstoreColumn(t *testing.T) {
+	rows := newFakeRows(collectFieldDescs, []string{"1", `"a"=>"b"`}, []string{"2", "NULL"})
+	out, err := pgxtypefaster.CollectHstoreColumn(rows, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []pgxtypefaster.Hstore{{"a": pgxtypefaster.NewText("b")}, nil}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("CollectHstoreColumn=%#v; expected %#v", out, expected)
+	}
+	if !rows.closed {
+		t.Error("rows must be closed")
+	}
+
+	_, err = pgxtypefaster.CollectHstoreColumn(newFakeRows(collectFieldDescs), 2)
+	if err == nil {
+		t.Error("expected error for out of range column")
+	}
+}
+
+func TestCollectKeyedHstores(t *testing.T) {
+	rows := newFakeRows(collectFieldDescs, []string{"1", `"a"=>"b"`}, []string{"2", `"c"=>NULL`})
+	out, err := pgxtypefaster.CollectKeyedHstores[int32](rows, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int32]pgxtypefaster.Hstore{
+		1: {"a": pgxtypefaster.NewText("b")},
+		2: {"c": pgtype.Text{}},
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("CollectKeyedHstores=%#v; expected %#v", out, expected)
+	}
+}
+
+func TestRowToHstore(t *testing.T) {
+	fieldDescs := collectFieldDescs[1:]
+	rows := newFakeRows(fieldDescs, []string{`"a"=>"b"`}, []string{"NULL"})
+	out, err := pgx.CollectRows(rows, pgxtypefaster.RowToHstore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []pgxtypefaster.Hstore{{"a": pgxtypefaster.NewText("b")}, nil}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("RowToHstore=%#v; expected %#v", out, expected)
+	}
+}
+
+func TestRowToKeyedHstore(t *testing.T) {
+	fieldDescs := append(collectFieldDescs[:2:2], pgconn.FieldDescription{
+		Name: "ignored", DataTypeOID: pgtype.TextOID, Format: pgtype.TextFormatCode})
+	rows := newFakeRows(fieldDescs, []string{"1", `"a"=>"b"`, "x"}, []string{"2", "NULL", "y"})
+	out, err := pgx.CollectRows(rows, pgxtypefaster.RowToKeyedHstore[int32](0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []pgxtypefaster.KeyedHstore[int32]{
+		{1, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}},
+		{2, nil},
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("RowToKeyedHstore=%#v; expected %#v", out, expected)
+	}
+
+	for _, columns := range [][2]int{{0, 3}, {-1, 1}, {1, 1}} {
+		rows := newFakeRows(fieldDescs, []string{"1", `"a"=>"b"`, "x"})
+		_, err := pgx.CollectRows(rows, pgxtypefaster.RowToKeyedHstore[int32](columns[0], columns[1]))
+		if err == nil {
+			t.Errorf("RowToKeyedHstore(%d, %d): expected error", columns[0], columns[1])
+		}
+	}
+}
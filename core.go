@@ -0,0 +1,634 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// hstoreValues adapts the value type V of an hstore map, so hstoreCore can implement the
+// operations once for both Hstore and HstoreCompat. textValues is the adapter for Hstore, and
+// pointerValues is the adapter for HstoreCompat.
+type hstoreValues[V any] interface {
+	// text returns v as a pgtype.Text.
+	text(v V) pgtype.Text
+	// value returns a map value for v. It may append the string to values, which was returned by
+	// allocValues, so all values share one allocation. It returns the new values.
+	value(values []string, v pgtype.Text) ([]string, V)
+	// allocMap returns an empty map with space for n pairs.
+	allocMap(alloc Allocator, n int) map[string]V
+	// allocValues returns the storage for n values passed to value, or nil if it is not needed.
+	allocValues(alloc Allocator, n int) []string
+	// hstoreValue returns the map from the value passed to an encode plan.
+	hstoreValue(value any) (map[string]V, error)
+	// scanHstore stores h in the target of a scan plan.
+	scanHstore(dst any, h map[string]V) error
+	// valuer returns h as the map type, to pass to an encode plan.
+	valuer(h map[string]V) any
+	// scanTarget returns h as a pointer to the map type, to pass to a scan plan.
+	scanTarget(h *map[string]V) any
+}
+
+// textValues adapts the pgtype.Text values of Hstore.
+type textValues struct{}
+
+func (textValues) text(v pgtype.Text) pgtype.Text {
+	return v
+}
+
+func (textValues) value(values []string, v pgtype.Text) ([]string, pgtype.Text) {
+	return values, v
+}
+
+func (textValues) allocMap(alloc Allocator, n int) map[string]pgtype.Text {
+	return allocHstore(alloc, n)
+}
+
+func (textValues) allocValues(alloc Allocator, n int) []string {
+	return nil
+}
+
+func (textValues) hstoreValue(value any) (map[string]pgtype.Text, error) {
+	return value.(HstoreValuer).HstoreValue()
+}
+
+func (textValues) scanHstore(dst any, h map[string]pgtype.Text) error {
+	return dst.(HstoreScanner).ScanHstore(h)
+}
+
+func (textValues) valuer(h map[string]pgtype.Text) any {
+	return Hstore(h)
+}
+
+func (textValues) scanTarget(h *map[string]pgtype.Text) any {
+	return (*Hstore)(h)
+}
+
+// pointerValues adapts the *string values of HstoreCompat. The strings are stored in one array,
+// rather than one allocation per string.
+type pointerValues struct{}
+
+func (pointerValues) text(v *string) pgtype.Text {
+	if v == nil {
+		return pgtype.Text{}
+	}
+	return NewText(*v)
+}
+
+func (pointerValues) value(values []string, v pgtype.Text) ([]string, *string) {
+	if !v.Valid {
+		return values, nil
+	}
+	// values has capacity for all pairs unless the count was an underestimate or limited by
+	// initialPairs. If append reallocates, the earlier pointers still point to the previous array.
+	values = append(values, v.String)
+	return values, &values[len(values)-1]
+}
+
+func (pointerValues) allocMap(alloc Allocator, n int) map[string]*string {
+	return allocHstoreCompat(alloc, n)
+}
+
+func (pointerValues) allocValues(alloc Allocator, n int) []string {
+	return allocStrings(alloc, n)
+}
+
+func (pointerValues) hstoreValue(value any) (map[string]*string, error) {
+	return value.(HstoreCompatValuer).HstoreCompatValue()
+}
+
+func (pointerValues) scanHstore(dst any, h map[string]*string) error {
+	return dst.(HstoreCompatScanner).ScanHstoreCompat(h)
+}
+
+func (pointerValues) valuer(h map[string]*string) any {
+	return HstoreCompat(h)
+}
+
+func (pointerValues) scanTarget(h *map[string]*string) any {
+	return (*HstoreCompat)(h)
+}
+
+// hstoreCore implements the hstore operations for maps with values of type V. New features should
+// be implemented here, so they work for both Hstore and HstoreCompat.
+type hstoreCore[V any, A hstoreValues[V]] struct {
+	values A
+}
+
+var (
+	hstoreOps       hstoreCore[pgtype.Text, textValues]
+	hstoreCompatOps hstoreCore[*string, pointerValues]
+)
+
+// appendBinary appends h in the binary format.
+func (c hstoreCore[V, A]) appendBinary(buf []byte, h map[string]V) []byte {
+	buf = pgio.AppendInt32(buf, int32(len(h)))
+	for k, v := range h {
+		buf = pgio.AppendLengthPrefixedString(buf, k)
+		value := c.values.text(v)
+		if value.Valid {
+			buf = pgio.AppendLengthPrefixedString(buf, value.String)
+		} else {
+			buf = pgio.AppendInt32(buf, -1)
+		}
+	}
+	return buf
+}
+
+// appendText appends h in the text format.
+func (c hstoreCore[V, A]) appendText(buf []byte, h map[string]V) []byte {
+	firstPair := true
+	for k, v := range h {
+		if firstPair {
+			firstPair = false
+		} else {
+			buf = append(buf, ',', ' ')
+		}
+
+		// unconditionally quote hstore keys/values like Postgres does
+		// this avoids a Mac OS X Postgres hstore parsing bug:
+		// https://www.postgresql.org/message-id/CA%2BHWA9awUW0%2BRV_gO9r1ABZwGoZxPztcJxPy8vMFSTbTfi4jig%40mail.gmail.com
+		buf = appendPairText(buf, k, c.values.text(v))
+	}
+	return buf
+}
+
+// encodeTextTo writes h to w in the text format, in chunks of encodeTextToBufferLen bytes.
+func (c hstoreCore[V, A]) encodeTextTo(w io.Writer, h map[string]V) error {
+	buf := make([]byte, 0, encodeTextToBufferLen)
+	first := true
+	for k, v := range h {
+		if !first {
+			buf = append(buf, ',', ' ')
+		}
+		first = false
+		buf = appendPairText(buf, k, c.values.text(v))
+		if len(buf) >= encodeTextToBufferLen {
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	if len(buf) > 0 {
+		_, err := w.Write(buf)
+		return err
+	}
+	return nil
+}
+
+// sortedPairs returns the pairs of h sorted by key.
+func (c hstoreCore[V, A]) sortedPairs(h map[string]V) []HstorePair {
+	pairs := make([]HstorePair, 0, len(h))
+	for k, v := range h {
+		pairs = append(pairs, HstorePair{k, c.values.text(v)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+	return pairs
+}
+
+// checkStrings returns an *InvalidStringError if any key or value in h is invalid.
+func (c hstoreCore[V, A]) checkStrings(h map[string]V) error {
+	for k, v := range h {
+		if err := checkPairStrings(k, c.values.text(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of h that shares the values.
+func (c hstoreCore[V, A]) clone(h map[string]V) map[string]V {
+	out := make(map[string]V, len(h))
+	for k, v := range h {
+		out[k] = v
+	}
+	return out
+}
+
+// copyValues returns a copy of h allocated for its size, with the values copied to new storage.
+func (c hstoreCore[V, A]) copyValues(alloc Allocator, h map[string]V) map[string]V {
+	out := c.values.allocMap(alloc, len(h))
+	values := c.values.allocValues(alloc, len(h))
+	for k, v := range h {
+		values, out[k] = c.values.value(values, c.values.text(v))
+	}
+	return out
+}
+
+// shrink returns a copy of h allocated for its size, if estimate was much too large. The values are
+// copied to new storage, so the old storage can be freed.
+func (c hstoreCore[V, A]) shrink(alloc Allocator, h map[string]V, estimate int) map[string]V {
+	if !needsShrink(estimate, len(h)) {
+		return h
+	}
+	return c.copyValues(alloc, h)
+}
+
+// merge returns a new map with the pairs of h and other, with the values from other for keys in
+// both.
+func (c hstoreCore[V, A]) merge(h map[string]V, other map[string]V) map[string]V {
+	out := make(map[string]V, len(h)+len(other))
+	for k, v := range h {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+	return out
+}
+
+// diff returns the pairs only in other, the pairs only in h, and the pairs of other with different
+// values in h. The results are nil if empty.
+func (c hstoreCore[V, A]) diff(h map[string]V, other map[string]V) (added, removed, changed map[string]V) {
+	for k, v := range other {
+		old, ok := h[k]
+		if !ok {
+			if added == nil {
+				added = map[string]V{}
+			}
+			added[k] = v
+		} else if c.values.text(old) != c.values.text(v) {
+			if changed == nil {
+				changed = map[string]V{}
+			}
+			changed[k] = v
+		}
+	}
+	for k, v := range h {
+		if _, ok := other[k]; !ok {
+			if removed == nil {
+				removed = map[string]V{}
+			}
+			removed[k] = v
+		}
+	}
+	return added, removed, changed
+}
+
+// equal returns true if h and other have the same pairs, where NULL values are equal.
+func (c hstoreCore[V, A]) equal(h map[string]V, other map[string]V) bool {
+	if (h == nil) != (other == nil) || len(h) != len(other) {
+		return false
+	}
+	for k, v := range h {
+		otherValue, ok := other[k]
+		if !ok {
+			return false
+		}
+		value, otherText := c.values.text(v), c.values.text(otherValue)
+		if value.Valid != otherText.Valid || (value.Valid && value.String != otherText.String) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanBinary parses src in the binary format. It calls duplicate for duplicate keys if it is not
+// nil.
+func (c hstoreCore[V, A]) scanBinary(
+	src []byte, alloc Allocator, duplicate func(key string, offset int) error, separateStrings bool,
+) (map[string]V, error) {
+	var r pgio.Reader
+	r.Reset(src)
+	pairCount := r.ReadCount(minBinaryPairLen)
+	if r.Err() != nil {
+		return nil, fmt.Errorf("hstore incomplete: %w", r.Err())
+	}
+
+	hstore := c.values.allocMap(alloc, initialPairs(pairCount))
+	values := c.values.allocValues(alloc, initialPairs(pairCount))
+	strs := newBinaryStrings(alloc, &r, separateStrings)
+
+	for i := 0; i < pairCount; i++ {
+		pairOffset := r.Pos()
+		keyStart, keyLen := r.ReadLengthPrefixedRange()
+		valueStart, valueLen := r.ReadLengthPrefixedRange()
+		if r.Err() != nil {
+			return nil, fmt.Errorf("hstore incomplete: %w", r.Err())
+		}
+		if keyLen < 0 {
+			return nil, errBinaryNullKey
+		}
+		key := strs.get(keyStart, keyLen)
+		if duplicate != nil {
+			if _, exists := hstore[key]; exists {
+				if err := duplicate(key, pairOffset); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		value := pgtype.Text{}
+		if valueLen >= 0 {
+			value = NewText(strs.get(valueStart, valueLen))
+		}
+		values, hstore[key] = c.values.value(values, value)
+	}
+	return hstore, nil
+}
+
+// parseText parses s in the text format, using cfg's allocator and pair estimate. cfg can be nil.
+func (c hstoreCore[V, A]) parseText(s string, cfg *codecConfig) (map[string]V, error) {
+	if cfg.parsesLeniently() {
+		return c.parseLenient(s, cfg)
+	}
+	p := newHSP(s)
+	duplicate := cfg.duplicateHandler()
+
+	numPairsEstimate := cfg.estimatePairs(s)
+	alloc := cfg.allocator()
+	result := c.values.allocMap(alloc, numPairsEstimate)
+	values := c.values.allocValues(alloc, numPairsEstimate)
+	first := true
+	for !p.AtEnd() {
+		if !first {
+			err := p.consumePairSeparator()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			first = false
+		}
+
+		pairOffset := p.Pos()
+		err := p.ConsumeExpectedByte('"')
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := p.ConsumeDoubleQuoted()
+		if err != nil {
+			return nil, err
+		}
+		if duplicate != nil {
+			if _, exists := result[key]; exists {
+				if err := duplicate(key, pairOffset); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		err = p.consumeKVSeparator()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := p.consumeDoubleQuotedOrNull()
+		if err != nil {
+			return nil, err
+		}
+		if cfg.separatesStrings() {
+			key = strings.Clone(key)
+			value.String = strings.Clone(value.String)
+		}
+		values, result[key] = c.values.value(values, value)
+	}
+
+	return c.shrink(alloc, result, numPairsEstimate), nil
+}
+
+// parseLenient parses s with the Postgres input grammar, using cfg's allocator and options. cfg
+// can be nil.
+func (c hstoreCore[V, A]) parseLenient(s string, cfg *codecConfig) (map[string]V, error) {
+	alloc := cfg.allocator()
+	numPairsEstimate := cfg.estimatePairs(s)
+	result := c.values.allocMap(alloc, numPairsEstimate)
+	values := c.values.allocValues(alloc, numPairsEstimate)
+	err := parsePairsLenientFunc(s, cfg, func(key string, value pgtype.Text) error {
+		values, result[key] = c.values.value(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.shrink(alloc, result, numPairsEstimate), nil
+}
+
+// apply applies the configuration to h. If inPlace is false, h is copied before it is changed.
+func (c hstoreCore[V, A]) apply(cfg *codecConfig, h map[string]V, inPlace bool) (map[string]V, error) {
+	if h == nil {
+		return nil, nil
+	}
+	if err := cfg.checkPairCount(len(h)); err != nil {
+		return nil, err
+	}
+	if cfg.hasNullPolicy {
+		copied := inPlace
+		var values []string
+		for k, v := range h {
+			if c.values.text(v).Valid {
+				continue
+			}
+			if cfg.nullPolicy == NullError {
+				return nil, &NullValueError{k}
+			}
+			if !copied {
+				h = c.clone(h)
+				copied = true
+			}
+			if cfg.nullPolicy == NullSkip {
+				delete(h, k)
+			} else {
+				values, h[k] = c.values.value(values, pgtype.Text{Valid: true})
+			}
+		}
+	}
+	if cfg.validate != nil {
+		for k, v := range h {
+			if err := cfg.validate(k, c.values.text(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cfg.interner != nil && inPlace {
+		for k, v := range h {
+			// assigning an equal key replaces the stored key
+			h[cfg.interner.Intern(k)] = v
+		}
+	}
+	return h, nil
+}
+
+// planEncode returns the plan to encode the map type in format with cfg, without the options that
+// change the pairs.
+func (c hstoreCore[V, A]) planEncode(cfg *codecConfig, format int16) pgtype.EncodePlan {
+	var plan pgtype.EncodePlan
+	switch format {
+	case pgtype.BinaryFormatCode:
+		plan = encodePlanBinary[V, A]{}
+	case pgtype.TextFormatCode:
+		plan = encodePlanText[V, A]{}
+	default:
+		return nil
+	}
+	if cfg.sortsKeys() {
+		plan = encodePlanSorted[V, A]{format}
+	}
+	if cfg.validatesStrings() {
+		plan = &encodePlanValidateStrings[V, A]{next: plan}
+	}
+	return plan
+}
+
+// planScan returns the plan to scan format into the map type with cfg, without the options that
+// change the pairs.
+func (c hstoreCore[V, A]) planScan(cfg *codecConfig, format int16) pgtype.ScanPlan {
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return scanPlanBinary[V, A]{cfg.allocator(), cfg.duplicateHandler(), cfg.separatesStrings()}
+	case pgtype.TextFormatCode:
+		return scanPlanText[V, A]{cfg}
+	}
+	return nil
+}
+
+type encodePlanBinary[V any, A hstoreValues[V]] struct{}
+
+func (encodePlanBinary[V, A]) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	var c hstoreCore[V, A]
+	hstore, err := c.values.hstoreValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return c.appendBinary(buf, hstore), nil
+}
+
+type encodePlanText[V any, A hstoreValues[V]] struct{}
+
+func (encodePlanText[V, A]) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	var c hstoreCore[V, A]
+	hstore, err := c.values.hstoreValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return c.appendText(buf, hstore), nil
+}
+
+// encodePlanSorted encodes with the pairs sorted by key.
+type encodePlanSorted[V any, A hstoreValues[V]] struct {
+	format int16
+}
+
+func (p encodePlanSorted[V, A]) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	var c hstoreCore[V, A]
+	hstore, err := c.values.hstoreValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return appendPairs(p.format, buf, c.sortedPairs(hstore)), nil
+}
+
+// encodePlanValidateStrings checks the keys and values before encoding with next.
+type encodePlanValidateStrings[V any, A hstoreValues[V]] struct {
+	next pgtype.EncodePlan
+}
+
+func (p *encodePlanValidateStrings[V, A]) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	var c hstoreCore[V, A]
+	hstore, err := c.values.hstoreValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkStrings(hstore); err != nil {
+		return nil, err
+	}
+	return p.next.Encode(c.values.valuer(hstore), buf)
+}
+
+// encodePlanOptions applies the codec configuration before encoding with next.
+type encodePlanOptions[V any, A hstoreValues[V]] struct {
+	cfg  *codecConfig
+	next pgtype.EncodePlan
+}
+
+func (p *encodePlanOptions[V, A]) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	var c hstoreCore[V, A]
+	hstore, err := c.values.hstoreValue(value)
+	if err != nil {
+		return nil, err
+	}
+	hstore, err = c.apply(p.cfg, hstore, false)
+	if err != nil {
+		return nil, err
+	}
+	return p.next.Encode(c.values.valuer(hstore), buf)
+}
+
+type scanPlanBinary[V any, A hstoreValues[V]] struct {
+	alloc           Allocator
+	duplicate       func(key string, offset int) error // nil if duplicates are not checked
+	separateStrings bool
+}
+
+func (p scanPlanBinary[V, A]) Scan(src []byte, dst any) error {
+	var c hstoreCore[V, A]
+	if src == nil {
+		return c.values.scanHstore(dst, nil)
+	}
+	hstore, err := c.scanBinary(src, p.alloc, p.duplicate, p.separateStrings)
+	if err != nil {
+		return err
+	}
+	return c.values.scanHstore(dst, hstore)
+}
+
+type scanPlanText[V any, A hstoreValues[V]] struct {
+	cfg *codecConfig
+}
+
+func (s scanPlanText[V, A]) Scan(src []byte, dst any) error {
+	var c hstoreCore[V, A]
+	if src == nil {
+		return c.values.scanHstore(dst, nil)
+	}
+	return s.scanString(allocString(s.cfg.allocator(), src), dst)
+}
+
+// scanString does not return nil hstore values because string cannot be nil.
+func (s scanPlanText[V, A]) scanString(src string, dst any) error {
+	var c hstoreCore[V, A]
+	hstore, err := c.parseText(src, s.cfg)
+	if err != nil {
+		return err
+	}
+	return c.values.scanHstore(dst, hstore)
+}
+
+// scanPlanOptions scans with next, then applies the codec configuration.
+type scanPlanOptions[V any, A hstoreValues[V]] struct {
+	cfg    *codecConfig
+	format int16
+	next   pgtype.ScanPlan
+}
+
+func (p *scanPlanOptions[V, A]) Scan(src []byte, dst any) error {
+	var c hstoreCore[V, A]
+	if err := p.cfg.checkScanLimits(p.format, src); err != nil {
+		return err
+	}
+	var hstore map[string]V
+	if err := p.next.Scan(src, c.values.scanTarget(&hstore)); err != nil {
+		return err
+	}
+	hstore, err := c.apply(p.cfg, hstore, true)
+	if err != nil {
+		return err
+	}
+	return c.values.scanHstore(dst, hstore)
+}
@@ -0,0 +1,69 @@
+package pgxtypefaster
+
+import "fmt"
+
+// DecodeValueType selects the Go type returned by the codecs' DecodeValue method, which is used by
+// rows.Values() and pgx.RowToMap.
+type DecodeValueType int
+
+const (
+	// DecodeValueDefault returns the codec's type: Hstore for HstoreCodec and HstoreCompat for
+	// HstoreCompatCodec.
+	DecodeValueDefault DecodeValueType = iota
+	// DecodeValueHstore returns Hstore.
+	DecodeValueHstore
+	// DecodeValueHstoreCompat returns HstoreCompat.
+	DecodeValueHstoreCompat
+	// DecodeValuePointerMap returns map[string]*string, with nil for NULL values.
+	DecodeValuePointerMap
+	// DecodeValueStringMap returns map[string]string. It returns a *NullValueError if any value is
+	// NULL.
+	DecodeValueStringMap
+)
+
+func (t DecodeValueType) String() string {
+	switch t {
+	case DecodeValueDefault:
+		return "DecodeValueDefault"
+	case DecodeValueHstore:
+		return "DecodeValueHstore"
+	case DecodeValueHstoreCompat:
+		return "DecodeValueHstoreCompat"
+	case DecodeValuePointerMap:
+		return "DecodeValuePointerMap"
+	case DecodeValueStringMap:
+		return "DecodeValueStringMap"
+	}
+	return fmt.Sprintf("DecodeValueType(%d)", int(t))
+}
+
+// decodeHstoreValueAs converts h to the type selected by t. defaultType is used for
+// DecodeValueDefault.
+func decodeHstoreValueAs(t DecodeValueType, defaultType DecodeValueType, h Hstore) (any, error) {
+	if t == DecodeValueDefault {
+		t = defaultType
+	}
+	switch t {
+	case DecodeValueHstore:
+		return h, nil
+	case DecodeValueHstoreCompat:
+		return HstoreToCompat(h), nil
+	case DecodeValuePointerMap:
+		return map[string]*string(HstoreToCompat(h)), nil
+	case DecodeValueStringMap:
+		return toStringMap(h, NullError)
+	}
+	return nil, fmt.Errorf("unsupported %s", t)
+}
+
+// decodeHstoreCompatValueAs converts h to the type selected by t. It avoids converting to Hstore
+// when possible.
+func decodeHstoreCompatValueAs(t DecodeValueType, h HstoreCompat) (any, error) {
+	switch t {
+	case DecodeValueDefault, DecodeValueHstoreCompat:
+		return h, nil
+	case DecodeValuePointerMap:
+		return map[string]*string(h), nil
+	}
+	return decodeHstoreValueAs(t, DecodeValueHstore, CompatToHstore(h))
+}
@@ -0,0 +1,90 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestDecodeValueAs(t *testing.T) {
+	value := "b"
+	tests := []struct {
+		decodeAs pgxtypefaster.DecodeValueType
+		input    string
+		expected any
+	}{
+		{pgxtypefaster.DecodeValueHstore, `"a"=>"b"`, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}},
+		{pgxtypefaster.DecodeValueHstoreCompat, `"a"=>"b"`, pgxtypefaster.HstoreCompat{"a": &value}},
+		{pgxtypefaster.DecodeValuePointerMap, `"a"=>"b", "c"=>NULL`, map[string]*string{"a": &value, "c": nil}},
+		{pgxtypefaster.DecodeValueStringMap, `"a"=>"b"`, map[string]string{"a": "b"}},
+	}
+
+	for _, test := range tests {
+		codecs := []pgtype.Codec{
+			pgxtypefaster.HstoreCodec{DecodeValueAs: test.decodeAs},
+			pgxtypefaster.HstoreCompatCodec{DecodeValueAs: test.decodeAs},
+		}
+		for _, codec := range codecs {
+			out, err := codec.DecodeValue(nil, 0, pgtype.TextFormatCode, []byte(test.input))
+			if err != nil {
+				t.Fatalf("%T %s: %s", codec, test.decodeAs, err)
+			}
+			if !reflect.DeepEqual(out, test.expected) {
+				t.Errorf("%T %s: DecodeValue=%#v; expected %#v", codec, test.decodeAs, out, test.expected)
+			}
+		}
+	}
+
+	// default types
+	out, err := pgxtypefaster.HstoreCodec{}.DecodeValue(nil, 0, pgtype.TextFormatCode, []byte(`"a"=>"b"`))
+	if _, ok := out.(pgxtypefaster.Hstore); !ok || err != nil {
+		t.Errorf("HstoreCodec default DecodeValue=%T, %v", out, err)
+	}
+	out, err = pgxtypefaster.HstoreCompatCodec{}.DecodeValue(nil, 0, pgtype.TextFormatCode, []byte(`"a"=>"b"`))
+	if _, ok := out.(pgxtypefaster.HstoreCompat); !ok || err != nil {
+		t.Errorf("HstoreCompatCodec default DecodeValue=%T, %v", out, err)
+	}
+
+	// map[string]string cannot represent NULL
+	codec := pgxtypefaster.HstoreCodec{DecodeValueAs: pgxtypefaster.DecodeValueStringMap}
+	_, err = codec.DecodeValue(nil, 0, pgtype.TextFormatCode, []byte(`"a"=>NULL`))
+	var nullErr *pgxtypefaster.NullValueError
+	if !errors.As(err, &nullErr) {
+		t.Errorf("expected NullValueError; err=%v", err)
+	}
+}
+
+func TestDecodeValueCompatFormats(t *testing.T) {
+	// untyped scans, such as Rows.Values, use DecodeValue for both formats
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": {}}
+	tests := []struct {
+		opts     []pgxtypefaster.CodecOption
+		expected any
+	}{
+		{nil, fasterToCompat(input)},
+		{[]pgxtypefaster.CodecOption{pgxtypefaster.WithDecodeValueAs(pgxtypefaster.DecodeValueHstore)}, input},
+	}
+	for _, test := range tests {
+		m := newOptionsTypeMap(test.opts...)
+		compatType, ok := m.TypeForOID(testHstoreOID + 1)
+		if !ok {
+			t.Fatal("compat codec not registered")
+		}
+		for _, format := range formats {
+			buf, err := m.Encode(testHstoreOID+1, format, fasterToCompat(input), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := compatType.Codec.DecodeValue(m, testHstoreOID+1, format, buf)
+			if err != nil {
+				t.Fatalf("format %d: %s", format, err)
+			}
+			if !reflect.DeepEqual(out, test.expected) {
+				t.Errorf("format %d: DecodeValue=%#v; expected %#v", format, out, test.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package pgxtypefaster
+
+import (
+	"reflect"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	hstoreType          = reflect.TypeOf(Hstore(nil))
+	hstorePtrType       = reflect.TypeOf((*Hstore)(nil))
+	hstoreCompatType    = reflect.TypeOf(HstoreCompat(nil))
+	hstoreCompatPtrType = reflect.TypeOf((*HstoreCompat)(nil))
+
+	hstoreValuerType        = reflect.TypeOf((*HstoreValuer)(nil)).Elem()
+	hstoreCompatValuerType  = reflect.TypeOf((*HstoreCompatValuer)(nil)).Elem()
+	orderedHstoreValuerType = reflect.TypeOf((*OrderedHstoreValuer)(nil)).Elem()
+	hstorePairsValuerType   = reflect.TypeOf((*HstorePairsValuer)(nil)).Elem()
+)
+
+// isValuerPointer returns true if value is a pointer to a type that implements valuer with value
+// methods, such as *Hstore for HstoreValuer. pgx's wrapper plans do not dereference these, since
+// they implement driver.Valuer, so the codecs plan them with planDerefPointer.
+func isValuerPointer(value any, valuer reflect.Type) bool {
+	t := reflect.TypeOf(value)
+	return t != nil && t.Kind() == reflect.Pointer && t.Elem().Implements(valuer)
+}
+
+// planDerefPointer returns a plan that encodes the value that value points to with codec.
+func planDerefPointer(codec pgtype.Codec, m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	elem := reflect.Zero(reflect.TypeOf(value).Elem()).Interface()
+	next := codec.PlanEncode(m, oid, format, elem)
+	if next == nil {
+		return nil
+	}
+	return &encodePlanDerefPointer{next}
+}
+
+// encodePlanDerefPointer encodes the value a pointer points to, and nil pointers as NULL. Calling
+// a value method with a nil pointer panics.
+type encodePlanDerefPointer struct {
+	next pgtype.EncodePlan
+}
+
+func (p *encodePlanDerefPointer) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	v := reflect.ValueOf(value)
+	if v.IsNil() {
+		return nil, nil
+	}
+	return p.next.Encode(v.Elem().Interface(), buf)
+}
+
+// isConvertibleMap returns true if value is a map with the same underlying type as to, such as a
+// derived type declared as `type Labels pgxtypefaster.Hstore`.
+func isConvertibleMap(value any, to reflect.Type) bool {
+	t := reflect.TypeOf(value)
+	return t != nil && t.Kind() == reflect.Map && t.ConvertibleTo(to)
+}
+
+// isConvertibleMapPointer returns true if target is a pointer to a map with the same underlying
+// type as *to, such as *Labels for `type Labels pgxtypefaster.Hstore`.
+func isConvertibleMapPointer(target any, to reflect.Type) bool {
+	t := reflect.TypeOf(target)
+	return t != nil && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Map && t.ConvertibleTo(to)
+}
+
+// encodePlanConvert converts derived map types to the type used by the next plan.
+type encodePlanConvert struct {
+	to   reflect.Type
+	next pgtype.EncodePlan
+}
+
+func (p *encodePlanConvert) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	return p.next.Encode(reflect.ValueOf(value).Convert(p.to).Interface(), buf)
+}
+
+// scanPlanConvert converts pointers to derived map types to the pointer type used by the next
+// plan. The pointers point to the same map variable, so the next plan scans into the target.
+type scanPlanConvert struct {
+	to   reflect.Type
+	next pgtype.ScanPlan
+}
+
+func (p *scanPlanConvert) Scan(src []byte, dst any) error {
+	return p.next.Scan(src, reflect.ValueOf(dst).Convert(p.to).Interface())
+}
@@ -0,0 +1,114 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type derivedHstore pgxtypefaster.Hstore
+type derivedHstoreCompat pgxtypefaster.HstoreCompat
+
+type embeddedHstore struct {
+	pgxtypefaster.Hstore
+}
+
+func TestDerivedTypes(t *testing.T) {
+	m := pgtype.NewMap()
+	const compatOID = testHstoreOID + 1
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstoreCodec{}, Name: "hstore", OID: testHstoreOID})
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstoreCompatCodec{}, Name: "hstore_compat", OID: compatOID})
+
+	value := "v"
+	tests := []struct {
+		oid    uint32
+		input  any
+		output any
+	}{
+		{testHstoreOID, derivedHstore{"k": pgxtypefaster.NewText(value)}, &derivedHstore{}},
+		{testHstoreOID, map[string]pgtype.Text{"k": pgxtypefaster.NewText(value)}, &map[string]pgtype.Text{}},
+		{testHstoreOID, embeddedHstore{pgxtypefaster.Hstore{"k": pgxtypefaster.NewText(value)}}, &embeddedHstore{}},
+		{compatOID, derivedHstoreCompat{"k": &value}, &derivedHstoreCompat{}},
+		{compatOID, pgtype.Hstore{"k": &value}, &pgtype.Hstore{}},
+	}
+	for _, test := range tests {
+		for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+			encoded, err := m.Encode(test.oid, format, test.input, nil)
+			if err != nil {
+				t.Fatalf("%T format=%d: Encode failed: %s", test.input, format, err)
+			}
+			err = m.Scan(test.oid, format, encoded, test.output)
+			if err != nil {
+				t.Fatalf("%T format=%d: Scan failed: %s", test.input, format, err)
+			}
+			if !reflect.DeepEqual(reflect.ValueOf(test.output).Elem().Interface(), test.input) {
+				t.Errorf("%T format=%d: output=%#v; expected %#v", test.input, format, test.output, test.input)
+			}
+		}
+	}
+}
+
+func TestPointerTypes(t *testing.T) {
+	m := pgtype.NewMap()
+	const compatOID = testHstoreOID + 1
+	const orderedOID = testHstoreOID + 2
+	const pairsOID = testHstoreOID + 3
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstoreCodec{}, Name: "hstore", OID: testHstoreOID})
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstoreCompatCodec{}, Name: "hstore_compat", OID: compatOID})
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.OrderedHstoreCodec{}, Name: "hstore_ordered", OID: orderedOID})
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstorePairsCodec{}, Name: "hstore_pairs", OID: pairsOID})
+
+	h := pgxtypefaster.Hstore{"k": pgxtypefaster.NewText("v")}
+	derived := derivedHstore(h)
+	hPtr := &h
+	compat := fasterToCompat(h).(pgxtypefaster.HstoreCompat)
+	for _, format := range formats {
+		expected, err := m.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range []any{&h, &hPtr, &derived, &compat} {
+			encoded, err := m.Encode(testHstoreOID, format, input, nil)
+			if err != nil || string(encoded) != string(expected) {
+				t.Errorf("format=%d: Encode(%T)=%q, %v; expected %q", format, input, encoded, err, expected)
+			}
+		}
+
+		// nil pointers are NULL
+		nilPointers := []struct {
+			oid   uint32
+			input any
+		}{
+			{testHstoreOID, (*pgxtypefaster.Hstore)(nil)},
+			{testHstoreOID, (**pgxtypefaster.Hstore)(nil)},
+			{testHstoreOID, (*derivedHstore)(nil)},
+			{testHstoreOID, (*pgxtypefaster.HstoreCompat)(nil)},
+			{compatOID, (*pgxtypefaster.HstoreCompat)(nil)},
+			{orderedOID, (*pgxtypefaster.OrderedHstore)(nil)},
+			{pairsOID, (*pgxtypefaster.HstorePairs)(nil)},
+		}
+		for _, test := range nilPointers {
+			encoded, err := m.Encode(test.oid, format, test.input, nil)
+			if err != nil || encoded != nil {
+				t.Errorf("format=%d: Encode(%T(nil))=%q, %v; expected NULL", format, test.input, encoded, err)
+			}
+		}
+
+		// pointers to pointers are allocated, and set to nil for NULL
+		var scanned *pgxtypefaster.Hstore
+		var scannedDerived *derivedHstore
+		for _, target := range []any{&scanned, &scannedDerived} {
+			if err := m.Scan(testHstoreOID, format, expected, target); err != nil {
+				t.Fatalf("format=%d: Scan(%T): %s", format, target, err)
+			}
+		}
+		if scanned == nil || !scanned.Equal(h) || scannedDerived == nil || len(*scannedDerived) != 1 {
+			t.Errorf("format=%d: scanned %#v %#v", format, scanned, scannedDerived)
+		}
+		if err := m.Scan(testHstoreOID, format, nil, &scanned); err != nil || scanned != nil {
+			t.Errorf("format=%d: Scan(NULL)=%#v, %v", format, scanned, err)
+		}
+	}
+}
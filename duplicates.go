@@ -0,0 +1,60 @@
+package pgxtypefaster
+
+import "fmt"
+
+// WithStrictDuplicates returns a *DuplicateKeyError when scanning an hstore that contains a key
+// more than once, instead of keeping the last value. By default, all scan targets that store pairs
+// by key keep the last value for a duplicate key, in both the text and binary formats.
+// HstorePairs and OrderedHstore keep all the pairs. Postgres removes duplicate keys when it stores
+// an hstore, so this is only useful to validate values from other sources, such as text columns
+// or query parameters cast to hstore on the client.
+func WithStrictDuplicates() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.strictDuplicates = true
+	}
+}
+
+// WithOnDuplicate calls onDuplicate with each duplicate key found when scanning, before the last
+// value replaces the earlier one. It can count or log unexpected input without failing the scan.
+// With WithStrictDuplicates, it is called before the error is returned. It must be safe to call
+// concurrently if the codec is used concurrently.
+func WithOnDuplicate(onDuplicate func(key string)) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.onDuplicate = onDuplicate
+	}
+}
+
+// checksDuplicates returns true if scanning must check for duplicate keys.
+func (cfg *codecConfig) checksDuplicates() bool {
+	return cfg != nil && (cfg.strictDuplicates || cfg.onDuplicate != nil)
+}
+
+// duplicateHandler returns the function to call when scanning finds a duplicate key at offset, or
+// nil if duplicates are not checked. An error from it must be returned by the scan.
+func (cfg *codecConfig) duplicateHandler() func(key string, offset int) error {
+	if !cfg.checksDuplicates() {
+		return nil
+	}
+	return func(key string, offset int) error {
+		if cfg.onDuplicate != nil {
+			cfg.onDuplicate(key)
+		}
+		if cfg.strictDuplicates {
+			return &DuplicateKeyError{key, offset}
+		}
+		return nil
+	}
+}
+
+// DuplicateKeyError is returned when scanning an hstore with a duplicate key, if the codec was
+// created with WithStrictDuplicates.
+type DuplicateKeyError struct {
+	Key string
+	// Offset is the byte offset of the second occurrence of the pair in the input: the opening
+	// quote of the key for the text format, or the key length for the binary format.
+	Offset int
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("hstore has duplicate key %#v at offset %d", e.Key, e.Offset)
+}
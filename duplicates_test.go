@@ -0,0 +1,92 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+// duplicateInputs contain the key "a" twice, with the values "1" then "3".
+var duplicateInputs = []struct {
+	format int16
+	src    []byte
+	// offset of the duplicate pair
+	offset int
+}{
+	{formats[0], []byte(`"a"=>"1", "b"=>"2", "a"=>"3"`), 20},
+	{formats[1], []byte{
+		0, 0, 0, 2,
+		0, 0, 0, 1, 'a', 0, 0, 0, 1, '1',
+		0, 0, 0, 1, 'a', 0, 0, 0, 1, '3',
+	}, 14},
+}
+
+func TestWithStrictDuplicates(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithStrictDuplicates())
+	lenient := newOptionsTypeMap()
+
+	for _, input := range duplicateInputs {
+		targets := []any{&pgxtypefaster.Hstore{}, &pgxtypefaster.HstoreCompat{}, &map[string]string{}}
+		for i, oid := range []uint32{testHstoreOID, testHstoreOID + 1, testHstoreOID} {
+			err := m.Scan(oid, input.format, input.src, targets[i])
+			var dupErr *pgxtypefaster.DuplicateKeyError
+			if !errors.As(err, &dupErr) || dupErr.Key != "a" || dupErr.Offset != input.offset {
+				t.Errorf("format=%d target=%T: expected DuplicateKeyError at %d; err=%v",
+					input.format, targets[i], input.offset, err)
+			}
+
+			// the last value is kept by default
+			if err := lenient.Scan(oid, input.format, input.src, targets[i]); err != nil {
+				t.Errorf("format=%d target=%T: lenient scan failed: %s", input.format, targets[i], err)
+			}
+		}
+	}
+
+	var h pgxtypefaster.Hstore
+	if err := m.Scan(testHstoreOID, formats[0], []byte(`"a"=>"1", "b"=>"2"`), &h); err != nil {
+		t.Errorf("unique keys failed: %s", err)
+	}
+}
+
+func TestDuplicatesLastWins(t *testing.T) {
+	var reported []string
+	onDuplicate := pgxtypefaster.WithOnDuplicate(func(key string) {
+		reported = append(reported, key)
+	})
+
+	for _, opts := range [][]pgxtypefaster.CodecOption{nil, {onDuplicate}} {
+		m := newOptionsTypeMap(opts...)
+		for _, input := range duplicateInputs {
+			var h, reused pgxtypefaster.Hstore
+			var compat pgxtypefaster.HstoreCompat
+			var strs map[string]string
+			var anys map[string]any
+			subset := pgxtypefaster.HstoreSubset{Keys: []string{"a"}}
+			targets := []struct {
+				oid    uint32
+				target any
+				value  func() any
+			}{
+				{testHstoreOID, &h, func() any { return h["a"].String }},
+				{testHstoreOID + 1, &compat, func() any { return *compat["a"] }},
+				{testHstoreOID, &strs, func() any { return strs["a"] }},
+				{testHstoreOID, &anys, func() any { return anys["a"] }},
+				{testHstoreOID, &subset, func() any { return subset.Out["a"].String }},
+				{testHstoreOID, pgxtypefaster.ReuseHstore{Target: &reused}, func() any { return reused["a"].String }},
+			}
+			for _, target := range targets {
+				reported = nil
+				if err := m.Scan(target.oid, input.format, input.src, target.target); err != nil {
+					t.Fatal(err)
+				}
+				if target.value() != "3" {
+					t.Errorf("format=%d target=%T: value=%#v; expected the last value", input.format, target.target, target.value())
+				}
+				if opts != nil && (len(reported) != 1 || reported[0] != "a") {
+					t.Errorf("format=%d target=%T: OnDuplicate reported %#v", input.format, target.target, reported)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pgxtypefaster
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WithEncodeCache caches the encoding of up to maxEntries distinct Hstore values for each format,
+// evicting the least recently used. Encoding a cached value copies the previous encoding instead
+// of encoding it again. This is useful for workloads that write a small set of distinct values at
+// high rates. Looking up a value costs about as much as encoding it in the binary format, so this
+// mostly helps the text format (used by database/sql) when values must be escaped. See
+// BenchmarkEncodeCache. It only applies to HstoreCodec. If maxEntries is <= 0, values are not
+// cached.
+func WithEncodeCache(maxEntries int) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.encodeCache = nil
+		if maxEntries > 0 {
+			cfg.encodeCache = newEncodeCache(maxEntries)
+		}
+	}
+}
+
+// encodeCacheKey identifies a cached encoding, using Hstore.Hash. Different values can have the
+// same hash, so entries also store the value.
+type encodeCacheKey struct {
+	hash   uint64
+	format int16
+}
+
+type encodeCacheEntry struct {
+	key     encodeCacheKey
+	value   Hstore
+	encoded []byte
+}
+
+// encodeCache is a least recently used cache of encoded Hstore values. It is safe to use
+// concurrently, since codecs are shared by connections.
+type encodeCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[encodeCacheKey]*list.Element
+	// lru contains *encodeCacheEntry, with the most recently used at the front.
+	lru *list.List
+}
+
+func newEncodeCache(maxEntries int) *encodeCache {
+	return &encodeCache{
+		maxEntries: maxEntries,
+		entries:    map[encodeCacheKey]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// get appends the cached encoding of h to buf. It returns false if h is not cached.
+func (c *encodeCache) get(key encodeCacheKey, h Hstore, buf []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return buf, false
+	}
+	entry := elem.Value.(*encodeCacheEntry)
+	if !entry.value.Equal(h) {
+		return buf, false
+	}
+	c.lru.MoveToFront(elem)
+	return append(buf, entry.encoded...), true
+}
+
+// put stores a copy of h and encoded, replacing any entry with the same key.
+func (c *encodeCache) put(key encodeCacheKey, h Hstore, encoded []byte) {
+	entry := &encodeCacheEntry{key, hstoreOps.clone(h), append([]byte(nil), encoded...)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	if c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*encodeCacheEntry).key)
+	}
+}
+
+// encodePlanHstoreCache returns cached encodings, and encodes values that are not cached with
+// next.
+type encodePlanHstoreCache struct {
+	cache  *encodeCache
+	format int16
+	next   pgtype.EncodePlan
+}
+
+func (p *encodePlanHstoreCache) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	hstore, err := value.(HstoreValuer).HstoreValue()
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+
+	key := encodeCacheKey{hstore.Hash(), p.format}
+	if buf, ok := p.cache.get(key, hstore, buf); ok {
+		return buf, nil
+	}
+	start := len(buf)
+	buf, err = p.next.Encode(hstore, buf)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.put(key, hstore, buf[start:])
+	return buf, nil
+}
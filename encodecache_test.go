@@ -0,0 +1,97 @@
+package pgxtypefaster_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestWithEncodeCache(t *testing.T) {
+	cached := newOptionsTypeMap(pgxtypefaster.WithEncodeCache(2))
+	uncached := newOptionsTypeMap()
+
+	values := []pgxtypefaster.Hstore{
+		{"a": pgxtypefaster.NewText("1"), "b": {}},
+		{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("")},
+		{"b": pgxtypefaster.NewText("a")},
+		{"a": pgxtypefaster.NewText("b")},
+		{},
+	}
+	for _, format := range formats {
+		// encode each value several times, to hit the cache and evict entries
+		for i := 0; i < 3; i++ {
+			for _, h := range values {
+				prefix := []byte("prefix")
+				buf, err := cached.Encode(testHstoreOID, format, h, prefix)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(buf[:len(prefix)]) != "prefix" {
+					t.Fatalf("format=%d: Encode must append to buf: %#v", format, string(buf))
+				}
+				var out pgxtypefaster.Hstore
+				if err := uncached.Scan(testHstoreOID, format, buf[len(prefix):], &out); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(out, h) {
+					t.Errorf("format=%d: cached encoding of %#v decoded to %#v", format, h, out)
+				}
+			}
+		}
+
+		// changing a value after it was cached must not return the old encoding
+		h := pgxtypefaster.Hstore{"k": pgxtypefaster.NewText("v1")}
+		if _, err := cached.Encode(testHstoreOID, format, h, nil); err != nil {
+			t.Fatal(err)
+		}
+		h["k"] = pgxtypefaster.NewText("v2")
+		buf, err := cached.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out pgxtypefaster.Hstore
+		if err := uncached.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out["k"].String != "v2" {
+			t.Errorf("format=%d: returned stale encoding: %#v", format, out)
+		}
+
+		buf, err = cached.Encode(testHstoreOID, format, pgxtypefaster.Hstore(nil), nil)
+		if err != nil || buf != nil {
+			t.Errorf("format=%d: nil Hstore must encode as NULL: %#v %v", format, buf, err)
+		}
+	}
+}
+
+func BenchmarkEncodeCache(b *testing.B) {
+	h := pgxtypefaster.Hstore{}
+	for _, k := range []string{"color", "size", "material", "brand", "region"} {
+		// quotes must be escaped in the text format
+		h[k] = pgxtypefaster.NewText(`"` + k + `" value`)
+	}
+	for _, bench := range []struct {
+		name string
+		opts []pgxtypefaster.CodecOption
+	}{
+		{"uncached", nil},
+		{"cached", []pgxtypefaster.CodecOption{pgxtypefaster.WithEncodeCache(16)}},
+	} {
+		m := newOptionsTypeMap(bench.opts...)
+		for _, format := range formats {
+			b.Run(fmt.Sprintf("%s/format=%d", bench.name, format), func(b *testing.B) {
+				b.ReportAllocs()
+				buf := make([]byte, 0, 1024)
+				for i := 0; i < b.N; i++ {
+					var err error
+					buf, err = m.Encode(testHstoreOID, format, h, buf[:0])
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
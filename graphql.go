@@ -0,0 +1,49 @@
+package pgxtypefaster
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MarshalGQL implements the gqlgen graphql.Marshaler interface. It writes h as a JSON object,
+// with NULL values as JSON null. A nil Hstore is written as null.
+func (h Hstore) MarshalGQL(w io.Writer) {
+	if h == nil {
+		io.WriteString(w, "null")
+		return
+	}
+	// json.Marshal sorts keys, and cannot fail: pgtype.Text.MarshalJSON never returns an error
+	out, err := json.Marshal(h)
+	if err != nil {
+		panic(err)
+	}
+	w.Write(out)
+}
+
+// UnmarshalGQL implements the gqlgen graphql.Unmarshaler interface. It accepts a GraphQL input
+// object (map[string]any) where each value is a string or null.
+func (h *Hstore) UnmarshalGQL(v any) error {
+	switch v := v.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case map[string]any:
+		out := make(Hstore, len(v))
+		for k, value := range v {
+			switch value := value.(type) {
+			case nil:
+				out[k] = pgtype.Text{}
+			case string:
+				out[k] = NewText(value)
+			default:
+				return fmt.Errorf("hstore key %#v: value must be a string or null; found %T", k, value)
+			}
+		}
+		*h = out
+		return nil
+	}
+	return fmt.Errorf("cannot unmarshal %T into Hstore: must be an object", v)
+}
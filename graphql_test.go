@@ -0,0 +1,41 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestGraphQL(t *testing.T) {
+	h := pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("x\"y"), "a": pgtype.Text{}}
+	var out strings.Builder
+	h.MarshalGQL(&out)
+	if out.String() != `{"a":null,"b":"x\"y"}` {
+		t.Errorf("MarshalGQL=%s", out.String())
+	}
+	out.Reset()
+	pgxtypefaster.Hstore(nil).MarshalGQL(&out)
+	if out.String() != "null" {
+		t.Errorf("MarshalGQL(nil)=%s", out.String())
+	}
+
+	var parsed pgxtypefaster.Hstore
+	err := parsed.UnmarshalGQL(map[string]any{"b": "x\"y", "a": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, h) {
+		t.Errorf("UnmarshalGQL=%#v; expected %#v", parsed, h)
+	}
+	err = parsed.UnmarshalGQL(map[string]any{"a": 1})
+	if err == nil {
+		t.Error("expected error for non-string value")
+	}
+	err = parsed.UnmarshalGQL("string")
+	if err == nil {
+		t.Error("expected error for non-object")
+	}
+}
@@ -0,0 +1,79 @@
+package pgxtypefaster
+
+import "math/bits"
+
+// FNV-1a constants from hash/fnv. They are used directly to hash without allocating.
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// nullHstoreHash is the Hash of a nil Hstore, so it differs from an empty Hstore.
+const nullHstoreHash = 0x9e3779b97f4a7c15
+
+// Hash returns a hash of the pairs of h that does not depend on their order, so equal Hstores as
+// defined by Equal have equal hashes. It is stable across processes and versions of this package,
+// so it can be stored, for example as part of a cache key. It is not a cryptographic hash.
+func (h Hstore) Hash() uint64 {
+	if h == nil {
+		return nullHstoreHash
+	}
+	// sum the hashes of the pairs, which does not depend on the order
+	var sum uint64
+	for k, v := range h {
+		pairHash := uint64(fnvOffset64)
+		pairHash = fnvString(pairHash, k)
+		if v.Valid {
+			// separates the key from the value, and non-NULL values from NULL
+			pairHash = fnvByte(pairHash, 1)
+			pairHash = fnvString(pairHash, v.String)
+		} else {
+			pairHash = fnvByte(pairHash, 0)
+		}
+		sum += mix64(pairHash)
+	}
+	return mix64(sum + uint64(len(h)))
+}
+
+// Canonical returns the text format of h with the pairs sorted by key, so equal Hstores as defined
+// by Equal have the same string. It can be parsed with ParseHstore or used as a cache key. A nil
+// Hstore returns the empty string, the same as an empty Hstore.
+func (h Hstore) Canonical() string {
+	// buf was allocated here and is not used again
+	return ownedBytesToString(appendPairsText(nil, hstoreOps.sortedPairs(h)))
+}
+
+// fnvString hashes s with FNV-1a, except that it hashes 8 bytes at a time while it can, which is
+// several times faster for long strings.
+func fnvString(hash uint64, s string) uint64 {
+	// the length separates the key and value, so "ab"+"c" differs from "a"+"bc"
+	hash = fnvWord(hash, uint64(len(s)))
+	for ; len(s) >= 8; s = s[8:] {
+		hash = fnvWord(hash, uint64(s[0])|uint64(s[1])<<8|uint64(s[2])<<16|uint64(s[3])<<24|
+			uint64(s[4])<<32|uint64(s[5])<<40|uint64(s[6])<<48|uint64(s[7])<<56)
+	}
+	for i := 0; i < len(s); i++ {
+		hash = fnvByte(hash, s[i])
+	}
+	return hash
+}
+
+// fnvWord hashes 8 bytes at once. The rotation moves the high bits of the product, which depend on
+// all the bytes, to the low bits.
+func fnvWord(hash uint64, word uint64) uint64 {
+	return bits.RotateLeft64((hash^word)*fnvPrime64, 31)
+}
+
+func fnvByte(hash uint64, b byte) uint64 {
+	return (hash ^ uint64(b)) * fnvPrime64
+}
+
+// mix64 is the splitmix64 finalizer. It spreads the bits of each pair hash before they are summed.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
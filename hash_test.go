@@ -0,0 +1,52 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreHashCanonical(t *testing.T) {
+	h := pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("2"), "a": pgxtypefaster.NewText("1"), "c": pgtype.Text{}}
+	// a NULL value with a String is equal to other NULL values
+	equal := pgxtypefaster.Hstore{"c": pgtype.Text{String: "ignored"}, "a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2")}
+	if h.Hash() != equal.Hash() {
+		t.Errorf("equal Hstores must have equal hashes: %x %x", h.Hash(), equal.Hash())
+	}
+	if h.Canonical() != `"a"=>"1", "b"=>"2", "c"=>NULL` || equal.Canonical() != h.Canonical() {
+		t.Errorf("Canonical()=%#v %#v", h.Canonical(), equal.Canonical())
+	}
+	parsed, err := pgxtypefaster.ParseHstore(h.Canonical())
+	if err != nil || !reflect.DeepEqual(parsed, h) {
+		t.Errorf("ParseHstore(Canonical())=%#v, %v", parsed, err)
+	}
+
+	different := []pgxtypefaster.Hstore{
+		nil,
+		{},
+		{"a": pgxtypefaster.NewText("1")},
+		{"a": pgxtypefaster.NewText("")},
+		{"a": pgtype.Text{}},
+		{"": pgxtypefaster.NewText("a")},
+		{"ab": pgxtypefaster.NewText("c")},
+		{"a": pgxtypefaster.NewText("bc")},
+		{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2")},
+		{"a": pgxtypefaster.NewText("2"), "b": pgxtypefaster.NewText("1")},
+		h,
+	}
+	hashes := map[uint64]int{}
+	for i, h := range different {
+		if j, exists := hashes[h.Hash()]; exists {
+			t.Errorf("%#v and %#v have the same hash %x", different[j], h, h.Hash())
+		}
+		hashes[h.Hash()] = i
+	}
+
+	// the hash is stable
+	const expectedHash = 0x41b7ae19fbc75a3
+	if h.Hash() != expectedHash {
+		t.Errorf("Hash()=%#x; expected %#x", h.Hash(), uint64(expectedHash))
+	}
+}
@@ -4,13 +4,12 @@ package pgxtypefaster
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
-	"encoding/binary"
 	"errors"
 	"fmt"
-	"strings"
 
-	"github.com/evanj/pgxtypefaster/internal/pgio"
+	"github.com/evanj/pgxtypefaster/internal/parsing"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -26,34 +25,101 @@ type HstoreValuer interface {
 var ErrHstoreDoesNotExist = errors.New("postgres type hstore does not exist (the extension may not be loaded)")
 
 // queryHstoreOID returns the Postgres Object Identifer (OID) for the "hstore" type. This must be
-// done for each separate Postgres database, since the OID can be different. It returns
-// errHstoreDoesNotExist if the row does not exist.
+// done for each separate Postgres database, since the OID can be different. If DefaultOIDCache is
+// set, the OID is cached in it. It returns the type found using search_path, like the name hstore
+// in a query. If hstore is not in a schema on the search_path, it returns the type in any schema.
+// It returns ErrHstoreDoesNotExist if the type does not exist.
 func queryHstoreOID(ctx context.Context, conn *pgx.Conn) (uint32, error) {
+	return queryHstoreOIDInSchema(ctx, conn, "")
+}
+
+// queryHstoreOIDInSchema returns the OID of the "hstore" type in schema, or like queryHstoreOID if
+// schema is empty.
+func queryHstoreOIDInSchema(ctx context.Context, conn *pgx.Conn, schema string) (uint32, error) {
+	name := "hstore"
+	if schema != "" {
+		name = pgx.Identifier{schema, "hstore"}.Sanitize()
+	}
+	if oid, ok := DefaultOIDCache.Lookup(conn, name); ok {
+		return oid, nil
+	}
 	// get the hstore OID: it varies because hstore is an extension and not built-in
-	var hstoreOID uint32
-	err := conn.QueryRow(ctx, `select oid from pg_type where typname = 'hstore'`).Scan(&hstoreOID)
+	oids, err := lookupOIDs(ctx, conn, []string{name})
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return 0, ErrHstoreDoesNotExist
-		}
 		return 0, err
 	}
+	hstoreOID, ok := oids[name]
+	if !ok {
+		return 0, ErrHstoreDoesNotExist
+	}
+	DefaultOIDCache.Store(conn, name, hstoreOID)
 	return hstoreOID, nil
 }
 
 // RegisterHstore registers the Hstore type with conn's default type map. It queries the database
-// for the Hstore OID to be able to register it.
+// for the Hstore OID to be able to register it. On servers that do not support the binary format,
+// the codec only uses the text format.
 func RegisterHstore(ctx context.Context, conn *pgx.Conn) error {
+	_, err := RegisterHstoreOID(ctx, conn)
+	return err
+}
+
+// RegisterHstoreOID registers the Hstore type like RegisterHstore, and returns the hstore OID, so
+// it can be used without querying again, such as for COPY, logical replication, or
+// RegisterHstoreWithOID on other connections to the same database.
+func RegisterHstoreOID(ctx context.Context, conn *pgx.Conn) (uint32, error) {
 	hstoreOID, err := queryHstoreOID(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	RegisterHstoreWithOID(conn, hstoreOID)
+	return hstoreOID, nil
+}
+
+// RegisterHstoreInSchema registers the Hstore type in schema with conn's default type map, for
+// databases where the hstore extension is not installed in a schema on the search_path, or is
+// installed in more than one schema.
+func RegisterHstoreInSchema(ctx context.Context, conn *pgx.Conn, schema string) error {
+	hstoreOID, err := queryHstoreOIDInSchema(ctx, conn, schema)
 	if err != nil {
 		return err
 	}
-	conn.TypeMap().RegisterType(&pgtype.Type{Codec: HstoreCodec{}, Name: "hstore", OID: hstoreOID})
+	RegisterHstoreWithOID(conn, hstoreOID)
 	return nil
 }
 
+// RegisterHstoreCreateExtension creates the hstore extension if it does not exist, then registers
+// the Hstore type like RegisterHstore. Creating the extension requires the CREATE privilege on the
+// database. It is intended for tests and databases that set up their own schema.
+func RegisterHstoreCreateExtension(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, "create extension if not exists hstore"); err != nil {
+		return err
+	}
+	return RegisterHstore(ctx, conn)
+}
+
+// RegisterHstoreWithOID registers the Hstore type with conn's default type map, using oid as the
+// hstore OID without querying the database. Use it when the OID is known ahead of time, or when
+// catalog queries are not allowed, such as with pgbouncer in transaction pooling mode.
+func RegisterHstoreWithOID(conn *pgx.Conn, oid uint32) {
+	conn.TypeMap().RegisterType(NewHstoreType(oid, hstoreRegisterOptions(conn)...))
+}
+
+// RegisterHstoreMap registers the Hstore type with m, using oid as the hstore OID. Unlike
+// RegisterHstoreWithOID, it does not know the server version, so the codec uses the binary format.
+func RegisterHstoreMap(m *pgtype.Map, oid uint32) {
+	m.RegisterType(NewHstoreType(oid))
+}
+
+// NewHstoreType returns the hstore type with oid and an HstoreCodec configured with opts, to
+// register with a pgtype.Map without a connection.
+func NewHstoreType(oid uint32, opts ...CodecOption) *pgtype.Type {
+	return &pgtype.Type{Codec: NewHstoreCodec(opts...), Name: "hstore", OID: oid}
+}
+
 // Hstore represents an hstore column that can be null or have null values
-// associated with its keys.
+// associated with its keys. It marshals to JSON with encoding/json as an object with NULL values as
+// null, and a nil Hstore as null, and unmarshals the same objects.
 type Hstore map[string]pgtype.Text
 
 func (h *Hstore) ScanHstore(v Hstore) error {
@@ -83,7 +149,41 @@ func PGXToFasterHstore(m map[string]*string) Hstore {
 	return h
 }
 
-// Scan implements the database/sql Scanner interface.
+// NewHstoreFromMap returns an Hstore with the pairs in m, with no NULL values. A nil map returns a
+// nil (NULL) Hstore.
+func NewHstoreFromMap(m map[string]string) Hstore {
+	return fromStringMap(m)
+}
+
+// NewHstoreFromPointerMap returns an Hstore with the pairs in m, where nil pointers are NULL values.
+// A nil map returns a nil (NULL) Hstore.
+func NewHstoreFromPointerMap(m map[string]*string) Hstore {
+	if m == nil {
+		return nil
+	}
+	return PGXToFasterHstore(m)
+}
+
+// NewHstoreFromPairs returns an Hstore from alternating keys and values, with no NULL values. If
+// a key is repeated, the last value is used. It panics if given an odd number of arguments.
+func NewHstoreFromPairs(keyValues ...string) Hstore {
+	if len(keyValues)%2 == 1 {
+		panic("pgxtypefaster.NewHstoreFromPairs: odd argument count")
+	}
+	h := make(Hstore, len(keyValues)/2)
+	for i := 0; i < len(keyValues); i += 2 {
+		h[keyValues[i]] = NewText(keyValues[i+1])
+	}
+	return h
+}
+
+// Ensure Hstore works with database/sql and libraries built on it, such as sqlx.
+var _ sql.Scanner = (*Hstore)(nil)
+var _ driver.Valuer = Hstore(nil)
+
+// Scan implements the database/sql Scanner interface. It accepts the text format as a string, as a
+// []byte, which is returned by some drivers such as lib/pq, or as a fmt.Stringer. The []byte is
+// copied, so it can be reused by the driver.
 func (h *Hstore) Scan(src any) error {
 	if src == nil {
 		*h = nil
@@ -93,6 +193,10 @@ func (h *Hstore) Scan(src any) error {
 	switch src := src.(type) {
 	case string:
 		return scanPlanTextAnyToHstoreScanner{}.scanString(src, h)
+	case []byte:
+		return scanPlanTextAnyToHstoreScanner{}.scanString(string(src), h)
+	case fmt.Stringer:
+		return scanPlanTextAnyToHstoreScanner{}.scanString(src.String(), h)
 	}
 
 	return fmt.Errorf("cannot scan %T", src)
@@ -108,198 +212,120 @@ func (h Hstore) Value() (driver.Value, error) {
 	if err != nil {
 		return nil, err
 	}
-	return string(buf), err
+	// buf was allocated by Encode and is not used again
+	return ownedBytesToString(buf), err
 }
 
-type HstoreCodec struct{}
+type HstoreCodec struct {
+	// DecodeValueAs selects the type returned by DecodeValue. The default is Hstore.
+	DecodeValueAs DecodeValueType
 
-func (HstoreCodec) FormatSupported(format int16) bool {
-	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+	// cfg is set by NewHstoreCodec. It is nil for the zero value.
+	cfg *codecConfig
 }
 
-func (HstoreCodec) PreferredFormat() int16 {
-	return pgtype.BinaryFormatCode
+func (c HstoreCodec) FormatSupported(format int16) bool {
+	return c.cfg.formatSupported(format)
 }
 
-func (HstoreCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
-	if _, ok := value.(HstoreValuer); !ok {
-		return nil
-	}
-
-	switch format {
-	case pgtype.BinaryFormatCode:
-		return encodePlanHstoreCodecBinary{}
-	case pgtype.TextFormatCode:
-		return encodePlanHstoreCodecText{}
-	}
-
-	return nil
+func (c HstoreCodec) PreferredFormat() int16 {
+	return c.cfg.preferredFormat()
 }
 
-type encodePlanHstoreCodecBinary struct{}
-
-func (encodePlanHstoreCodecBinary) Encode(value any, buf []byte) (newBuf []byte, err error) {
-	hstore, err := value.(HstoreValuer).HstoreValue()
-	if err != nil {
-		return nil, err
+func (c HstoreCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if isValuerPointer(value, hstoreValuerType) || isValuerPointer(value, hstoreCompatValuerType) {
+		return planDerefPointer(c, m, oid, format, value)
 	}
-
-	if hstore == nil {
-		return nil, nil
-	}
-
-	buf = pgio.AppendInt32(buf, int32(len(hstore)))
-
-	for k, v := range hstore {
-		buf = pgio.AppendInt32(buf, int32(len(k)))
-		buf = append(buf, k...)
-
-		if v.Valid {
-			buf = pgio.AppendInt32(buf, int32(len(v.String)))
-			buf = append(buf, (v.String)...)
-		} else {
-			buf = pgio.AppendInt32(buf, -1)
+	if _, ok := value.(HstoreValuer); !ok {
+		// derived types like `type Labels Hstore`
+		if isConvertibleMap(value, hstoreType) {
+			if next := c.PlanEncode(m, oid, format, Hstore(nil)); next != nil {
+				return &encodePlanConvert{to: hstoreType, next: next}
+			}
 		}
+		// HstoreCompat, pgtype.Hstore, and other map[string]*string values use the compatible codec,
+		// like scanning, so one registered codec handles both types
+		if _, ok := value.(HstoreCompatValuer); ok || isConvertibleMap(value, hstoreCompatType) {
+			return HstoreCompatCodec{cfg: c.cfg}.PlanEncode(m, oid, format, value)
+		}
+		return nil
 	}
 
-	return buf, nil
-}
-
-type encodePlanHstoreCodecText struct{}
-
-func (encodePlanHstoreCodecText) Encode(value any, buf []byte) (newBuf []byte, err error) {
-	hstore, err := value.(HstoreValuer).HstoreValue()
-	if err != nil {
-		return nil, err
+	plan := hstoreOps.planEncode(c.cfg, format)
+	if plan == nil {
+		return nil
 	}
-
-	if hstore == nil {
-		return nil, nil
+	if cache := c.cfg.cache(); cache != nil {
+		plan = &encodePlanHstoreCache{cache: cache, format: format, next: plan}
 	}
-
-	firstPair := true
-
-	for k, v := range hstore {
-		if firstPair {
-			firstPair = false
-		} else {
-			buf = append(buf, ',', ' ')
-		}
-
-		// unconditionally quote hstore keys/values like Postgres does
-		// this avoids a Mac OS X Postgres hstore parsing bug:
-		// https://www.postgresql.org/message-id/CA%2BHWA9awUW0%2BRV_gO9r1ABZwGoZxPztcJxPy8vMFSTbTfi4jig%40mail.gmail.com
-		buf = append(buf, '"')
-		buf = append(buf, quoteArrayReplacer.Replace(k)...)
-		buf = append(buf, '"')
-		buf = append(buf, "=>"...)
-
-		if v.Valid {
-			buf = append(buf, '"')
-			buf = append(buf, quoteArrayReplacer.Replace(v.String)...)
-			buf = append(buf, '"')
-		} else {
-			buf = append(buf, "NULL"...)
-		}
+	if c.cfg.transformsPairs() {
+		return &encodePlanHstoreOptions{cfg: c.cfg, next: plan}
 	}
-
-	return buf, nil
+	return plan
 }
 
-func (HstoreCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+// the plans for Hstore, implemented by hstoreCore
+type (
+	encodePlanHstoreCodecBinary         = encodePlanBinary[pgtype.Text, textValues]
+	encodePlanHstoreCodecText           = encodePlanText[pgtype.Text, textValues]
+	encodePlanHstoreOptions             = encodePlanOptions[pgtype.Text, textValues]
+	scanPlanBinaryHstoreToHstoreScanner = scanPlanBinary[pgtype.Text, textValues]
+	scanPlanTextAnyToHstoreScanner      = scanPlanText[pgtype.Text, textValues]
+	scanPlanHstoreOptions               = scanPlanOptions[pgtype.Text, textValues]
+)
 
-	switch format {
-	case pgtype.BinaryFormatCode:
-		switch target.(type) {
-		case HstoreScanner:
-			return scanPlanBinaryHstoreToHstoreScanner{}
-		}
-	case pgtype.TextFormatCode:
-		switch target.(type) {
-		case HstoreScanner:
-			return scanPlanTextAnyToHstoreScanner{}
+func (c HstoreCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if plan := c.planScanMap(m, oid, format, target); plan != nil {
+		return plan
+	}
+	plan := c.planScan(m, oid, format, target)
+	if plan != nil && c.cfg.transformsPairs() {
+		if convert, ok := plan.(*scanPlanConvert); ok {
+			// apply the options before converting to the derived type
+			next := &scanPlanHstoreOptions{cfg: c.cfg, format: format, next: convert.next}
+			return &scanPlanConvert{to: convert.to, next: next}
 		}
+		return &scanPlanHstoreOptions{cfg: c.cfg, format: format, next: plan}
 	}
-
-	return nil
+	return plan
 }
 
-type scanPlanBinaryHstoreToHstoreScanner struct{}
-
-func (scanPlanBinaryHstoreToHstoreScanner) Scan(src []byte, dst any) error {
-	scanner := (dst).(HstoreScanner)
-
-	if src == nil {
-		return scanner.ScanHstore(Hstore(nil))
-	}
-
-	rp := 0
-
-	const uint32Len = 4
-	if len(src[rp:]) < uint32Len {
-		return fmt.Errorf("hstore incomplete %v", src)
+func (c HstoreCodec) planScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(HstoreScanner); ok {
+		return hstoreOps.planScan(c.cfg, format)
 	}
-	pairCount := int(int32(binary.BigEndian.Uint32(src[rp:])))
-	rp += uint32Len
 
-	hstore := make(Hstore, pairCount)
-	// one shared string for all key/value strings
-	keyValueString := string(src[rp:])
-
-	for i := 0; i < pairCount; i++ {
-		if len(src[rp:]) < uint32Len {
-			return fmt.Errorf("hstore incomplete %v", src)
-		}
-		keyLen := int(int32(binary.BigEndian.Uint32(src[rp:])))
-		rp += uint32Len
-
-		if len(src[rp:]) < keyLen {
-			return fmt.Errorf("hstore incomplete %v", src)
-		}
-		key := string(keyValueString[rp-uint32Len : rp-uint32Len+keyLen])
-		rp += keyLen
-
-		if len(src[rp:]) < uint32Len {
-			return fmt.Errorf("hstore incomplete %v", src)
-		}
-		valueLen := int(int32(binary.BigEndian.Uint32(src[rp:])))
-		rp += 4
-
-		if valueLen >= 0 {
-			value := string(keyValueString[rp-uint32Len : rp-uint32Len+valueLen])
-			rp += valueLen
-
-			hstore[key] = pgtype.Text{String: value, Valid: true}
-		} else {
-			hstore[key] = pgtype.Text{String: "", Valid: false}
+	// pointers to derived types like `type Labels Hstore`
+	if isConvertibleMapPointer(target, hstorePtrType) {
+		if next := c.planScan(m, oid, format, (*Hstore)(nil)); next != nil {
+			return &scanPlanConvert{to: hstorePtrType, next: next}
 		}
 	}
 
-	return scanner.ScanHstore(hstore)
+	return nil
 }
 
-type scanPlanTextAnyToHstoreScanner struct{}
+// minBinaryPairLen is the minimum size of a key/value pair in the binary format: the key length
+// and the value length.
+const minBinaryPairLen = 8
 
-func (s scanPlanTextAnyToHstoreScanner) Scan(src []byte, dst any) error {
-	scanner := (dst).(HstoreScanner)
+// maxInitialPairs limits the capacity allocated from an estimated pair count. Binary counts are
+// checked against the size of the value, but a corrupted value could still allocate several times
+// its size before the error is found. Larger hstores grow as they are parsed.
+const maxInitialPairs = 4096
 
-	if src == nil {
-		return scanner.ScanHstore(Hstore(nil))
+// initialPairs returns the capacity to allocate for count pairs.
+func initialPairs(count int) int {
+	if count > maxInitialPairs {
+		return maxInitialPairs
 	}
-	return s.scanString(string(src), scanner)
+	return count
 }
 
-// scanString does not return nil hstore values because string cannot be nil.
-func (scanPlanTextAnyToHstoreScanner) scanString(src string, scanner HstoreScanner) error {
-	hstore, err := parseHstore(src)
-	if err != nil {
-		return err
-	}
-	return scanner.ScanHstore(hstore)
-}
+var errBinaryNullKey = errors.New("hstore key cannot be NULL")
 
 func (c HstoreCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
-	return codecDecodeToTextFormat(c, m, oid, format, src)
+	return codecDecodeToTextFormat(c, m, oid, format, src, c.cfg.pool())
 }
 
 func (c HstoreCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
@@ -312,211 +338,62 @@ func (c HstoreCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []
 	if err != nil {
 		return nil, err
 	}
-	return hstore, nil
+	if c.DecodeValueAs == DecodeValueDefault {
+		return hstore, nil
+	}
+	return decodeHstoreValueAs(c.DecodeValueAs, DecodeValueHstore, hstore)
 }
 
 type hstoreParser struct {
-	str           string
-	pos           int
-	nextBackslash int
+	parsing.Parser
 }
 
 func newHSP(in string) *hstoreParser {
-	return &hstoreParser{
-		pos:           0,
-		str:           in,
-		nextBackslash: strings.IndexByte(in, '\\'),
-	}
-}
-
-func (p *hstoreParser) atEnd() bool {
-	return p.pos >= len(p.str)
-}
-
-// consume returns the next byte of the string, or end if the string is done.
-func (p *hstoreParser) consume() (b byte, end bool) {
-	if p.pos >= len(p.str) {
-		return 0, true
-	}
-	b = p.str[p.pos]
-	p.pos++
-	return b, false
-}
-
-func unexpectedByteErr(actualB byte, expectedB byte) error {
-	return fmt.Errorf("expected '%c' ('%#v'); found '%c' ('%#v')", expectedB, expectedB, actualB, actualB)
-}
-
-// consumeExpectedByte consumes expectedB from the string, or returns an error.
-func (p *hstoreParser) consumeExpectedByte(expectedB byte) error {
-	nextB, end := p.consume()
-	if end {
-		return fmt.Errorf("expected '%c' ('%#v'); found end", expectedB, expectedB)
-	}
-	if nextB != expectedB {
-		return unexpectedByteErr(nextB, expectedB)
-	}
-	return nil
-}
-
-// consumeExpected2 consumes two expected bytes or returns an error.
-// This was a bit faster than using a string argument (better inlining? Not sure).
-func (p *hstoreParser) consumeExpected2(one byte, two byte) error {
-	if p.pos+2 > len(p.str) {
-		return errors.New("unexpected end of string")
-	}
-	if p.str[p.pos] != one {
-		return unexpectedByteErr(p.str[p.pos], one)
-	}
-	if p.str[p.pos+1] != two {
-		return unexpectedByteErr(p.str[p.pos+1], two)
-	}
-	p.pos += 2
-	return nil
-}
-
-var errEOSInQuoted = errors.New(`found end before closing double-quote ('"')`)
-
-// consumeDoubleQuoted consumes a double-quoted string from p. The double quote must have been
-// parsed already.
-func (p *hstoreParser) consumeDoubleQuoted() (string, error) {
-	// fast path: assume most keys/values do not contain escapes
-	nextDoubleQuote := strings.IndexByte(p.str[p.pos:], '"')
-	if nextDoubleQuote == -1 {
-		return "", errEOSInQuoted
-	}
-	nextDoubleQuote += p.pos
-	if p.nextBackslash == -1 || p.nextBackslash > nextDoubleQuote {
-		// no escapes in this string
-		s := p.str[p.pos:nextDoubleQuote]
-		p.pos = nextDoubleQuote + 1
-		return s, nil
-	}
-
-	// slow path: string contains escapes
-	s, err := p.consumeDoubleQuotedWithEscapes(p.nextBackslash)
-	p.nextBackslash = strings.IndexByte(p.str[p.pos:], '\\')
-	if p.nextBackslash != -1 {
-		p.nextBackslash += p.pos
-	}
-	return s, err
-}
-
-// consumeDoubleQuotedWithEscapes consumes a double-quoted string containing escapes, starting
-// at p.pos, and with the first backslash at firstBackslash.
-func (p *hstoreParser) consumeDoubleQuotedWithEscapes(firstBackslash int) (string, error) {
-	// copy the prefix that does not contain backslashes
-	var builder strings.Builder
-	builder.WriteString(p.str[p.pos:firstBackslash])
-
-	// skip to the backslash
-	p.pos = firstBackslash
-
-	// copy bytes until the end, unescaping backslashes
-	for {
-		nextB, end := p.consume()
-		if end {
-			return "", errEOSInQuoted
-		} else if nextB == '"' {
-			break
-		} else if nextB == '\\' {
-			// escape: skip the backslash and copy the char
-			nextB, end = p.consume()
-			if end {
-				return "", errEOSInQuoted
-			}
-			if !(nextB == '\\' || nextB == '"') {
-				return "", fmt.Errorf("unexpected escape in quoted string: found '%#v'", nextB)
-			}
-			builder.WriteByte(nextB)
-		} else {
-			// normal byte: copy it
-			builder.WriteByte(nextB)
-		}
-	}
-	return builder.String(), nil
+	return &hstoreParser{parsing.New(in)}
 }
 
 // consumePairSeparator consumes the Hstore pair separator ", " or returns an error.
 func (p *hstoreParser) consumePairSeparator() error {
-	return p.consumeExpected2(',', ' ')
+	return p.ConsumeExpected2(',', ' ')
 }
 
 // consumeKVSeparator consumes the Hstore key/value separator "=>" or returns an error.
 func (p *hstoreParser) consumeKVSeparator() error {
-	return p.consumeExpected2('=', '>')
+	return p.ConsumeExpected2('=', '>')
 }
 
-// consumeDoubleQuotedOrNull consumes the Hstore key/value separator "=>" or returns an error.
+// consumeDoubleQuotedOrNull consumes a double-quoted value or NULL.
 func (p *hstoreParser) consumeDoubleQuotedOrNull() (pgtype.Text, error) {
 	// peek at the next byte
-	if p.atEnd() {
-		return pgtype.Text{}, errors.New("found end instead of value")
+	next, end := p.Peek()
+	if end {
+		return pgtype.Text{}, p.ErrorAt(p.Pos(), `'"' or NULL`)
 	}
-	next := p.str[p.pos]
 	if next == 'N' {
-		// must be the exact string NULL: use consumeExpected2 twice
-		err := p.consumeExpected2('N', 'U')
+		// must be the exact string NULL: use ConsumeExpected2 twice
+		err := p.ConsumeExpected2('N', 'U')
 		if err != nil {
 			return pgtype.Text{}, err
 		}
-		err = p.consumeExpected2('L', 'L')
+		err = p.ConsumeExpected2('L', 'L')
 		if err != nil {
 			return pgtype.Text{}, err
 		}
 		return pgtype.Text{String: "", Valid: false}, nil
 	} else if next != '"' {
-		return pgtype.Text{}, unexpectedByteErr(next, '"')
+		return pgtype.Text{}, p.ErrorAt(p.Pos(), `'"' or NULL`)
 	}
 
 	// skip the double quote
-	p.pos += 1
-	s, err := p.consumeDoubleQuoted()
+	p.Skip(1)
+	s, err := p.ConsumeDoubleQuoted()
 	if err != nil {
 		return pgtype.Text{}, err
 	}
 	return NewText(s), nil
 }
 
-func parseHstore(s string) (Hstore, error) {
-	p := newHSP(s)
-
-	// This is an over-estimate of the number of key/value pairs. Use '>' because I am guessing it
-	// is less likely to occur in keys/values than '=' or ','.
-	numPairsEstimate := strings.Count(s, ">")
-	result := make(Hstore, numPairsEstimate)
-	first := true
-	for !p.atEnd() {
-		if !first {
-			err := p.consumePairSeparator()
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			first = false
-		}
-
-		err := p.consumeExpectedByte('"')
-		if err != nil {
-			return nil, err
-		}
-
-		key, err := p.consumeDoubleQuoted()
-		if err != nil {
-			return nil, err
-		}
-
-		err = p.consumeKVSeparator()
-		if err != nil {
-			return nil, err
-		}
-
-		value, err := p.consumeDoubleQuotedOrNull()
-		if err != nil {
-			return nil, err
-		}
-		result[key] = value
-	}
-
-	return result, nil
+// parseHstore parses s, using cfg's allocator and pair estimate. cfg can be nil.
+func parseHstore(s string, cfg *codecConfig) (Hstore, error) {
+	return hstoreOps.parseText(s, cfg)
 }
@@ -5,11 +5,8 @@ package pgxtypefaster
 import (
 	"context"
 	"database/sql/driver"
-	"encoding/binary"
 	"fmt"
-	"strings"
 
-	"github.com/evanj/pgxtypefaster/internal/pgio"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -21,10 +18,16 @@ func RegisterHstoreCompat(ctx context.Context, conn *pgx.Conn) error {
 	if err != nil {
 		return err
 	}
-	conn.TypeMap().RegisterType(&pgtype.Type{Codec: HstoreCompatCodec{}, Name: "hstore", OID: hstoreOID})
+	conn.TypeMap().RegisterType(NewHstoreCompatType(hstoreOID, hstoreRegisterOptions(conn)...))
 	return nil
 }
 
+// NewHstoreCompatType returns the hstore type with oid and an HstoreCompatCodec configured with
+// opts, to register with a pgtype.Map without a connection.
+func NewHstoreCompatType(oid uint32, opts ...CodecOption) *pgtype.Type {
+	return &pgtype.Type{Codec: NewHstoreCompatCodec(opts...), Name: "hstore", OID: oid}
+}
+
 type HstoreCompatScanner interface {
 	ScanHstoreCompat(v HstoreCompat) error
 }
@@ -34,9 +37,36 @@ type HstoreCompatValuer interface {
 }
 
 // HstoreCompat represents an hstore column that can be null or have null values
-// associated with its keys.
+// associated with its keys. Like Hstore, it marshals to JSON with encoding/json as an object with
+// NULL values as null, and a nil HstoreCompat as null, so it does not need to be converted to
+// Hstore to be serialized.
 type HstoreCompat map[string]*string
 
+// HstoreToCompat converts h to an HstoreCompat. It makes one allocation for all the values, like
+// the binary scan. A nil Hstore returns nil.
+func HstoreToCompat(h Hstore) HstoreCompat {
+	if h == nil {
+		return nil
+	}
+	out := make(HstoreCompat, len(h))
+	valueStrings := make([]string, 0, len(h))
+	for k, v := range h {
+		if v.Valid {
+			valueStrings = append(valueStrings, v.String)
+			out[k] = &valueStrings[len(valueStrings)-1]
+		} else {
+			out[k] = nil
+		}
+	}
+	return out
+}
+
+// CompatToHstore converts h to an Hstore. Hstore stores the values in the map, so it only
+// allocates the map. A nil HstoreCompat returns nil.
+func CompatToHstore(h HstoreCompat) Hstore {
+	return NewHstoreFromPointerMap(h)
+}
+
 func (h *HstoreCompat) ScanHstoreCompat(v HstoreCompat) error {
 	*h = v
 	return nil
@@ -46,7 +76,8 @@ func (h HstoreCompat) HstoreCompatValue() (HstoreCompat, error) {
 	return h, nil
 }
 
-// Scan implements the database/sql Scanner interface.
+// Scan implements the database/sql Scanner interface. It accepts the text format as a string, a
+// []byte, or a fmt.Stringer.
 func (h *HstoreCompat) Scan(src any) error {
 	if src == nil {
 		*h = nil
@@ -56,6 +87,11 @@ func (h *HstoreCompat) Scan(src any) error {
 	switch src := src.(type) {
 	case string:
 		return scanPlanTextAnyToHstoreCompatScanner{}.scanString(src, h)
+	case []byte:
+		// copy: drivers may reuse the buffer
+		return scanPlanTextAnyToHstoreCompatScanner{}.scanString(string(src), h)
+	case fmt.Stringer:
+		return scanPlanTextAnyToHstoreCompatScanner{}.scanString(src.String(), h)
 	}
 
 	return fmt.Errorf("cannot scan %T", src)
@@ -71,200 +107,87 @@ func (h HstoreCompat) Value() (driver.Value, error) {
 	if err != nil {
 		return nil, err
 	}
-	return string(buf), err
+	// buf was allocated by Encode and is not used again
+	return ownedBytesToString(buf), err
 }
 
-type HstoreCompatCodec struct{}
+type HstoreCompatCodec struct {
+	// DecodeValueAs selects the type returned by DecodeValue. The default is HstoreCompat.
+	DecodeValueAs DecodeValueType
 
-func (HstoreCompatCodec) FormatSupported(format int16) bool {
-	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+	// cfg is set by NewHstoreCompatCodec. It is nil for the zero value.
+	cfg *codecConfig
 }
 
-func (HstoreCompatCodec) PreferredFormat() int16 {
-	return pgtype.BinaryFormatCode
+func (c HstoreCompatCodec) FormatSupported(format int16) bool {
+	return c.cfg.formatSupported(format)
 }
 
-func (HstoreCompatCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
-	if _, ok := value.(HstoreCompatValuer); !ok {
-		return nil
-	}
-
-	switch format {
-	case pgtype.BinaryFormatCode:
-		return encodePlanHstoreCompatCodecBinary{}
-	case pgtype.TextFormatCode:
-		return encodePlanHstoreCompatCodecText{}
-	}
-
-	return nil
+func (c HstoreCompatCodec) PreferredFormat() int16 {
+	return c.cfg.preferredFormat()
 }
 
-type encodePlanHstoreCompatCodecBinary struct{}
-
-func (encodePlanHstoreCompatCodecBinary) Encode(value any, buf []byte) (newBuf []byte, err error) {
-	hstore, err := value.(HstoreCompatValuer).HstoreCompatValue()
-	if err != nil {
-		return nil, err
-	}
-
-	if hstore == nil {
-		return nil, nil
+func (c HstoreCompatCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if isValuerPointer(value, hstoreCompatValuerType) {
+		return planDerefPointer(c, m, oid, format, value)
 	}
-
-	buf = pgio.AppendInt32(buf, int32(len(hstore)))
-
-	for k, v := range hstore {
-		buf = pgio.AppendInt32(buf, int32(len(k)))
-		buf = append(buf, k...)
-
-		if v == nil {
-			buf = pgio.AppendInt32(buf, -1)
-		} else {
-			buf = pgio.AppendInt32(buf, int32(len(*v)))
-			buf = append(buf, (*v)...)
+	if _, ok := value.(HstoreCompatValuer); !ok {
+		// derived types like `type Labels HstoreCompat`
+		if isConvertibleMap(value, hstoreCompatType) {
+			if next := c.PlanEncode(m, oid, format, HstoreCompat(nil)); next != nil {
+				return &encodePlanConvert{to: hstoreCompatType, next: next}
+			}
 		}
+		return nil
 	}
 
-	return buf, nil
-}
-
-type encodePlanHstoreCompatCodecText struct{}
-
-func (encodePlanHstoreCompatCodecText) Encode(value any, buf []byte) (newBuf []byte, err error) {
-	hstore, err := value.(HstoreCompatValuer).HstoreCompatValue()
-	if err != nil {
-		return nil, err
-	}
-
-	if hstore == nil {
-		return nil, nil
+	plan := hstoreCompatOps.planEncode(c.cfg, format)
+	if plan == nil {
+		return nil
 	}
-
-	firstPair := true
-
-	for k, v := range hstore {
-		if firstPair {
-			firstPair = false
-		} else {
-			buf = append(buf, ',', ' ')
-		}
-
-		// unconditionally quote hstore keys/values like Postgres does
-		// this avoids a Mac OS X Postgres hstore parsing bug:
-		// https://www.postgresql.org/message-id/CA%2BHWA9awUW0%2BRV_gO9r1ABZwGoZxPztcJxPy8vMFSTbTfi4jig%40mail.gmail.com
-		buf = append(buf, '"')
-		buf = append(buf, quoteArrayReplacer.Replace(k)...)
-		buf = append(buf, '"')
-		buf = append(buf, "=>"...)
-
-		if v == nil {
-			buf = append(buf, "NULL"...)
-		} else {
-			buf = append(buf, '"')
-			buf = append(buf, quoteArrayReplacer.Replace(*v)...)
-			buf = append(buf, '"')
-		}
+	if c.cfg.transformsPairs() {
+		return &encodePlanHstoreCompatOptions{cfg: c.cfg, next: plan}
 	}
-
-	return buf, nil
+	return plan
 }
 
-func (HstoreCompatCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+// the plans for HstoreCompat, implemented by hstoreCore
+type (
+	encodePlanHstoreCompatOptions        = encodePlanOptions[*string, pointerValues]
+	scanPlanTextAnyToHstoreCompatScanner = scanPlanText[*string, pointerValues]
+	scanPlanHstoreCompatOptions          = scanPlanOptions[*string, pointerValues]
+)
 
-	switch format {
-	case pgtype.BinaryFormatCode:
-		switch target.(type) {
-		case HstoreCompatScanner:
-			return scanPlanBinaryHstoreToHstoreCompatScanner{}
-		}
-	case pgtype.TextFormatCode:
-		switch target.(type) {
-		case HstoreCompatScanner:
-			return scanPlanTextAnyToHstoreCompatScanner{}
+func (c HstoreCompatCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	plan := c.planScan(m, oid, format, target)
+	if plan != nil && c.cfg.transformsPairs() {
+		if convert, ok := plan.(*scanPlanConvert); ok {
+			// apply the options before converting to the derived type
+			next := &scanPlanHstoreCompatOptions{cfg: c.cfg, format: format, next: convert.next}
+			return &scanPlanConvert{to: convert.to, next: next}
 		}
+		return &scanPlanHstoreCompatOptions{cfg: c.cfg, format: format, next: plan}
 	}
-
-	return nil
+	return plan
 }
 
-type scanPlanBinaryHstoreToHstoreCompatScanner struct{}
-
-func (scanPlanBinaryHstoreToHstoreCompatScanner) Scan(src []byte, dst any) error {
-	scanner := (dst).(HstoreCompatScanner)
-
-	if src == nil {
-		return scanner.ScanHstoreCompat(HstoreCompat(nil))
-	}
-
-	rp := 0
-
-	const uint32Len = 4
-	if len(src[rp:]) < uint32Len {
-		return fmt.Errorf("hstore incomplete %v", src)
+func (c HstoreCompatCodec) planScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(HstoreCompatScanner); ok {
+		return hstoreCompatOps.planScan(c.cfg, format)
 	}
-	pairCount := int(int32(binary.BigEndian.Uint32(src[rp:])))
-	rp += uint32Len
-
-	hstore := make(HstoreCompat, pairCount)
-	// one allocation for all *string, rather than one per string, just like text parsing
-	valueStrings := make([]string, pairCount)
-	// one shared string for all key/value strings
-	keyValueString := string(src[rp:])
 
-	for i := 0; i < pairCount; i++ {
-		if len(src[rp:]) < uint32Len {
-			return fmt.Errorf("hstore incomplete %v", src)
+	// pointers to derived types like `type Labels HstoreCompat`
+	if isConvertibleMapPointer(target, hstoreCompatPtrType) {
+		if next := c.planScan(m, oid, format, (*HstoreCompat)(nil)); next != nil {
+			return &scanPlanConvert{to: hstoreCompatPtrType, next: next}
 		}
-		keyLen := int(int32(binary.BigEndian.Uint32(src[rp:])))
-		rp += uint32Len
-
-		if len(src[rp:]) < keyLen {
-			return fmt.Errorf("hstore incomplete %v", src)
-		}
-		key := string(keyValueString[rp-uint32Len : rp-uint32Len+keyLen])
-		rp += keyLen
-
-		if len(src[rp:]) < uint32Len {
-			return fmt.Errorf("hstore incomplete %v", src)
-		}
-		valueLen := int(int32(binary.BigEndian.Uint32(src[rp:])))
-		rp += 4
-
-		if valueLen >= 0 {
-			valueStrings[i] = string(keyValueString[rp-uint32Len : rp-uint32Len+valueLen])
-			rp += valueLen
-
-			hstore[key] = &valueStrings[i]
-		} else {
-			hstore[key] = nil
-		}
-	}
-
-	return scanner.ScanHstoreCompat(hstore)
-}
-
-type scanPlanTextAnyToHstoreCompatScanner struct{}
-
-func (s scanPlanTextAnyToHstoreCompatScanner) Scan(src []byte, dst any) error {
-	scanner := (dst).(HstoreCompatScanner)
-
-	if src == nil {
-		return scanner.ScanHstoreCompat(HstoreCompat(nil))
 	}
-	return s.scanString(string(src), scanner)
-}
 
-// scanString does not return nil hstore values because string cannot be nil.
-func (scanPlanTextAnyToHstoreCompatScanner) scanString(src string, scanner HstoreCompatScanner) error {
-	hstore, err := parseHstoreCompat(src)
-	if err != nil {
-		return err
-	}
-	return scanner.ScanHstoreCompat(hstore)
+	return nil
 }
 
 func (c HstoreCompatCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
-	return codecDecodeToTextFormat(c, m, oid, format, src)
+	return codecDecodeToTextFormat(c, m, oid, format, src, c.cfg.pool())
 }
 
 func (c HstoreCompatCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
@@ -277,55 +200,10 @@ func (c HstoreCompatCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16,
 	if err != nil {
 		return nil, err
 	}
-	return hstore, nil
+	return decodeHstoreCompatValueAs(c.DecodeValueAs, hstore)
 }
 
-func parseHstoreCompat(s string) (HstoreCompat, error) {
-	p := newHSP(s)
-
-	// This is an over-estimate of the number of key/value pairs. Use '>' because I am guessing it
-	// is less likely to occur in keys/values than '=' or ','.
-	numPairsEstimate := strings.Count(s, ">")
-	result := make(HstoreCompat, numPairsEstimate)
-	// makes one allocation of strings for the entire Hstore, rather than one allocation per value.
-	valueStrings := make([]string, 0, numPairsEstimate)
-	first := true
-	for !p.atEnd() {
-		if !first {
-			err := p.consumePairSeparator()
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			first = false
-		}
-
-		err := p.consumeExpectedByte('"')
-		if err != nil {
-			return nil, err
-		}
-
-		key, err := p.consumeDoubleQuoted()
-		if err != nil {
-			return nil, err
-		}
-
-		err = p.consumeKVSeparator()
-		if err != nil {
-			return nil, err
-		}
-
-		value, err := p.consumeDoubleQuotedOrNull()
-		if err != nil {
-			return nil, err
-		}
-		if value.Valid {
-			valueStrings = append(valueStrings, value.String)
-			result[key] = &valueStrings[len(valueStrings)-1]
-		} else {
-			result[key] = nil
-		}
-	}
-
-	return result, nil
+// parseHstoreCompat parses s, using cfg's allocator and pair estimate. cfg can be nil.
+func parseHstoreCompat(s string, cfg *codecConfig) (HstoreCompat, error) {
+	return hstoreCompatOps.parseText(s, cfg)
 }
@@ -1,12 +1,15 @@
 package pgxtypefaster_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/evanj/pgxtypefaster"
+	"github.com/evanj/pgxtypefaster/pgio"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -226,3 +229,179 @@ func BenchmarkHstoreScan(b *testing.B) {
 		})
 	}
 }
+
+func TestBinaryScanMalformed(t *testing.T) {
+	appendInt32 := func(buf []byte, n int32) []byte {
+		return append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	inputs := map[string][]byte{
+		"empty":             {},
+		"negative count":    appendInt32(nil, -1),
+		"huge count":        appendInt32(nil, 1<<30),
+		"negative key":      appendInt32(appendInt32(appendInt32(nil, 1), -1), -1),
+		"key too long":      appendInt32(appendInt32(appendInt32(nil, 1), 100), -1),
+		"value too long":    append(appendInt32(appendInt32(appendInt32(nil, 1), 1), 100), 'k', 0, 0, 0, 0),
+		"missing value len": append(appendInt32(appendInt32(nil, 1), 1), 'k'),
+	}
+	for _, config := range allHstoreConfigs {
+		if !strings.HasPrefix(config.name, "pgxtypefaster") || !strings.HasSuffix(config.name, "binary") {
+			continue
+		}
+		for name, input := range inputs {
+			err := config.scanPlan.Scan(input, config.newScanType())
+			if err == nil {
+				t.Errorf("%s %s: expected error for %#v", config.name, name, input)
+			}
+			var countErr *pgio.CountError
+			if strings.HasSuffix(name, "count") && !errors.As(err, &countErr) {
+				t.Errorf("%s %s: expected *pgio.CountError; err=%#v", config.name, name, err)
+			}
+		}
+	}
+}
+
+func TestBinaryScanManyPairs(t *testing.T) {
+	// more pairs than the initial capacity allocated from the count
+	const numPairs = 10000
+	input := make(pgxtypefaster.Hstore, numPairs)
+	for i := 0; i < numPairs; i++ {
+		input[fmt.Sprintf("key%d", i)] = pgxtypefaster.NewText(fmt.Sprintf("value%d", i))
+	}
+	m := newTestTypeMap()
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &h); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(h, input) {
+			t.Errorf("format=%d: Hstore does not match", format)
+		}
+		var compat pgxtypefaster.HstoreCompat
+		if err := m.Scan(testHstoreOID, format, buf, &compat); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(compat, fasterToCompat(input)) {
+			t.Errorf("format=%d: HstoreCompat does not match", format)
+		}
+	}
+}
+
+func TestNewHstoreConstructors(t *testing.T) {
+	s := "2"
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2")}
+	if h := pgxtypefaster.NewHstoreFromMap(map[string]string{"a": "1", "b": "2"}); !reflect.DeepEqual(h, expected) {
+		t.Errorf("NewHstoreFromMap=%#v", h)
+	}
+	if h := pgxtypefaster.NewHstoreFromPairs("a", "1", "b", "x", "b", "2"); !reflect.DeepEqual(h, expected) {
+		t.Errorf("NewHstoreFromPairs=%#v", h)
+	}
+	h := pgxtypefaster.NewHstoreFromPointerMap(map[string]*string{"a": &s, "null": nil})
+	if !reflect.DeepEqual(h, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("2"), "null": pgtype.Text{}}) {
+		t.Errorf("NewHstoreFromPointerMap=%#v", h)
+	}
+
+	if pgxtypefaster.NewHstoreFromMap(nil) != nil || pgxtypefaster.NewHstoreFromPointerMap(nil) != nil {
+		t.Error("nil maps must return nil")
+	}
+	if h := pgxtypefaster.NewHstoreFromPairs(); h == nil || len(h) != 0 {
+		t.Errorf("NewHstoreFromPairs()=%#v; expected empty", h)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewHstoreFromPairs with an odd count must panic")
+		}
+	}()
+	pgxtypefaster.NewHstoreFromPairs("a")
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		h    pgxtypefaster.Hstore
+		json string
+	}{
+		{nil, `null`},
+		{pgxtypefaster.Hstore{}, `{}`},
+		{pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("x"), "a": {}, "": pgxtypefaster.NewText("")},
+			`{"":"","a":null,"b":"x"}`},
+	}
+	for _, test := range tests {
+		compat := fasterToCompat(test.h).(pgxtypefaster.HstoreCompat)
+		if test.h == nil {
+			compat = nil
+		}
+		for _, value := range []any{test.h, compat} {
+			out, err := json.Marshal(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != test.json {
+				t.Errorf("json.Marshal(%T %#v)=%s; expected %s", value, value, out, test.json)
+			}
+		}
+
+		var h pgxtypefaster.Hstore
+		if err := json.Unmarshal([]byte(test.json), &h); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(h, test.h) {
+			t.Errorf("json.Unmarshal(%s)=%#v; expected %#v", test.json, h, test.h)
+		}
+		var compatOut pgxtypefaster.HstoreCompat
+		if err := json.Unmarshal([]byte(test.json), &compatOut); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(compatOut, compat) {
+			t.Errorf("json.Unmarshal(%s)=%#v; expected %#v", test.json, compatOut, compat)
+		}
+	}
+
+	// values must be strings or null
+	var compat pgxtypefaster.HstoreCompat
+	if err := json.Unmarshal([]byte(`{"a":1}`), &compat); err == nil {
+		t.Error("expected error for a number value")
+	}
+}
+
+func TestHstoreCompatConversions(t *testing.T) {
+	inputs := []pgxtypefaster.Hstore{
+		nil,
+		{},
+		{"a": pgxtypefaster.NewText("b"), "c": {}, "": pgxtypefaster.NewText("")},
+	}
+	for _, h := range inputs {
+		compat := pgxtypefaster.HstoreToCompat(h)
+		if h == nil {
+			if compat != nil {
+				t.Errorf("HstoreToCompat(nil)=%#v; expected nil", compat)
+			}
+		} else if !reflect.DeepEqual(compat, fasterToCompat(h)) {
+			t.Errorf("HstoreToCompat(%#v)=%#v", h, compat)
+		}
+		out := pgxtypefaster.CompatToHstore(compat)
+		if !reflect.DeepEqual(out, h) {
+			t.Errorf("CompatToHstore(%#v)=%#v; expected %#v", compat, out, h)
+		}
+	}
+
+	// one allocation for the values in addition to the map
+	h := inputs[2]
+	var sink pgxtypefaster.HstoreCompat
+	mapAllocs := testing.AllocsPerRun(100, func() {
+		sink = make(pgxtypefaster.HstoreCompat, len(h))
+		for k := range h {
+			sink[k] = nil
+		}
+	})
+	allocs := testing.AllocsPerRun(100, func() {
+		sink = pgxtypefaster.HstoreToCompat(h)
+	})
+	_ = sink
+	if allocs != mapAllocs+1 {
+		t.Errorf("HstoreToCompat allocs=%f; expected %f", allocs, mapAllocs+1)
+	}
+}
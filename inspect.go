@@ -0,0 +1,68 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RegisteredType describes a type registered on a connection's type map.
+type RegisteredType struct {
+	Name string
+	OID  uint32
+	// Codec is the Go type of the codec, such as "pgxtypefaster.HstoreCodec". For codecs that wrap
+	// another codec with an Unwrap method, such as *ChainedCodec, it is the innermost codec.
+	Codec string
+	// Faster is true if the codec is from this package, and false if it is from pgtype or elsewhere.
+	Faster bool
+	// Wrapped is true if the codec is wrapped, such as with Chain.
+	Wrapped bool
+	// PreferredFormat is the format pgx requests for results of this type.
+	PreferredFormat int16
+}
+
+// inspectedTypeNames are the types this package provides codecs for.
+var inspectedTypeNames = []string{"hstore", "_hstore"}
+
+var packagePath = reflect.TypeOf(HstoreCodec{}).PkgPath()
+
+// InspectTypeMap reports the codecs registered on a type map, such as conn.TypeMap(), for the
+// types this package provides codecs for, and for the types in names, such as types generated by
+// fastertypegen. Types that are not registered are omitted. It can be used to verify that the
+// faster codecs are used, for example from an admin endpoint.
+func InspectTypeMap(m *pgtype.Map, names ...string) []RegisteredType {
+	var result []RegisteredType
+	allNames := append(inspectedTypeNames[:len(inspectedTypeNames):len(inspectedTypeNames)], names...)
+	for _, name := range allNames {
+		t, ok := m.TypeForName(name)
+		if !ok {
+			continue
+		}
+		codec := t.Codec
+		wrapped := false
+		for {
+			unwrapper, ok := codec.(interface{ Unwrap() pgtype.Codec })
+			if !ok {
+				break
+			}
+			codec = unwrapper.Unwrap()
+			wrapped = true
+		}
+
+		codecType := reflect.TypeOf(codec)
+		elemType := codecType
+		if elemType.Kind() == reflect.Pointer {
+			elemType = elemType.Elem()
+		}
+		result = append(result, RegisteredType{
+			Name:            t.Name,
+			OID:             t.OID,
+			Codec:           fmt.Sprintf("%v", codecType),
+			Faster:          elemType.PkgPath() == packagePath,
+			Wrapped:         wrapped,
+			PreferredFormat: t.Codec.PreferredFormat(),
+		})
+	}
+	return result
+}
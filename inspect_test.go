@@ -0,0 +1,37 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestInspectTypeMap(t *testing.T) {
+	m := pgtype.NewMap()
+	if types := pgxtypefaster.InspectTypeMap(m); len(types) != 0 {
+		t.Errorf("no types registered: %#v", types)
+	}
+
+	m.RegisterType(&pgtype.Type{Codec: pgtype.HstoreCodec{}, Name: "hstore", OID: testHstoreOID})
+	m.RegisterType(&pgtype.Type{
+		Codec: pgxtypefaster.Chain(pgxtypefaster.NewHstoreCodec(pgxtypefaster.WithServerVersion(80400))),
+		Name:  "other_hstore",
+		OID:   testHstoreOID + 1,
+	})
+	types := pgxtypefaster.InspectTypeMap(m, "other_hstore", "not_registered")
+	expected := []pgxtypefaster.RegisteredType{
+		{
+			Name: "hstore", OID: testHstoreOID, Codec: "pgtype.HstoreCodec",
+			PreferredFormat: pgtype.BinaryFormatCode,
+		},
+		{
+			Name: "other_hstore", OID: testHstoreOID + 1, Codec: "pgxtypefaster.HstoreCodec",
+			Faster: true, Wrapped: true, PreferredFormat: pgtype.TextFormatCode,
+		},
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("InspectTypeMap=%#v; expected %#v", types, expected)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build go1.23
+
+package pgxtypefaster
+
+import "unique"
+
+// internString returns a canonical copy of s, so equal strings from different scans share memory.
+// It uses the unique package, which frees strings when they are no longer used.
+func internString(s string) string {
+	return unique.Make(s).Value()
+}
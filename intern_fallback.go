@@ -0,0 +1,24 @@
+//go:build !go1.23
+
+package pgxtypefaster
+
+import (
+	"strings"
+	"sync"
+)
+
+// internedStrings contains the canonical copies returned by internString. Without the unique
+// package (Go 1.23), they are never freed, so this should only be used for a bounded set of strings
+// like hstore keys.
+var internedStrings sync.Map
+
+// internString returns a canonical copy of s, so equal strings from different scans share memory.
+func internString(s string) string {
+	if interned, ok := internedStrings.Load(s); ok {
+		return interned.(string)
+	}
+	// s may be a substring of a much larger string: do not retain it
+	s = strings.Clone(s)
+	interned, _ := internedStrings.LoadOrStore(s, s)
+	return interned.(string)
+}
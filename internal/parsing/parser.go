@@ -0,0 +1,256 @@
+// Package parsing contains a parser for Postgres text formats that returns substrings of the input
+// where possible, so parsing a value makes one allocation for the input string instead of one per
+// element. It was extracted from the hstore parser so other text formats can share it.
+package parsing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// snippetContext is the number of bytes of input on each side of the offset in Error.Snippet.
+const snippetContext = 16
+
+// Error is returned for invalid input. It records where parsing failed, to help find the problem in
+// large inputs.
+type Error struct {
+	// Offset is the byte offset in the input where the error was found.
+	Offset int
+	// Expected describes what was expected at Offset, such as `'"'` or "value".
+	Expected string
+	// Found describes the byte at Offset, or "end" if the input ended.
+	Found string
+	// Snippet is the input around Offset: up to 16 bytes before and after it.
+	Snippet string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("parse error at offset %d: expected %s; found %s near %#v",
+		e.Offset, e.Expected, e.Found, e.Snippet)
+}
+
+// QuoteByte describes b for Error.Expected and Error.Found.
+func QuoteByte(b byte) string {
+	return fmt.Sprintf("%q", rune(b))
+}
+
+// Parser consumes a string from the start. Strings returned by the Consume methods are substrings
+// of the input unless they contain escapes.
+type Parser struct {
+	str string
+	pos int
+	// nextBackslash is the offset of the next backslash at or after the position where it was last
+	// searched, or -1 if there are none. Searching once for the whole string makes the common case
+	// of no escapes fast. The byte-level methods can move pos past it, so the methods that use it
+	// call syncNextBackslash first.
+	nextBackslash int
+}
+
+// New returns a Parser for in.
+func New(in string) Parser {
+	return Parser{
+		str:           in,
+		pos:           0,
+		nextBackslash: strings.IndexByte(in, '\\'),
+	}
+}
+
+// Pos returns the offset of the next byte to be consumed.
+func (p *Parser) Pos() int {
+	return p.pos
+}
+
+// Remaining returns the part of the input that has not been consumed.
+func (p *Parser) Remaining() string {
+	return p.str[p.pos:]
+}
+
+// AtEnd returns true if the entire input has been consumed.
+func (p *Parser) AtEnd() bool {
+	return p.pos >= len(p.str)
+}
+
+// Peek returns the next byte without consuming it, or end if the input is done.
+func (p *Parser) Peek() (b byte, end bool) {
+	if p.pos >= len(p.str) {
+		return 0, true
+	}
+	return p.str[p.pos], false
+}
+
+// Consume returns the next byte of the string, or end if the string is done.
+func (p *Parser) Consume() (b byte, end bool) {
+	if p.pos >= len(p.str) {
+		return 0, true
+	}
+	b = p.str[p.pos]
+	p.pos++
+	return b, false
+}
+
+// Skip advances past n bytes, which must have been checked with Peek.
+func (p *Parser) Skip(n int) {
+	p.pos += n
+}
+
+// ErrorAt returns an *Error for offset in the input, where expected was not found.
+func (p *Parser) ErrorAt(offset int, expected string) *Error {
+	found := "end"
+	if offset < len(p.str) {
+		found = QuoteByte(p.str[offset])
+	}
+	start := offset - snippetContext
+	if start < 0 {
+		start = 0
+	}
+	end := offset + snippetContext
+	if end > len(p.str) {
+		end = len(p.str)
+	}
+	return &Error{Offset: offset, Expected: expected, Found: found, Snippet: p.str[start:end]}
+}
+
+// ConsumeExpectedByte consumes expectedB from the string, or returns an error.
+func (p *Parser) ConsumeExpectedByte(expectedB byte) error {
+	if p.pos >= len(p.str) || p.str[p.pos] != expectedB {
+		return p.ErrorAt(p.pos, QuoteByte(expectedB))
+	}
+	p.pos++
+	return nil
+}
+
+// ConsumeExpected2 consumes two expected bytes or returns an error.
+// This was a bit faster than using a string argument (better inlining? Not sure).
+func (p *Parser) ConsumeExpected2(one byte, two byte) error {
+	if p.pos >= len(p.str) || p.str[p.pos] != one {
+		return p.ErrorAt(p.pos, QuoteByte(one))
+	}
+	if p.pos+1 >= len(p.str) || p.str[p.pos+1] != two {
+		return p.ErrorAt(p.pos+1, QuoteByte(two))
+	}
+	p.pos += 2
+	return nil
+}
+
+// ConsumeDoubleQuoted consumes a double-quoted string where the only valid escapes are \\ and \".
+// The opening double quote must have been consumed already.
+func (p *Parser) ConsumeDoubleQuoted() (string, error) {
+	return p.consumeDoubleQuoted(false)
+}
+
+// ConsumeDoubleQuotedAnyEscape consumes a double-quoted string where a backslash escapes any
+// byte, as in the array and composite text formats. The opening double quote must have been
+// consumed already.
+func (p *Parser) ConsumeDoubleQuotedAnyEscape() (string, error) {
+	return p.consumeDoubleQuoted(true)
+}
+
+func (p *Parser) consumeDoubleQuoted(anyEscape bool) (string, error) {
+	p.syncNextBackslash()
+	// fast path: assume most strings do not contain escapes
+	nextDoubleQuote := strings.IndexByte(p.str[p.pos:], '"')
+	if nextDoubleQuote == -1 {
+		return "", p.ErrorAt(len(p.str), `closing '"'`)
+	}
+	nextDoubleQuote += p.pos
+	if p.nextBackslash == -1 || p.nextBackslash > nextDoubleQuote {
+		// no escapes in this string
+		s := p.str[p.pos:nextDoubleQuote]
+		p.pos = nextDoubleQuote + 1
+		return s, nil
+	}
+
+	// slow path: string contains escapes
+	s, err := p.consumeDoubleQuotedWithEscapes(p.nextBackslash, anyEscape)
+	p.updateNextBackslash()
+	return s, err
+}
+
+// consumeDoubleQuotedWithEscapes consumes a double-quoted string containing escapes, starting
+// at p.pos, and with the first backslash at firstBackslash.
+func (p *Parser) consumeDoubleQuotedWithEscapes(firstBackslash int, anyEscape bool) (string, error) {
+	// copy the prefix that does not contain backslashes
+	var builder strings.Builder
+	builder.WriteString(p.str[p.pos:firstBackslash])
+
+	// skip to the backslash
+	p.pos = firstBackslash
+
+	// copy bytes until the end, unescaping backslashes
+	for {
+		nextB, end := p.Consume()
+		if end {
+			return "", p.ErrorAt(p.pos, `closing '"'`)
+		} else if nextB == '"' {
+			break
+		} else if nextB == '\\' {
+			// escape: skip the backslash and copy the char
+			nextB, end = p.Consume()
+			if end {
+				return "", p.ErrorAt(p.pos, `escaped byte and closing '"'`)
+			}
+			if !anyEscape && !(nextB == '\\' || nextB == '"') {
+				return "", p.ErrorAt(p.pos-1, `'\\' or '"' after '\\'`)
+			}
+			builder.WriteByte(nextB)
+		} else {
+			// normal byte: copy it
+			builder.WriteByte(nextB)
+		}
+	}
+	return builder.String(), nil
+}
+
+// ConsumeUnquoted consumes bytes up to the first byte in delimiters or the end of the input, and
+// returns them. A backslash escapes the following byte. It returns an error if the input ends
+// after a backslash.
+func (p *Parser) ConsumeUnquoted(delimiters string) (string, error) {
+	p.syncNextBackslash()
+	end := strings.IndexAny(p.str[p.pos:], delimiters)
+	if end == -1 {
+		end = len(p.str)
+	} else {
+		end += p.pos
+	}
+	if p.nextBackslash == -1 || p.nextBackslash >= end {
+		s := p.str[p.pos:end]
+		p.pos = end
+		return s, nil
+	}
+
+	// slow path: contains escapes, which may also escape delimiters
+	var builder strings.Builder
+	builder.WriteString(p.str[p.pos:p.nextBackslash])
+	p.pos = p.nextBackslash
+	for {
+		nextB, isEnd := p.Peek()
+		if isEnd || strings.IndexByte(delimiters, nextB) != -1 {
+			break
+		}
+		p.pos++
+		if nextB == '\\' {
+			nextB, isEnd = p.Consume()
+			if isEnd {
+				return "", p.ErrorAt(p.pos, `escaped byte after '\\'`)
+			}
+		}
+		builder.WriteByte(nextB)
+	}
+	p.updateNextBackslash()
+	return builder.String(), nil
+}
+
+// syncNextBackslash searches for the next backslash again if pos was moved past it, for example by
+// consuming the backslash with Consume.
+func (p *Parser) syncNextBackslash() {
+	if p.nextBackslash != -1 && p.nextBackslash < p.pos {
+		p.updateNextBackslash()
+	}
+}
+
+func (p *Parser) updateNextBackslash() {
+	p.nextBackslash = strings.IndexByte(p.str[p.pos:], '\\')
+	if p.nextBackslash != -1 {
+		p.nextBackslash += p.pos
+	}
+}
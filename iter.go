@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package pgxtypefaster
+
+import (
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// All returns an iterator over the pairs of h, in map iteration order.
+func (h Hstore) All() iter.Seq2[string, pgtype.Text] {
+	return maps.All(h)
+}
+
+// Sorted returns an iterator over the pairs of h, sorted by key. It sorts the keys when iteration
+// starts, so changes to h during iteration are not reflected in the keys that are visited.
+func (h Hstore) Sorted() iter.Seq2[string, pgtype.Text] {
+	return func(yield func(string, pgtype.Text) bool) {
+		for _, k := range slices.Sorted(maps.Keys(h)) {
+			if !yield(k, h[k]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//go:build go1.23
+
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreIterators(t *testing.T) {
+	h := pgxtypefaster.Hstore{"c": pgxtypefaster.NewText("3"), "a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+
+	all := pgxtypefaster.Hstore{}
+	for k, v := range h.All() {
+		all[k] = v
+	}
+	if !reflect.DeepEqual(all, h) {
+		t.Errorf("All()=%#v; expected %#v", all, h)
+	}
+
+	var keys []string
+	var values []pgtype.Text
+	for k, v := range h.Sorted() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Errorf("Sorted() keys=%#v", keys)
+	}
+	if !reflect.DeepEqual(values, []pgtype.Text{pgxtypefaster.NewText("1"), {}, pgxtypefaster.NewText("3")}) {
+		t.Errorf("Sorted() values=%#v", values)
+	}
+
+	// stops when the loop breaks
+	count := 0
+	for range h.Sorted() {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("break after %d iterations", count)
+	}
+
+	for range pgxtypefaster.Hstore(nil).Sorted() {
+		t.Error("nil Hstore must not yield pairs")
+	}
+}
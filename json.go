@@ -0,0 +1,123 @@
+package pgxtypefaster
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// JSONCodec is a codec for json, like JSONBCodec. It scans into *json.RawMessage, *[]byte, and
+// *string with a single copy, and other types like pgtype.JSONCodec. It can also scan without
+// copying into a JSONDecodeFunc, which reads the value with a json.Decoder, or into an io.Writer,
+// which receives the value as it was sent by the server. JSONBCodec also supports these targets.
+type JSONCodec struct {
+	cfg *codecConfig
+}
+
+// NewJSONCodec returns a JSONCodec configured with opts.
+func NewJSONCodec(opts ...CodecOption) JSONCodec {
+	return JSONCodec{cfg: newCodecConfig(opts)}
+}
+
+func (JSONCodec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func (JSONCodec) PreferredFormat() int16 {
+	return pgtype.TextFormatCode
+}
+
+func (JSONCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if _, ok := value.(json.RawMessage); ok {
+		// the binary format of json is the same as the text format
+		return encodePlanJSONRawMessage{}
+	}
+	return pgtype.JSONCodec{}.PlanEncode(m, oid, format, value)
+}
+
+func (c JSONCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if format != pgtype.TextFormatCode && format != pgtype.BinaryFormatCode {
+		return nil
+	}
+	if plan := planScanJSON(c.cfg, false, target); plan != nil {
+		return plan
+	}
+	return pgtype.JSONCodec{}.PlanScan(m, oid, format, target)
+}
+
+func (JSONCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return pgtype.JSONCodec{}.DecodeDatabaseSQLValue(m, oid, format, src)
+}
+
+func (JSONCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	return pgtype.JSONCodec{}.DecodeValue(m, oid, format, src)
+}
+
+// JSONDecodeFunc is a scan target for json and jsonb that calls the function with a json.Decoder
+// reading the value, for example to decode a large array one element at a time with
+// json.Decoder.More. The decoder reads directly from pgx's read buffer, so it must not be used
+// after the function returns. Scanning NULL returns an error without calling the function. Pass
+// the function itself, not a pointer to it:
+//
+//	err := row.Scan(pgxtypefaster.JSONDecodeFunc(func(d *json.Decoder) error { return d.Decode(&v) }))
+type JSONDecodeFunc func(d *json.Decoder) error
+
+// planScanJSON returns a plan that scans json, or jsonb if binary is true, into target, or nil if
+// the json codecs from pgtype should scan it.
+func planScanJSON(cfg *codecConfig, binary bool, target any) pgtype.ScanPlan {
+	switch target.(type) {
+	case *json.RawMessage, *[]byte, *string:
+		return scanPlanJSONBytes{cfg.allocator(), binary}
+	case JSONDecodeFunc:
+		return scanPlanJSONDecodeFunc{binary}
+	case sql.Scanner, json.Unmarshaler:
+		// scan these like pgtype, even if they are also io.Writers
+		return nil
+	case io.Writer:
+		return scanPlanJSONWriter{binary}
+	}
+	return nil
+}
+
+// scanPlanJSONDecodeFunc scans json or jsonb into a JSONDecodeFunc.
+type scanPlanJSONDecodeFunc struct {
+	binary bool
+}
+
+func (p scanPlanJSONDecodeFunc) Scan(src []byte, dst any) error {
+	src, err := jsonScanSource(src, dst, p.binary)
+	if err != nil {
+		return err
+	}
+	return dst.(JSONDecodeFunc)(json.NewDecoder(bytes.NewReader(src)))
+}
+
+// scanPlanJSONWriter scans json or jsonb by writing it to an io.Writer.
+type scanPlanJSONWriter struct {
+	binary bool
+}
+
+func (p scanPlanJSONWriter) Scan(src []byte, dst any) error {
+	src, err := jsonScanSource(src, dst, p.binary)
+	if err != nil {
+		return err
+	}
+	_, err = dst.(io.Writer).Write(src)
+	return err
+}
+
+// jsonScanSource returns the JSON text of src for targets that cannot represent NULL.
+func jsonScanSource(src []byte, dst any, binary bool) ([]byte, error) {
+	if src == nil {
+		return nil, fmt.Errorf("cannot scan NULL into %T", dst)
+	}
+	if binary {
+		return stripJSONBVersion(src)
+	}
+	return src, nil
+}
@@ -0,0 +1,122 @@
+package pgxtypefaster_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestJSONCodec(t *testing.T) {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.NewJSONCodec(), Name: "json", OID: pgtype.JSONOID})
+	const input = `{"a": "x",  "b": [1, 2]}`
+	for _, format := range formats {
+		encoded, err := m.Encode(pgtype.JSONOID, format, json.RawMessage(input), nil)
+		if err != nil || string(encoded) != input {
+			t.Fatalf("format=%d: Encode=%q, %v", format, encoded, err)
+		}
+
+		var raw json.RawMessage
+		var s string
+		var st jsonbStruct
+		for _, target := range []any{&raw, &s, &st} {
+			if err := m.Scan(pgtype.JSONOID, format, encoded, target); err != nil {
+				t.Fatalf("format=%d: Scan(%T): %s", format, target, err)
+			}
+		}
+		if string(raw) != input || s != input {
+			t.Errorf("format=%d: scanned %q %q", format, raw, s)
+		}
+		if !reflect.DeepEqual(st, jsonbStruct{"x", []int{1, 2}}) {
+			t.Errorf("format=%d: scanned %#v", format, st)
+		}
+	}
+}
+
+func TestJSONDecodeFuncAndWriter(t *testing.T) {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.JSONCodec{}, Name: "json", OID: pgtype.JSONOID})
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.JSONBCodec{}, Name: "jsonb", OID: pgtype.JSONBOID})
+	const input = `[{"a": 1}, {"a": 2}, {"a": 3}]`
+
+	tests := []struct {
+		oid    uint32
+		format int16
+		src    string
+	}{
+		{pgtype.JSONOID, pgtype.TextFormatCode, input},
+		{pgtype.JSONOID, pgtype.BinaryFormatCode, input},
+		{pgtype.JSONBOID, pgtype.TextFormatCode, input},
+		{pgtype.JSONBOID, pgtype.BinaryFormatCode, "\x01" + input},
+	}
+	for _, test := range tests {
+		// decode the array one element at a time
+		var values []int
+		decode := pgxtypefaster.JSONDecodeFunc(func(d *json.Decoder) error {
+			if _, err := d.Token(); err != nil {
+				return err
+			}
+			for d.More() {
+				var element struct{ A int }
+				if err := d.Decode(&element); err != nil {
+					return err
+				}
+				values = append(values, element.A)
+			}
+			return nil
+		})
+		if err := m.Scan(test.oid, test.format, []byte(test.src), decode); err != nil {
+			t.Fatalf("oid=%d format=%d: %s", test.oid, test.format, err)
+		}
+		if !reflect.DeepEqual(values, []int{1, 2, 3}) {
+			t.Errorf("oid=%d format=%d: decoded %#v", test.oid, test.format, values)
+		}
+
+		var buf bytes.Buffer
+		var builder strings.Builder
+		for _, w := range []any{&buf, &builder} {
+			if err := m.Scan(test.oid, test.format, []byte(test.src), w); err != nil {
+				t.Fatalf("oid=%d format=%d: Scan(%T): %s", test.oid, test.format, w, err)
+			}
+		}
+		if buf.String() != input || builder.String() != input {
+			t.Errorf("oid=%d format=%d: wrote %q %q", test.oid, test.format, buf.String(), builder.String())
+		}
+
+		// NULL cannot be decoded or written
+		if err := m.Scan(test.oid, test.format, nil, decode); err == nil {
+			t.Errorf("oid=%d format=%d: scanning NULL into JSONDecodeFunc must fail", test.oid, test.format)
+		}
+		if err := m.Scan(test.oid, test.format, nil, &buf); err == nil {
+			t.Errorf("oid=%d format=%d: scanning NULL into io.Writer must fail", test.oid, test.format)
+		}
+	}
+}
+
+// jsonWriterScanner implements both io.Writer and sql.Scanner.
+type jsonWriterScanner struct {
+	bytes.Buffer
+	scanned any
+}
+
+func (s *jsonWriterScanner) Scan(src any) error {
+	s.scanned = src
+	return nil
+}
+
+func TestJSONCodecScannerWriter(t *testing.T) {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.JSONCodec{}, Name: "json", OID: pgtype.JSONOID})
+	var target jsonWriterScanner
+	if err := m.Scan(pgtype.JSONOID, pgtype.TextFormatCode, []byte(`{}`), &target); err != nil {
+		t.Fatal(err)
+	}
+	if target.scanned != "{}" || target.Len() != 0 {
+		t.Errorf("sql.Scanner must be used before io.Writer: scanned=%#v written=%q", target.scanned, target.String())
+	}
+}
@@ -0,0 +1,142 @@
+package pgxtypefaster
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// JSONBCodec is a codec for jsonb that scans into *json.RawMessage, *[]byte, and *string with a
+// single copy of the value, without the version byte of the binary format. Unlike
+// pgtype.JSONBCodec, scanning a json.RawMessage does not unmarshal the value to validate it, since
+// Postgres only returns valid JSON. Other types are scanned and encoded like pgtype.JSONBCodec,
+// which unmarshals directly from the received bytes. It uses the WithAllocator and WithZeroCopy
+// codec options: with WithZeroCopy and the pgxtypefasterunsafe build tag, the scanned values are
+// views of pgx's read buffer.
+type JSONBCodec struct {
+	cfg *codecConfig
+}
+
+// NewJSONBCodec returns a JSONBCodec configured with opts.
+func NewJSONBCodec(opts ...CodecOption) JSONBCodec {
+	return JSONBCodec{cfg: newCodecConfig(opts)}
+}
+
+func (JSONBCodec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func (JSONBCodec) PreferredFormat() int16 {
+	return pgtype.TextFormatCode
+}
+
+// jsonbVersion is the version byte that starts the binary format.
+const jsonbVersion = 1
+
+func (JSONBCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if _, ok := value.(json.RawMessage); ok {
+		// pgtype marshals json.RawMessage, which validates and compacts it
+		return encodePlanJSONRawMessage{binary: format == pgtype.BinaryFormatCode}
+	}
+	return pgtype.JSONBCodec{}.PlanEncode(m, oid, format, value)
+}
+
+// encodePlanJSONRawMessage encodes a json.RawMessage without checking it. If binary is true, it
+// adds the jsonb version byte.
+type encodePlanJSONRawMessage struct {
+	binary bool
+}
+
+func (p encodePlanJSONRawMessage) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	raw := value.(json.RawMessage)
+	if raw == nil {
+		return nil, nil
+	}
+	if p.binary {
+		buf = append(buf, jsonbVersion)
+	}
+	return append(buf, raw...), nil
+}
+
+func (c JSONBCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if format != pgtype.TextFormatCode && format != pgtype.BinaryFormatCode {
+		return nil
+	}
+	if plan := planScanJSON(c.cfg, format == pgtype.BinaryFormatCode, target); plan != nil {
+		return plan
+	}
+	return pgtype.JSONBCodec{}.PlanScan(m, oid, format, target)
+}
+
+// scanPlanJSONBytes scans json or jsonb into *json.RawMessage, *[]byte, or *string with one copy.
+// If binary is true, src is the jsonb binary format, which starts with the version byte.
+type scanPlanJSONBytes struct {
+	alloc  Allocator
+	binary bool
+}
+
+func (p scanPlanJSONBytes) Scan(src []byte, dst any) error {
+	if src != nil && p.binary {
+		var err error
+		src, err = stripJSONBVersion(src)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch dst := dst.(type) {
+	case *json.RawMessage:
+		*dst = p.bytes(src)
+	case *[]byte:
+		*dst = p.bytes(src)
+	case *string:
+		if src == nil {
+			// the same error as pgtype in the text format
+			return fmt.Errorf("cannot scan NULL into %T", dst)
+		}
+		*dst = allocString(p.alloc, src)
+	default:
+		return fmt.Errorf("cannot scan json into %T", dst)
+	}
+	return nil
+}
+
+// bytes returns a copy of src, or src itself for WithZeroCopy with the pgxtypefasterunsafe build
+// tag. It returns nil for NULL.
+func (p scanPlanJSONBytes) bytes(src []byte) []byte {
+	if src == nil {
+		return nil
+	}
+	if _, ok := p.alloc.(zeroCopyAllocator); ok && UnsafeOptimizations {
+		return src[:len(src):len(src)]
+	}
+	var out []byte
+	if p.alloc == nil {
+		out = make([]byte, len(src))
+	} else {
+		out = p.alloc.AllocBytes(len(src))
+	}
+	copy(out, src)
+	return out
+}
+
+// stripJSONBVersion returns the JSON text of src in the jsonb binary format.
+func stripJSONBVersion(src []byte) ([]byte, error) {
+	if len(src) == 0 {
+		return nil, fmt.Errorf("jsonb too short")
+	}
+	if src[0] != jsonbVersion {
+		return nil, fmt.Errorf("unknown jsonb version number %d", src[0])
+	}
+	return src[1:], nil
+}
+
+func (JSONBCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return pgtype.JSONBCodec{}.DecodeDatabaseSQLValue(m, oid, format, src)
+}
+
+func (JSONBCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	return pgtype.JSONBCodec{}.DecodeValue(m, oid, format, src)
+}
@@ -0,0 +1,149 @@
+package pgxtypefaster_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func newJSONBTypeMap(opts ...pgxtypefaster.CodecOption) *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.NewJSONBCodec(opts...), Name: "jsonb", OID: pgtype.JSONBOID})
+	return m
+}
+
+type jsonbStruct struct {
+	A string `json:"a"`
+	B []int  `json:"b"`
+}
+
+func TestJSONBCodec(t *testing.T) {
+	m := newJSONBTypeMap()
+	const input = `{"a": "x", "b": [1, 2]}`
+	for _, format := range formats {
+		encoded, err := m.Encode(pgtype.JSONBOID, format, json.RawMessage(input), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == pgtype.BinaryFormatCode && (len(encoded) == 0 || encoded[0] != 1) {
+			t.Errorf("binary encoding must start with the version byte: %q", encoded)
+		}
+		// encoding the raw message does not compact it
+		if string(encoded[len(encoded)-len(input):]) != input {
+			t.Errorf("format=%d: encoded %q", format, encoded)
+		}
+
+		var raw json.RawMessage
+		var b []byte
+		var s string
+		var st jsonbStruct
+		for _, target := range []any{&raw, &b, &s, &st} {
+			if err := m.Scan(pgtype.JSONBOID, format, encoded, target); err != nil {
+				t.Fatalf("format=%d: Scan(%T): %s", format, target, err)
+			}
+		}
+		if string(raw) != input || string(b) != input || s != input {
+			t.Errorf("format=%d: scanned %q %q %q", format, raw, b, s)
+		}
+		if !reflect.DeepEqual(st, jsonbStruct{"x", []int{1, 2}}) {
+			t.Errorf("format=%d: scanned %#v", format, st)
+		}
+
+		// the scanned value is a copy
+		encoded[len(encoded)-2] = 'X'
+		if string(raw) != input {
+			t.Errorf("format=%d: scanned value changed with the source: %q", format, raw)
+		}
+
+		// NULL
+		for _, target := range []any{&raw, &b} {
+			if err := m.Scan(pgtype.JSONBOID, format, nil, target); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if raw != nil || b != nil {
+			t.Errorf("format=%d: scanned NULL as %#v %#v", format, raw, b)
+		}
+		s = "unchanged"
+		if err := m.Scan(pgtype.JSONBOID, format, nil, &s); err == nil || s != "unchanged" {
+			t.Errorf("format=%d: scanning NULL into *string must fail: %v %#v", format, err, s)
+		}
+		encoded, err = m.Encode(pgtype.JSONBOID, format, json.RawMessage(nil), nil)
+		if err != nil || encoded != nil {
+			t.Errorf("format=%d: Encode(nil)=%q, %v", format, encoded, err)
+		}
+	}
+
+	var raw json.RawMessage
+	for _, src := range []string{"", "\x02{}"} {
+		if err := m.Scan(pgtype.JSONBOID, pgtype.BinaryFormatCode, []byte(src), &raw); err == nil {
+			t.Errorf("Scan(%q): expected error", src)
+		}
+	}
+
+	// other values use pgtype.JSONBCodec
+	encoded, err := m.Encode(pgtype.JSONBOID, pgtype.TextFormatCode, jsonbStruct{"y", nil}, nil)
+	if err != nil || string(encoded) != `{"a":"y","b":null}` {
+		t.Errorf("Encode(struct)=%q, %v", encoded, err)
+	}
+	decoded, err := pgxtypefaster.JSONBCodec{}.DecodeValue(m, pgtype.JSONBOID, pgtype.TextFormatCode, []byte(`{"a": 1}`))
+	if err != nil || !reflect.DeepEqual(decoded, map[string]any{"a": 1.0}) {
+		t.Errorf("DecodeValue=%#v, %v", decoded, err)
+	}
+}
+
+func TestJSONBCodecAllocs(t *testing.T) {
+	m := newJSONBTypeMap()
+	src := []byte("\x01" + `{"key": "value", "nested": {"array": [1, 2, 3]}}`)
+	var raw json.RawMessage
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := m.Scan(pgtype.JSONBOID, pgtype.BinaryFormatCode, src, &raw); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("scanning json.RawMessage allocated %.1f times; expected 1", allocs)
+	}
+
+	// with WithZeroCopy, it only copies without the unsafe build tag
+	m = newJSONBTypeMap(pgxtypefaster.WithZeroCopy())
+	expected := 1.0
+	if pgxtypefaster.UnsafeOptimizations {
+		expected = 0
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		if err := m.Scan(pgtype.JSONBOID, pgtype.BinaryFormatCode, src, &raw); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != expected {
+		t.Errorf("WithZeroCopy: scanning json.RawMessage allocated %.1f times; expected %.0f", allocs, expected)
+	}
+}
+
+func BenchmarkJSONBScanRawMessage(b *testing.B) {
+	src := []byte("\x01" + `{"key": "value", "nested": {"array": [1, 2, 3], "string": "some longer text value"}}`)
+	codecs := []struct {
+		name  string
+		codec pgtype.Codec
+	}{
+		{"pgtype", pgtype.JSONBCodec{}},
+		{"pgxtypefaster", pgxtypefaster.JSONBCodec{}},
+	}
+	for _, codec := range codecs {
+		b.Run(codec.name, func(b *testing.B) {
+			m := pgtype.NewMap()
+			m.RegisterType(&pgtype.Type{Codec: codec.codec, Name: "jsonb", OID: pgtype.JSONBOID})
+			var raw json.RawMessage
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := m.Scan(pgtype.JSONBOID, pgtype.BinaryFormatCode, src, &raw); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package pgxtypefaster
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// anyFromText returns a string, or nil for NULL values, like encoding/json decodes a JSON object.
+func anyFromText(key string, value pgtype.Text) (any, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	return value.String, nil
+}
+
+// planScanJSON returns a plan that scans into *json.RawMessage.
+func (c HstoreCodec) planScanJSON(m *pgtype.Map, oid uint32, format int16) pgtype.ScanPlan {
+	if format != pgtype.BinaryFormatCode && format != pgtype.TextFormatCode {
+		return nil
+	}
+	p := &scanPlanHstoreToJSON{format: format}
+	if c.cfg.scansWithHstorePlan() {
+		p.next = c.PlanScan(m, oid, format, (*Hstore)(nil))
+	}
+	return p
+}
+
+// scanPlanHstoreToJSON scans into *json.RawMessage, formatted like the Postgres hstore_to_json
+// function: values are strings, and NULL values are null. If next is nil, it writes the pairs
+// directly in the order they are stored. Otherwise, it scans an Hstore with next, which applies the
+// codec options, then writes it sorted by key. A NULL hstore scans as a nil json.RawMessage, which
+// encoding/json marshals as null.
+type scanPlanHstoreToJSON struct {
+	format int16
+	next   pgtype.ScanPlan
+}
+
+func (p *scanPlanHstoreToJSON) Scan(src []byte, dst any) error {
+	target := dst.(*json.RawMessage)
+	if src == nil {
+		*target = nil
+		return nil
+	}
+
+	// allocate a new slice: callers may retain the previous value, as with pgx's json codec
+	out := make([]byte, 0, len(src)+2)
+	out = append(out, '{')
+	add := func(key string, value pgtype.Text) error {
+		out = appendJSONPair(out, key, value)
+		return nil
+	}
+
+	if p.next != nil {
+		var h Hstore
+		if err := p.next.Scan(src, &h); err != nil {
+			return err
+		}
+		for _, k := range h.SortedKeys() {
+			out = appendJSONPair(out, k, h[k])
+		}
+	} else {
+		if err := forEachPair(p.format, src, add); err != nil {
+			return err
+		}
+	}
+	*target = append(out, '}')
+	return nil
+}
+
+// appendJSONPair appends key and value as a member of a JSON object, with the separator Postgres
+// uses if it is not the first member.
+func appendJSONPair(buf []byte, key string, value pgtype.Text) []byte {
+	if buf[len(buf)-1] != '{' {
+		buf = append(buf, ", "...)
+	}
+	buf = appendJSONString(buf, key)
+	buf = append(buf, ": "...)
+	if !value.Valid {
+		return append(buf, "null"...)
+	}
+	return appendJSONString(buf, value.String)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string. Invalid UTF-8 is replaced with U+FFFD, like
+// encoding/json.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf = append(buf, s[start:i]...)
+				buf = append(buf, `�`...)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if b >= 0x20 && b != '"' && b != '\\' {
+			i++
+			continue
+		}
+
+		buf = append(buf, s[start:i]...)
+		switch b {
+		case '"', '\\':
+			buf = append(buf, '\\', b)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xf])
+		}
+		i++
+		start = i
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
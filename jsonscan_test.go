@@ -0,0 +1,98 @@
+package pgxtypefaster_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestScanJSON(t *testing.T) {
+	input := pgxtypefaster.Hstore{
+		"a":          pgxtypefaster.NewText("1"),
+		"null":       pgtype.Text{},
+		"escape\"\\": pgxtypefaster.NewText("line\nbreak\ttab\x01  é"),
+		"invalid":    pgxtypefaster.NewText("\xff"),
+	}
+	expected := map[string]any{
+		"a":          "1",
+		"null":       nil,
+		"escape\"\\": "line\nbreak\ttab\x01  é",
+		"invalid":    "�",
+	}
+	// map[string]any is not JSON, so invalid UTF-8 is not replaced
+	expectedMap := map[string]any{}
+	for k, v := range expected {
+		expectedMap[k] = v
+	}
+	expectedMap["invalid"] = "\xff"
+
+	for _, opts := range [][]pgxtypefaster.CodecOption{nil, {pgxtypefaster.WithMaxPairs(10)}} {
+		m := newOptionsTypeMap(opts...)
+		for _, format := range formats {
+			buf, err := m.Encode(testHstoreOID, format, input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var raw json.RawMessage
+			if err := m.Scan(testHstoreOID, format, buf, &raw); err != nil {
+				t.Fatal(err)
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("format=%d: invalid JSON %#v: %s", format, string(raw), err)
+			}
+			if !reflect.DeepEqual(decoded, expected) {
+				t.Errorf("format=%d: JSON %#v decoded to %#v; expected %#v", format, string(raw), decoded, expected)
+			}
+
+			var out map[string]any
+			if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, expectedMap) {
+				t.Errorf("format=%d: map[string]any scanned %#v; expected %#v", format, out, expectedMap)
+			}
+
+			if err := m.Scan(testHstoreOID, format, nil, &raw); err != nil || raw != nil {
+				t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, raw, err)
+			}
+			if err := m.Scan(testHstoreOID, format, nil, &out); err != nil || out != nil {
+				t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, out, err)
+			}
+		}
+	}
+
+	// formatted like Postgres's hstore_to_json
+	m := newOptionsTypeMap(pgxtypefaster.WithMaxPairs(2))
+	ordered := pgxtypefaster.Hstore{"b": pgtype.Text{}, "a": pgxtypefaster.NewText("1")}
+	buf, err := m.Encode(testHstoreOID, pgtype.TextFormatCode, ordered, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw json.RawMessage
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, buf, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `{"a": "1", "b": null}` {
+		t.Errorf("JSON=%#v", string(raw))
+	}
+	// empty
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte{}, &raw); err != nil || string(raw) != `{}` {
+		t.Errorf("empty JSON=%#v %v", string(raw), err)
+	}
+
+	// the codec options are applied
+	buf, err = newTestTypeMap().Encode(testHstoreOID, pgtype.BinaryFormatCode, input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limitErr *pgxtypefaster.LimitError
+	if err := m.Scan(testHstoreOID, pgtype.BinaryFormatCode, buf, &raw); !errors.As(err, &limitErr) {
+		t.Errorf("scanning JSON must apply WithMaxPairs: %v", err)
+	}
+}
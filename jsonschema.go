@@ -0,0 +1,54 @@
+package pgxtypefaster
+
+import (
+	"reflect"
+)
+
+// JSONSchema returns a JSON Schema object describing the hstore keys of struct v, which must be a
+// struct or a pointer to a struct. The struct is mapped using `hstore:"name,omitempty"` struct
+// tags. Each key is a property with a type derived from the Go field type. Keys without omitempty
+// are required. Pointer and pgtype.Text fields are nullable. The result can be marshaled with
+// encoding/json, or used as an OpenAPI 3.1 component schema.
+func JSONSchema(v any) (map[string]any, error) {
+	t, err := structType(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := cachedHstoreFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := make(map[string]any, len(fields))
+	required := []string{}
+	for _, field := range fields {
+		var schemaType any = jsonSchemaType(field.kind)
+		if field.nullable {
+			schemaType = []string{jsonSchemaType(field.kind), "null"}
+		}
+		properties[field.key] = map[string]any{"type": schemaType}
+		if !field.omitEmpty {
+			required = append(required, field.key)
+		}
+	}
+
+	return map[string]any{
+		"title":      t.Name(),
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, nil
+}
+
+func jsonSchemaType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "string"
+}
@@ -0,0 +1,53 @@
+package pgxtypefaster_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type schemaEmbedded struct {
+	Region string `hstore:"region"`
+}
+
+type schemaExample struct {
+	schemaEmbedded
+	Name     string      `hstore:"name"`
+	Count    int         `hstore:"count,omitempty"`
+	Ratio    *float64    `hstore:"ratio"`
+	Enabled  bool        `hstore:"enabled"`
+	Comment  pgtype.Text `hstore:"comment,omitempty"`
+	Ignored  string      `hstore:"-"`
+	Untagged string
+	private  string
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := pgxtypefaster.JSONSchema(&schemaExample{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `{"properties":{"Untagged":{"type":"string"},"comment":{"type":["string","null"]},"count":{"type":"integer"},"enabled":{"type":"boolean"},"name":{"type":"string"},"ratio":{"type":["number","null"]},"region":{"type":"string"}},"required":["region","name","ratio","enabled","Untagged"],"title":"schemaExample","type":"object"}`
+	if string(out) != expected {
+		t.Errorf("JSONSchema=%s\n  expected=%s", string(out), expected)
+	}
+
+	type duplicate struct {
+		A string `hstore:"a"`
+		B string `hstore:"a"`
+	}
+	_, err = pgxtypefaster.JSONSchema(duplicate{})
+	if err == nil {
+		t.Error("expected error for duplicate keys")
+	}
+	_, err = pgxtypefaster.JSONSchema("not a struct")
+	if err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
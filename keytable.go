@@ -0,0 +1,77 @@
+package pgxtypefaster
+
+import (
+	"strings"
+	"sync"
+)
+
+// Interner returns canonical copies of strings, so equal strings share memory. Intern must be
+// safe to call concurrently. It may return s if it does not have a copy.
+type Interner interface {
+	Intern(s string) string
+}
+
+// WithInterner uses interner for the keys of scanned hstores, so equal keys from different rows
+// share memory. Use a KeyTable to deduplicate a bounded set of keys without sharing them with the
+// rest of the process, unlike WithInternKeys.
+func WithInterner(interner Interner) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.interner = interner
+	}
+}
+
+// processInterner interns strings for the entire process. It is used by WithInternKeys.
+type processInterner struct{}
+
+func (processInterner) Intern(s string) string {
+	return internString(s)
+}
+
+// KeyTable is an Interner that keeps up to a fixed number of keys. It is intended for hstores with
+// a small set of keys that are repeated in every row: caching many scanned values then only
+// stores one copy of each key. Once the table is full, other strings are returned unchanged.
+// Keys are never removed. A KeyTable is safe to use concurrently.
+type KeyTable struct {
+	maxKeys int
+
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+var _ Interner = (*KeyTable)(nil)
+
+// NewKeyTable returns a KeyTable that keeps up to maxKeys keys.
+func NewKeyTable(maxKeys int) *KeyTable {
+	return &KeyTable{maxKeys: maxKeys, keys: make(map[string]string)}
+}
+
+// Intern returns the canonical copy of s. If s is not in the table and the table is full, it
+// returns s.
+func (t *KeyTable) Intern(s string) string {
+	t.mu.RLock()
+	interned, ok := t.keys[s]
+	t.mu.RUnlock()
+	if ok {
+		return interned
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if interned, ok := t.keys[s]; ok {
+		return interned
+	}
+	if len(t.keys) >= t.maxKeys {
+		return s
+	}
+	// s may be a substring of a much larger string: do not retain it
+	interned = strings.Clone(s)
+	t.keys[interned] = interned
+	return interned
+}
+
+// Len returns the number of keys in the table.
+func (t *KeyTable) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.keys)
+}
@@ -0,0 +1,66 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestKeyTable(t *testing.T) {
+	table := pgxtypefaster.NewKeyTable(2)
+	a := table.Intern(strings.Repeat("a", 2))
+	if a != "aa" || unsafe.StringData(table.Intern(strings.Repeat("a", 2))) != unsafe.StringData(a) {
+		t.Error("Intern must return the same copy for equal strings")
+	}
+	table.Intern("b")
+	c := strings.Repeat("c", 2)
+	if unsafe.StringData(table.Intern(c)) != unsafe.StringData(c) {
+		t.Error("Intern must return the input when the table is full")
+	}
+	if table.Len() != 2 {
+		t.Errorf("Len()=%d; expected 2", table.Len())
+	}
+}
+
+func TestWithInterner(t *testing.T) {
+	table := pgxtypefaster.NewKeyTable(10)
+	m := newOptionsTypeMap(pgxtypefaster.WithInterner(table))
+	h := pgxtypefaster.Hstore{"interned_key": pgxtypefaster.NewText("v"), "other": {}}
+	interned := table.Intern("interned_key")
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var output pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &output); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(output, h) {
+			t.Fatalf("format=%d: output=%#v; expected %#v", format, output, h)
+		}
+		var compat pgxtypefaster.HstoreCompat
+		if err := m.Scan(testHstoreOID+1, format, buf, &compat); err != nil {
+			t.Fatal(err)
+		}
+
+		for k := range output {
+			if k == "interned_key" && unsafe.StringData(k) != unsafe.StringData(interned) {
+				t.Errorf("format=%d: Hstore key was not interned", format)
+			}
+		}
+		for k := range compat {
+			if k == "interned_key" && unsafe.StringData(k) != unsafe.StringData(interned) {
+				t.Errorf("format=%d: HstoreCompat key was not interned", format)
+			}
+		}
+	}
+	if table.Len() != 2 {
+		t.Errorf("Len()=%d; expected 2", table.Len())
+	}
+}
@@ -0,0 +1,156 @@
+package pgxtypefaster
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errLazyFound stops parsing the text format when LazyHstore.Get finds the key.
+var errLazyFound = errors.New("found")
+
+// LazyHstore is a scan target for HstoreCodec that copies the raw value when scanned, and only
+// parses it when it is accessed. This is faster for queries where most hstores are never used.
+// Get parses until it finds the key without building a map, and ToHstore parses the entire value
+// once and caches the result. Codec options that check or change the pairs, such as WithNullPolicy
+// or WithValidation, are not applied. The zero value is NULL.
+type LazyHstore struct {
+	// the raw value in the text or binary format. Both are empty for NULL.
+	text   string
+	binary []byte
+	valid  bool
+	parsed Hstore
+}
+
+// Valid returns false if the hstore is NULL.
+func (h *LazyHstore) Valid() bool {
+	return h.valid
+}
+
+// Get returns the value of key and true if it exists. It returns an error if the hstore is
+// malformed. It parses the raw value on each call, until ToHstore is called. It stops at the first
+// occurrence of key, so if the value has duplicate keys, which Postgres never returns, it returns
+// the first value while ToHstore keeps the last.
+func (h *LazyHstore) Get(key string) (pgtype.Text, bool, error) {
+	if h.parsed != nil || !h.valid {
+		v, ok := h.parsed[key]
+		return v, ok, nil
+	}
+	if h.binary != nil {
+		return getBinary(h.binary, key)
+	}
+
+	var found pgtype.Text
+	var ok bool
+	err := parsePairsTextFunc(h.text, func(k string, value pgtype.Text) error {
+		if k == key {
+			found = value
+			ok = true
+			return errLazyFound
+		}
+		return nil
+	})
+	if err != nil && err != errLazyFound {
+		return pgtype.Text{}, false, err
+	}
+	return found, ok, nil
+}
+
+// getBinary returns the value of key in src in the binary format.
+func getBinary(src []byte, key string) (pgtype.Text, bool, error) {
+	var r pgio.Reader
+	r.Reset(src)
+	pairCount := r.ReadCount(minBinaryPairLen)
+	for i := 0; i < pairCount; i++ {
+		keyStart, keyLen := r.ReadLengthPrefixedRange()
+		valueStart, valueLen := r.ReadLengthPrefixedRange()
+		if r.Err() != nil {
+			break
+		}
+		if keyLen < 0 {
+			return pgtype.Text{}, false, errBinaryNullKey
+		}
+		// the conversion in the comparison does not allocate
+		if string(src[keyStart:keyStart+keyLen]) != key {
+			continue
+		}
+		if valueLen < 0 {
+			return pgtype.Text{}, true, nil
+		}
+		return NewText(string(src[valueStart : valueStart+valueLen])), true, nil
+	}
+	if r.Err() != nil {
+		return pgtype.Text{}, false, fmt.Errorf("hstore incomplete: %w", r.Err())
+	}
+	return pgtype.Text{}, false, nil
+}
+
+// ToHstore parses the entire value and returns it. The result is cached, so it is only parsed once
+// and later calls to Get use it. A NULL value returns nil.
+func (h *LazyHstore) ToHstore() (Hstore, error) {
+	if h.parsed != nil || !h.valid {
+		return h.parsed, nil
+	}
+	var parsed Hstore
+	var err error
+	if h.binary != nil {
+		err = scanPlanBinaryHstoreToHstoreScanner{}.Scan(h.binary, &parsed)
+	} else {
+		parsed, err = parseHstore(h.text, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h.parsed = parsed
+	h.text = ""
+	h.binary = nil
+	return parsed, nil
+}
+
+// HstoreValue implements HstoreValuer, so a LazyHstore can be used as a query argument.
+func (h *LazyHstore) HstoreValue() (Hstore, error) {
+	return h.ToHstore()
+}
+
+// Scan implements the database/sql Scanner interface. It accepts the text format as a string or
+// a []byte.
+func (h *LazyHstore) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*h = LazyHstore{}
+		return nil
+	case string:
+		*h = LazyHstore{text: src, valid: true}
+		return nil
+	case []byte:
+		*h = LazyHstore{text: string(src), valid: true}
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T", src)
+}
+
+// scanPlanHstoreToLazy copies the raw value into *LazyHstore.
+type scanPlanHstoreToLazy struct {
+	cfg    *codecConfig
+	format int16
+}
+
+func (p scanPlanHstoreToLazy) Scan(src []byte, dst any) error {
+	lazy := dst.(*LazyHstore)
+	if src == nil {
+		*lazy = LazyHstore{}
+		return nil
+	}
+	if err := p.cfg.checkScanLimits(p.format, src); err != nil {
+		return err
+	}
+	if p.format == pgtype.BinaryFormatCode {
+		// copy: pgx reuses the buffer for the next row
+		*lazy = LazyHstore{binary: append([]byte(nil), src...), valid: true}
+	} else {
+		*lazy = LazyHstore{text: string(src), valid: true}
+	}
+	return nil
+}
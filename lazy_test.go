@@ -0,0 +1,83 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestLazyHstore(t *testing.T) {
+	m := newOptionsTypeMap()
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText(`x"\`), "null": pgtype.Text{}}
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var lazy pgxtypefaster.LazyHstore
+		if err := m.Scan(testHstoreOID, format, buf, &lazy); err != nil {
+			t.Fatal(err)
+		}
+		// the scanned value must not use the buffer
+		for i := range buf {
+			buf[i] = 0
+		}
+		if !lazy.Valid() {
+			t.Errorf("format=%d: must be valid", format)
+		}
+
+		// Get before and after ToHstore
+		for i := 0; i < 2; i++ {
+			v, ok, err := lazy.Get("a")
+			if err != nil || !ok || v != input["a"] {
+				t.Errorf("format=%d: Get(a)=%#v, %t, %v", format, v, ok, err)
+			}
+			v, ok, err = lazy.Get("null")
+			if err != nil || !ok || v.Valid {
+				t.Errorf("format=%d: Get(null)=%#v, %t, %v", format, v, ok, err)
+			}
+			if _, ok, err := lazy.Get("missing"); err != nil || ok {
+				t.Errorf("format=%d: Get(missing)=%t, %v", format, ok, err)
+			}
+
+			h, err := lazy.ToHstore()
+			if err != nil || !reflect.DeepEqual(h, input) {
+				t.Errorf("format=%d: ToHstore()=%#v, %v", format, h, err)
+			}
+		}
+
+		// can be encoded
+		encoded, err := m.Encode(testHstoreOID, format, &lazy, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, encoded, &h); err != nil || !reflect.DeepEqual(h, input) {
+			t.Errorf("format=%d: encoded LazyHstore scanned %#v, %v", format, h, err)
+		}
+
+		if err := m.Scan(testHstoreOID, format, nil, &lazy); err != nil || lazy.Valid() {
+			t.Errorf("format=%d: NULL must not be valid: %v", format, err)
+		}
+		if h, err := lazy.ToHstore(); h != nil || err != nil {
+			t.Errorf("format=%d: NULL ToHstore()=%#v, %v", format, h, err)
+		}
+	}
+
+	// malformed values are only detected when accessed
+	var lazy pgxtypefaster.LazyHstore
+	if err := m.Scan(testHstoreOID, pgtype.BinaryFormatCode, []byte{0, 0, 0, 1}, &lazy); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := lazy.Get("a"); err == nil {
+		t.Error("Get expected error for malformed binary")
+	}
+	if err := lazy.Scan(`"a"=>`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lazy.ToHstore(); err == nil {
+		t.Error("ToHstore expected error for malformed text")
+	}
+}
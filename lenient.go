@@ -0,0 +1,135 @@
+package pgxtypefaster
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WithLenientParsing parses the text format with the Postgres hstore input grammar, which accepts
+// literals written by people and other tools, instead of only the format Postgres outputs. See
+// ParseHstoreLenient for the differences. It is slower, and has no effect on the binary format.
+func WithLenientParsing() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.lenientParsing = true
+	}
+}
+
+func (cfg *codecConfig) parsesLeniently() bool {
+	return cfg != nil && cfg.lenientParsing
+}
+
+// ParseHstoreLenient parses s with the Postgres hstore input grammar. Unlike ParseHstore, keys and
+// values can be unquoted, whitespace is allowed around "=>" and ",", a trailing comma is ignored,
+// an unquoted NULL is case-insensitive, and a backslash escapes any byte. An unquoted key ends at
+// whitespace or "=", and an unquoted value ends at whitespace or ",". If a key occurs more than
+// once, the last value is kept, like ParseHstore.
+func ParseHstoreLenient(s string) (Hstore, error) {
+	return parseHstoreLenient(s, nil)
+}
+
+// parseHstoreLenient parses s with the input grammar, using cfg's allocator and options. cfg can
+// be nil.
+func parseHstoreLenient(s string, cfg *codecConfig) (Hstore, error) {
+	return hstoreOps.parseLenient(s, cfg)
+}
+
+// postgresSpace contains the bytes that Postgres treats as whitespace (scanner_isspace).
+const postgresSpace = " \t\n\r\f\v"
+
+// parsePairsLenientFunc parses s with the input grammar, calling fn with each pair in order. It
+// applies cfg's duplicate and string options. cfg can be nil.
+func parsePairsLenientFunc(s string, cfg *codecConfig, fn func(key string, value pgtype.Text) error) error {
+	p := newHSP(s)
+	duplicate := cfg.duplicateHandler()
+	var seen map[string]struct{}
+	if duplicate != nil {
+		seen = map[string]struct{}{}
+	}
+	for {
+		p.skipSpace()
+		if p.AtEnd() {
+			return nil
+		}
+
+		pairOffset := p.Pos()
+		key, _, err := p.consumeLenientString("="+postgresSpace, "key")
+		if err != nil {
+			return err
+		}
+		if seen != nil {
+			if _, exists := seen[key]; exists {
+				if err := duplicate(key, pairOffset); err != nil {
+					return err
+				}
+			}
+			seen[key] = struct{}{}
+		}
+
+		p.skipSpace()
+		if err := p.consumeKVSeparator(); err != nil {
+			return err
+		}
+		p.skipSpace()
+
+		s, quoted, err := p.consumeLenientString(","+postgresSpace, "value")
+		if err != nil {
+			return err
+		}
+		value := NewText(s)
+		// like hstore_in, NULL must be unquoted, but can contain escapes
+		if !quoted && strings.EqualFold(s, "NULL") {
+			value = pgtype.Text{}
+		}
+		if cfg.separatesStrings() {
+			key = strings.Clone(key)
+			value.String = strings.Clone(value.String)
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		if p.AtEnd() {
+			return nil
+		}
+		if err := p.ConsumeExpectedByte(','); err != nil {
+			return err
+		}
+	}
+}
+
+// skipSpace consumes whitespace.
+func (p *hstoreParser) skipSpace() {
+	for {
+		b, end := p.Peek()
+		if end || strings.IndexByte(postgresSpace, b) < 0 {
+			return
+		}
+		p.Skip(1)
+	}
+}
+
+// consumeLenientString consumes a quoted string, or an unquoted string that ends at a byte in
+// delimiters. It returns an error for an empty unquoted string, describing what was expected.
+func (p *hstoreParser) consumeLenientString(delimiters string, expected string) (s string, quoted bool, err error) {
+	next, end := p.Peek()
+	if end {
+		return "", false, p.ErrorAt(p.Pos(), expected)
+	}
+	if next == '"' {
+		p.Skip(1)
+		s, err := p.ConsumeDoubleQuotedAnyEscape()
+		return s, true, err
+	}
+
+	start := p.Pos()
+	s, err = p.ConsumeUnquoted(delimiters)
+	if err != nil {
+		return "", false, err
+	}
+	if p.Pos() == start {
+		return "", false, p.ErrorAt(start, expected)
+	}
+	return s, false, nil
+}
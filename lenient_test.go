@@ -0,0 +1,105 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseHstoreLenient(t *testing.T) {
+	text := pgxtypefaster.NewText
+	tests := []struct {
+		input    string
+		expected pgxtypefaster.Hstore
+	}{
+		{``, pgxtypefaster.Hstore{}},
+		{" \t\n", pgxtypefaster.Hstore{}},
+		{`a=>b`, pgxtypefaster.Hstore{"a": text("b")}},
+		{`"a"=>"1", "b"=>NULL`, pgxtypefaster.Hstore{"a": text("1"), "b": {}}},
+		{"  a  =>  b ,c=>\"d e\"\n", pgxtypefaster.Hstore{"a": text("b"), "c": text("d e")}},
+		// like Postgres, an unquoted NULL is compared after removing escapes
+		{`a=>NULL, b=>null, c=>"NULL", d=>nUlL, e=>\NULL, f=>NULLS, g=>N\ULL, h=>NUL\L\L`, pgxtypefaster.Hstore{
+			"a": {}, "b": {}, "c": text("NULL"), "d": {}, "e": {}, "f": text("NULLS"), "g": {}, "h": text("NULLL"),
+		}},
+		{`a=>b,`, pgxtypefaster.Hstore{"a": text("b")}},
+		{`a\=b=>c\,d`, pgxtypefaster.Hstore{"a=b": text("c,d")}},
+		{`"a\x"=>"\y"`, pgxtypefaster.Hstore{"ax": text("y")}},
+		{`key=>val=ue`, pgxtypefaster.Hstore{"key": text("val=ue")}},
+		{`a=>1, a=>2`, pgxtypefaster.Hstore{"a": text("2")}},
+	}
+	for _, test := range tests {
+		h, err := pgxtypefaster.ParseHstoreLenient(test.input)
+		if err != nil {
+			t.Errorf("ParseHstoreLenient(%#v): unexpected error: %s", test.input, err)
+		} else if !reflect.DeepEqual(h, test.expected) {
+			t.Errorf("ParseHstoreLenient(%#v)=%#v; expected %#v", test.input, h, test.expected)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		offset   int
+		expected string
+	}{
+		{`a=>`, 3, "value"},
+		{`=>b`, 0, "key"},
+		{`a=b`, 2, `'>'`},
+		{`a = >b`, 3, `'>'`},
+		{`a=>b c=>d`, 5, `','`},
+		{`a=>b,,`, 6, `'='`},
+		{`"a=>b`, 5, `closing '"'`},
+		{`a=>b\`, 5, `escaped byte after '\\'`},
+	}
+	for _, test := range errorTests {
+		_, err := pgxtypefaster.ParseHstoreLenient(test.input)
+		var parseErr *pgxtypefaster.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Offset != test.offset || parseErr.Expected != test.expected {
+			t.Errorf("ParseHstoreLenient(%#v): err=%#v; expected offset=%d expected=%#v",
+				test.input, err, test.offset, test.expected)
+		}
+	}
+}
+
+func TestWithLenientParsing(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithLenientParsing())
+	input := []byte(`a => 1, B=>null`)
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "B": pgtype.Text{}}
+
+	var h pgxtypefaster.Hstore
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, input, &h); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("Hstore=%#v; expected %#v", h, expected)
+	}
+	var compat pgxtypefaster.HstoreCompat
+	if err := m.Scan(testHstoreOID+1, pgtype.TextFormatCode, input, &compat); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(compat, fasterToCompat(expected)) {
+		t.Errorf("HstoreCompat=%#v; expected %#v", compat, expected)
+	}
+	// scan targets that parse directly use the lenient parser
+	var out map[string]*string
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, input, &out); err != nil {
+		t.Fatal(err)
+	}
+	var strs map[string]any
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, input, &strs); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(strs, map[string]any{"a": "1", "B": nil}) {
+		t.Errorf("map[string]any=%#v", strs)
+	}
+
+	// strict duplicates
+	m = newOptionsTypeMap(pgxtypefaster.WithLenientParsing(), pgxtypefaster.WithStrictDuplicates())
+	var dupErr *pgxtypefaster.DuplicateKeyError
+	err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(`a=>1, a=>2`), &h)
+	if !errors.As(err, &dupErr) || *dupErr != (pgxtypefaster.DuplicateKeyError{Key: "a", Offset: 6}) {
+		t.Errorf("duplicate key: err=%#v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package pgxtypefaster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNULByte is returned when an hstore key or value contains the zero byte, which Postgres cannot
+// store in text values.
+var ErrNULByte = errors.New("hstore keys and values cannot contain the zero byte")
+
+// QuoteLiteral returns h as an SQL literal, like E'"a"=>"b"'::hstore. This should only be used
+// where query parameters cannot be used, such as DO blocks. A nil Hstore returns NULL::hstore.
+// It returns an error if any key or value contains a zero byte or is not valid UTF-8. The
+// literal uses the escape string syntax (E'...'), so it is correct regardless of the
+// standard_conforming_strings setting.
+func QuoteLiteral(h Hstore) (string, error) {
+	if h == nil {
+		return "NULL::hstore", nil
+	}
+	for k, v := range h {
+		if err := validateLiteralString(k); err != nil {
+			return "", fmt.Errorf("hstore key %#v: %w", k, err)
+		}
+		if v.Valid {
+			if err := validateLiteralString(v.String); err != nil {
+				return "", fmt.Errorf("hstore key %#v value: %w", k, err)
+			}
+		}
+	}
+
+	encoded, err := encodePlanHstoreCodecText{}.Encode(h, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(encoded) + len(`E''::hstore`))
+	builder.WriteString("E'")
+	for _, b := range encoded {
+		if b == '\\' || b == '\'' {
+			builder.WriteByte(b)
+		}
+		builder.WriteByte(b)
+	}
+	builder.WriteString("'::hstore")
+	return builder.String(), nil
+}
+
+func validateLiteralString(s string) error {
+	if strings.IndexByte(s, 0) != -1 {
+		return ErrNULByte
+	}
+	if !utf8.ValidString(s) {
+		return errors.New("invalid UTF-8")
+	}
+	return nil
+}
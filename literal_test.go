@@ -0,0 +1,40 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct {
+		input    pgxtypefaster.Hstore
+		expected string
+	}{
+		{nil, "NULL::hstore"},
+		{pgxtypefaster.Hstore{}, "E''::hstore"},
+		{pgxtypefaster.Hstore{"a": pgtype.Text{}}, `E'"a"=>NULL'::hstore`},
+		{pgxtypefaster.Hstore{`it's`: pgxtypefaster.NewText(`back\slash "quote"`)},
+			`E'"it''s"=>"back\\\\slash \\"quote\\""'::hstore`},
+	}
+	for _, test := range tests {
+		out, err := pgxtypefaster.QuoteLiteral(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.expected {
+			t.Errorf("QuoteLiteral(%#v)=%s; expected %s", test.input, out, test.expected)
+		}
+	}
+
+	_, err := pgxtypefaster.QuoteLiteral(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("\x00")})
+	if !errors.Is(err, pgxtypefaster.ErrNULByte) {
+		t.Errorf("expected ErrNULByte; err=%v", err)
+	}
+	_, err = pgxtypefaster.QuoteLiteral(pgxtypefaster.Hstore{"\xff": pgtype.Text{}})
+	if err == nil {
+		t.Error("expected error for invalid UTF-8")
+	}
+}
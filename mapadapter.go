@@ -0,0 +1,106 @@
+package pgxtypefaster
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Converter converts between hstore values and V, for HstoreMapAdapter.
+type Converter[V any] struct {
+	// FromText converts a scanned hstore value to V. It is required to scan.
+	FromText func(pgtype.Text) (V, error)
+	// ToText converts V to an hstore value. It is required to encode.
+	ToText func(V) (pgtype.Text, error)
+}
+
+var (
+	errConverterNoFromText = errors.New("Converter.FromText is nil: cannot scan")
+	errConverterNoToText   = errors.New("Converter.ToText is nil: cannot encode")
+)
+
+// HstoreMapAdapter adapts a map type with string keys to HstoreScanner and HstoreValuer, so it
+// can be scanned and encoded with the hstore codecs without implementing the interfaces or
+// converting at each call site. It also implements sql.Scanner and driver.Valuer. Create one with
+// AsHstoreScanner or AsHstoreValuer.
+type HstoreMapAdapter[M ~map[string]V, V any] struct {
+	m    *M
+	conv Converter[V]
+}
+
+// Ensure HstoreMapAdapter works with pgx and database/sql.
+var _ HstoreScanner = (*HstoreMapAdapter[map[string]int, int])(nil)
+var _ HstoreValuer = (*HstoreMapAdapter[map[string]int, int])(nil)
+var _ sql.Scanner = (*HstoreMapAdapter[map[string]int, int])(nil)
+var _ driver.Valuer = (*HstoreMapAdapter[map[string]int, int])(nil)
+
+// AsHstoreScanner returns an adapter that scans hstore values into *m, converting values with
+// conv.FromText. A NULL hstore sets *m to nil.
+func AsHstoreScanner[M ~map[string]V, V any](m *M, conv Converter[V]) *HstoreMapAdapter[M, V] {
+	return &HstoreMapAdapter[M, V]{m, conv}
+}
+
+// AsHstoreValuer returns an adapter that encodes m as an hstore, converting values with
+// conv.ToText. A nil m is encoded as NULL.
+func AsHstoreValuer[M ~map[string]V, V any](m M, conv Converter[V]) *HstoreMapAdapter[M, V] {
+	return &HstoreMapAdapter[M, V]{&m, conv}
+}
+
+func (a *HstoreMapAdapter[M, V]) ScanHstore(h Hstore) error {
+	if a.conv.FromText == nil {
+		return errConverterNoFromText
+	}
+	if h == nil {
+		*a.m = nil
+		return nil
+	}
+	out := make(M, len(h))
+	for k, v := range h {
+		converted, err := a.conv.FromText(v)
+		if err != nil {
+			return fmt.Errorf("hstore key %#v: %w", k, err)
+		}
+		out[k] = converted
+	}
+	*a.m = out
+	return nil
+}
+
+func (a *HstoreMapAdapter[M, V]) HstoreValue() (Hstore, error) {
+	if a.conv.ToText == nil {
+		return nil, errConverterNoToText
+	}
+	if *a.m == nil {
+		return nil, nil
+	}
+	h := make(Hstore, len(*a.m))
+	for k, v := range *a.m {
+		converted, err := a.conv.ToText(v)
+		if err != nil {
+			return nil, fmt.Errorf("hstore key %#v: %w", k, err)
+		}
+		h[k] = converted
+	}
+	return h, nil
+}
+
+// Scan implements the database/sql Scanner interface.
+func (a *HstoreMapAdapter[M, V]) Scan(src any) error {
+	var h Hstore
+	if err := h.Scan(src); err != nil {
+		return err
+	}
+	return a.ScanHstore(h)
+}
+
+// Value implements the database/sql/driver Valuer interface.
+func (a *HstoreMapAdapter[M, V]) Value() (driver.Value, error) {
+	h, err := a.HstoreValue()
+	if err != nil {
+		return nil, err
+	}
+	return h.Value()
+}
@@ -0,0 +1,84 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Quantities is an existing domain map type.
+type Quantities map[string]int
+
+var intConverter = pgxtypefaster.Converter[int]{
+	FromText: func(t pgtype.Text) (int, error) {
+		if !t.Valid {
+			return 0, errors.New("NULL quantity")
+		}
+		return strconv.Atoi(t.String)
+	},
+	ToText: func(v int) (pgtype.Text, error) {
+		return pgxtypefaster.NewText(strconv.Itoa(v)), nil
+	},
+}
+
+func TestHstoreMapAdapter(t *testing.T) {
+	m := newTestTypeMap()
+	in := Quantities{"apples": 3, "pears": -1}
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, pgxtypefaster.AsHstoreValuer(in, intConverter), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Quantities
+		if err := m.Scan(testHstoreOID, format, buf, pgxtypefaster.AsHstoreScanner(&out, intConverter)); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("format=%d: got %#v; expected %#v", format, out, in)
+		}
+
+		// NULL
+		buf, err = m.Encode(testHstoreOID, format, pgxtypefaster.AsHstoreValuer(Quantities(nil), intConverter), nil)
+		if err != nil || buf != nil {
+			t.Errorf("format=%d: nil map must encode as NULL: %#v %v", format, buf, err)
+		}
+		if err := m.Scan(testHstoreOID, format, nil, pgxtypefaster.AsHstoreScanner(&out, intConverter)); err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v", format, out)
+		}
+
+		// conversion errors include the key
+		buf, err = m.Encode(testHstoreOID, format, pgxtypefaster.Hstore{"bad": pgxtypefaster.NewText("x")}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = m.Scan(testHstoreOID, format, buf, pgxtypefaster.AsHstoreScanner(&out, intConverter))
+		if err == nil || !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("format=%d: expected syntax error; err=%v", format, err)
+		}
+	}
+
+	// database/sql
+	value, err := pgxtypefaster.AsHstoreValuer(in, intConverter).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Quantities
+	if err := pgxtypefaster.AsHstoreScanner(&out, intConverter).Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("database/sql: got %#v; expected %#v", out, in)
+	}
+
+	if err := pgxtypefaster.AsHstoreScanner(&out, pgxtypefaster.Converter[int]{}).ScanHstore(nil); err == nil {
+		t.Error("missing FromText expected error")
+	}
+}
@@ -0,0 +1,152 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MarshalHstore returns an Hstore with the fields of struct v, which must be a struct or a pointer
+// to a struct. Fields are mapped with `hstore:"name,omitempty"` struct tags, like encoding/json:
+// exported fields without a tag use the field name, `hstore:"-"` ignores the field, and omitempty
+// omits zero values. Nil pointers and invalid pgtype.Text values are NULL. Fields can be strings,
+// bools, integers, floats, pointers to them, or pgtype.Text. A nil pointer returns a nil Hstore.
+func MarshalHstore(v any) (Hstore, error) {
+	t, err := structType(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := cachedHstoreFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	structValue := reflect.ValueOf(v)
+	if structValue.Kind() == reflect.Pointer {
+		if structValue.IsNil() {
+			return nil, nil
+		}
+		structValue = structValue.Elem()
+	}
+
+	h := make(Hstore, len(fields))
+	for _, field := range fields {
+		fieldValue := structValue.FieldByIndex(field.index)
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		h[field.key] = marshalHstoreField(fieldValue)
+	}
+	return h, nil
+}
+
+func marshalHstoreField(v reflect.Value) pgtype.Text {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return pgtype.Text{}
+		}
+		v = v.Elem()
+	}
+	if v.Type() == textType {
+		return v.Interface().(pgtype.Text)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return NewText(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewText(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NewText(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return NewText(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	}
+	// cachedHstoreFields only returns supported kinds: the remaining kind is string
+	return NewText(v.String())
+}
+
+// UnmarshalHstore sets the fields of the struct pointed to by v from h, using the same struct tags
+// as MarshalHstore. Keys that are missing from h leave the field unchanged, and keys without a
+// field are ignored. A NULL value sets pointers to nil and pgtype.Text to invalid, and returns an
+// error for other types.
+func UnmarshalHstore(h Hstore, v any) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return fmt.Errorf("UnmarshalHstore requires a non-nil pointer to a struct; found %T", v)
+	}
+	t, err := structType(v)
+	if err != nil {
+		return err
+	}
+	fields, err := cachedHstoreFields(t)
+	if err != nil {
+		return err
+	}
+
+	structValue := ptr.Elem()
+	for _, field := range fields {
+		value, ok := h[field.key]
+		if !ok {
+			continue
+		}
+		err := unmarshalHstoreField(structValue.FieldByIndex(field.index), value)
+		if err != nil {
+			return fmt.Errorf("hstore key %#v: %w", field.key, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalHstoreField(v reflect.Value, value pgtype.Text) error {
+	if v.Type() == textType {
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if !value.Valid {
+			v.SetZero()
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := unmarshalHstoreField(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if !value.Valid {
+		return fmt.Errorf("cannot unmarshal NULL into %s", v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value.String)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value.String, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value.String, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value.String, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		v.SetString(value.String)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMarshalHstore(t *testing.T) {
+	ratio := 0.25
+	v := schemaExample{
+		schemaEmbedded: schemaEmbedded{Region: "us"},
+		Name:           "n",
+		Ratio:          &ratio,
+		Enabled:        true,
+		Ignored:        "ignored",
+		Untagged:       "u",
+	}
+	h, err := pgxtypefaster.MarshalHstore(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pgxtypefaster.NewHstoreFromPairs("region", "us", "name", "n", "ratio", "0.25", "enabled", "true", "Untagged", "u")
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("MarshalHstore=%#v; expected %#v", h, expected)
+	}
+
+	v.Ratio = nil
+	v.Count = -5
+	v.Comment = pgxtypefaster.NewText("c")
+	h, err = pgxtypefaster.MarshalHstore(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h["ratio"].Valid || h["count"] != pgxtypefaster.NewText("-5") || h["comment"] != pgxtypefaster.NewText("c") {
+		t.Errorf("MarshalHstore=%#v", h)
+	}
+
+	h, err = pgxtypefaster.MarshalHstore((*schemaExample)(nil))
+	if err != nil || h != nil {
+		t.Errorf("MarshalHstore(nil)=%#v, %v", h, err)
+	}
+	if _, err := pgxtypefaster.MarshalHstore(42); err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
+
+func TestUnmarshalHstore(t *testing.T) {
+	h := pgxtypefaster.Hstore{
+		"region":  pgxtypefaster.NewText("eu"),
+		"name":    pgxtypefaster.NewText("n"),
+		"count":   pgxtypefaster.NewText("42"),
+		"ratio":   pgxtypefaster.NewText("1.5"),
+		"enabled": pgxtypefaster.NewText("true"),
+		"comment": pgtype.Text{},
+		"unknown": pgxtypefaster.NewText("ignored"),
+	}
+	v := schemaExample{Untagged: "unchanged", Comment: pgxtypefaster.NewText("replaced")}
+	if err := pgxtypefaster.UnmarshalHstore(h, &v); err != nil {
+		t.Fatal(err)
+	}
+	ratio := 1.5
+	expected := schemaExample{
+		schemaEmbedded: schemaEmbedded{Region: "eu"},
+		Name:           "n",
+		Count:          42,
+		Ratio:          &ratio,
+		Enabled:        true,
+		Untagged:       "unchanged",
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("UnmarshalHstore=%#v; expected %#v", v, expected)
+	}
+
+	// round trip
+	roundTrip, err := pgxtypefaster.MarshalHstore(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out schemaExample
+	if err := pgxtypefaster.UnmarshalHstore(roundTrip, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, v) {
+		t.Errorf("round trip=%#v; expected %#v", out, v)
+	}
+
+	errInputs := []pgxtypefaster.Hstore{
+		{"name": pgtype.Text{}},
+		{"count": pgxtypefaster.NewText("x")},
+		{"enabled": pgxtypefaster.NewText("maybe")},
+	}
+	for _, input := range errInputs {
+		if err := pgxtypefaster.UnmarshalHstore(input, &out); err == nil {
+			t.Errorf("UnmarshalHstore(%#v) expected error", input)
+		}
+	}
+	if err := pgxtypefaster.UnmarshalHstore(h, out); err == nil {
+		t.Error("UnmarshalHstore must require a pointer")
+	}
+}
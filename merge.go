@@ -0,0 +1,102 @@
+package pgxtypefaster
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// Merge returns a new Hstore with the pairs of h and other. If a key is in both, the value from
+// other is used, like the Postgres || operator. Unlike Postgres, a nil Hstore is treated as
+// empty, so the result is never nil.
+func (h Hstore) Merge(other Hstore) Hstore {
+	return hstoreOps.merge(h, other)
+}
+
+// Diff returns the changes from h to other: added contains the keys only in other, removed
+// contains the keys only in h with their old values, and changed contains the keys in both with
+// different values, with the new values from other. A NULL value is different from every string.
+// The results are nil if empty. To update a row from h to other, set the keys in added and changed
+// and delete the keys in removed, for example with HstoreColumn.SetKeys and DeleteKeys.
+func (h Hstore) Diff(other Hstore) (added Hstore, removed Hstore, changed Hstore) {
+	return hstoreOps.diff(h, other)
+}
+
+// Filter returns a new Hstore with the pairs of h where keep returns true. A nil h returns nil.
+func (h Hstore) Filter(keep func(key string, value pgtype.Text) bool) Hstore {
+	if h == nil {
+		return nil
+	}
+	out := Hstore{}
+	for k, v := range h {
+		if keep(k, v) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// DeleteNulls removes the keys with NULL values from h, without copying it.
+func (h Hstore) DeleteNulls() {
+	for k, v := range h {
+		if !v.Valid {
+			delete(h, k)
+		}
+	}
+}
+
+// Select returns a new Hstore with only the pairs of h with keys, like the Postgres slice
+// function. Keys that are not in h are ignored. A nil h returns nil.
+func (h Hstore) Select(keys ...string) Hstore {
+	if h == nil {
+		return nil
+	}
+	size := len(keys)
+	if len(h) < size {
+		size = len(h)
+	}
+	out := make(Hstore, size)
+	for _, k := range keys {
+		if v, ok := h[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Clone returns a copy of h. A nil h returns nil. Strings are immutable, so they are shared.
+func (h Hstore) Clone() Hstore {
+	if h == nil {
+		return nil
+	}
+	return hstoreOps.clone(h)
+}
+
+// Equal returns true if h and other have the same pairs. NULL values are equal to each other, even
+// if the String fields differ. A nil (NULL) Hstore is only equal to nil, not to an empty Hstore.
+func (h Hstore) Equal(other Hstore) bool {
+	return hstoreOps.equal(h, other)
+}
+
+// Merge returns a new HstoreCompat with the pairs of h and other, like Hstore.Merge. The values
+// point to the same strings as h and other.
+func (h HstoreCompat) Merge(other HstoreCompat) HstoreCompat {
+	return hstoreCompatOps.merge(h, other)
+}
+
+// Diff returns the changes from h to other, like Hstore.Diff. Values are compared by the strings
+// they point to. The results point to the same strings as h and other.
+func (h HstoreCompat) Diff(other HstoreCompat) (added HstoreCompat, removed HstoreCompat, changed HstoreCompat) {
+	return hstoreCompatOps.diff(h, other)
+}
+
+// Clone returns a copy of h, with the values copied to new strings, so the copy can be changed
+// through the pointers without changing h. A nil h returns nil.
+func (h HstoreCompat) Clone() HstoreCompat {
+	if h == nil {
+		return nil
+	}
+	return hstoreCompatOps.copyValues(nil, h)
+}
+
+// Equal returns true if h and other have the same pairs, comparing the strings the values point
+// to. A nil (NULL) HstoreCompat is only equal to nil, not to an empty HstoreCompat.
+func (h HstoreCompat) Equal(other HstoreCompat) bool {
+	return hstoreCompatOps.equal(h, other)
+}
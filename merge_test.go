@@ -0,0 +1,196 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreMerge(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2")}
+	other := pgxtypefaster.Hstore{"b": pgtype.Text{}, "c": pgxtypefaster.NewText("3")}
+	merged := h.Merge(other)
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}, "c": pgxtypefaster.NewText("3")}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Merge=%#v; expected %#v", merged, expected)
+	}
+	// inputs are not modified
+	if h["b"] != pgxtypefaster.NewText("2") || len(h) != 2 {
+		t.Errorf("Merge modified h: %#v", h)
+	}
+
+	var null pgxtypefaster.Hstore
+	if merged := null.Merge(nil); merged == nil || len(merged) != 0 {
+		t.Errorf("Merge of nil=%#v; expected empty", merged)
+	}
+}
+
+func TestHstoreDiff(t *testing.T) {
+	h := pgxtypefaster.Hstore{
+		"same":      pgxtypefaster.NewText("1"),
+		"changed":   pgxtypefaster.NewText("old"),
+		"to_null":   pgxtypefaster.NewText(""),
+		"from_null": pgtype.Text{},
+		"removed":   pgxtypefaster.NewText("gone"),
+	}
+	other := pgxtypefaster.Hstore{
+		"same":      pgxtypefaster.NewText("1"),
+		"changed":   pgxtypefaster.NewText("new"),
+		"to_null":   pgtype.Text{},
+		"from_null": pgxtypefaster.NewText(""),
+		"added":     pgtype.Text{},
+	}
+	added, removed, changed := h.Diff(other)
+	if !reflect.DeepEqual(added, pgxtypefaster.Hstore{"added": pgtype.Text{}}) {
+		t.Errorf("added=%#v", added)
+	}
+	if !reflect.DeepEqual(removed, pgxtypefaster.Hstore{"removed": pgxtypefaster.NewText("gone")}) {
+		t.Errorf("removed=%#v", removed)
+	}
+	expectedChanged := pgxtypefaster.Hstore{
+		"changed":   pgxtypefaster.NewText("new"),
+		"to_null":   pgtype.Text{},
+		"from_null": pgxtypefaster.NewText(""),
+	}
+	if !reflect.DeepEqual(changed, expectedChanged) {
+		t.Errorf("changed=%#v", changed)
+	}
+
+	// applying the diff to h produces other
+	applied := h.Merge(added).Merge(changed)
+	for k := range removed {
+		delete(applied, k)
+	}
+	if !reflect.DeepEqual(applied, other) {
+		t.Errorf("applied diff=%#v; expected %#v", applied, other)
+	}
+
+	added, removed, changed = h.Diff(h)
+	if added != nil || removed != nil || changed != nil {
+		t.Errorf("Diff with itself=%#v %#v %#v; expected nil", added, removed, changed)
+	}
+}
+
+func TestHstoreCloneEqual(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": pgtype.Text{}}
+	clone := h.Clone()
+	if !h.Equal(clone) || !clone.Equal(h) {
+		t.Errorf("Clone()=%#v must equal %#v", clone, h)
+	}
+	clone["a"] = pgxtypefaster.NewText("2")
+	if h["a"] != pgxtypefaster.NewText("1") {
+		t.Error("modifying the clone modified the original")
+	}
+	if h.Equal(clone) {
+		t.Error("different values must not be equal")
+	}
+	if pgxtypefaster.Hstore(nil).Clone() != nil {
+		t.Error("Clone of nil must be nil")
+	}
+
+	tests := []struct {
+		a, b  pgxtypefaster.Hstore
+		equal bool
+	}{
+		{nil, nil, true},
+		{nil, pgxtypefaster.Hstore{}, false},
+		{pgxtypefaster.Hstore{}, pgxtypefaster.Hstore{}, true},
+		{pgxtypefaster.Hstore{"a": {String: "ignored"}}, pgxtypefaster.Hstore{"a": {}}, true},
+		{pgxtypefaster.Hstore{"a": {}}, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("")}, false},
+		{pgxtypefaster.Hstore{"a": {}}, pgxtypefaster.Hstore{"b": {}}, false},
+		{pgxtypefaster.Hstore{"a": {}}, pgxtypefaster.Hstore{"a": {}, "b": {}}, false},
+	}
+	for i, test := range tests {
+		if test.a.Equal(test.b) != test.equal || test.b.Equal(test.a) != test.equal {
+			t.Errorf("%d: %#v.Equal(%#v) expected %t", i, test.a, test.b, test.equal)
+		}
+	}
+}
+
+func TestHstoreCompatHelpers(t *testing.T) {
+	h := pgxtypefaster.Hstore{
+		"same":    pgxtypefaster.NewText("1"),
+		"changed": pgxtypefaster.NewText("old"),
+		"to_null": pgxtypefaster.NewText(""),
+		"removed": pgxtypefaster.NewText("gone"),
+	}
+	other := pgxtypefaster.Hstore{
+		"same":    pgxtypefaster.NewText("1"),
+		"changed": pgxtypefaster.NewText("new"),
+		"to_null": pgtype.Text{},
+		"added":   pgtype.Text{},
+	}
+	compat := pgxtypefaster.HstoreToCompat(h)
+	otherCompat := pgxtypefaster.HstoreToCompat(other)
+
+	// the results must match Hstore
+	merged := compat.Merge(otherCompat)
+	if !pgxtypefaster.CompatToHstore(merged).Equal(h.Merge(other)) {
+		t.Errorf("Merge=%#v", merged)
+	}
+	added, removed, changed := compat.Diff(otherCompat)
+	expectedAdded, expectedRemoved, expectedChanged := h.Diff(other)
+	if !pgxtypefaster.CompatToHstore(added).Equal(expectedAdded) ||
+		!pgxtypefaster.CompatToHstore(removed).Equal(expectedRemoved) ||
+		!pgxtypefaster.CompatToHstore(changed).Equal(expectedChanged) {
+		t.Errorf("Diff=%#v %#v %#v", added, removed, changed)
+	}
+	// equal strings in different pointers are not changed
+	added, removed, changed = compat.Diff(compat.Clone())
+	if added != nil || removed != nil || changed != nil {
+		t.Errorf("Diff with a clone=%#v %#v %#v; expected nil", added, removed, changed)
+	}
+
+	clone := compat.Clone()
+	if !compat.Equal(clone) || !clone.Equal(compat) {
+		t.Errorf("Clone()=%#v must equal %#v", clone, compat)
+	}
+	*clone["same"] = "2"
+	if *compat["same"] != "1" {
+		t.Error("modifying the clone's values modified the original")
+	}
+	if compat.Equal(clone) {
+		t.Error("different values must not be equal")
+	}
+	if pgxtypefaster.HstoreCompat(nil).Clone() != nil {
+		t.Error("Clone of nil must be nil")
+	}
+	if pgxtypefaster.HstoreCompat(nil).Equal(pgxtypefaster.HstoreCompat{}) {
+		t.Error("nil must not equal empty")
+	}
+}
+
+func TestHstoreFilterSelect(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}, "c": pgxtypefaster.NewText("3")}
+
+	filtered := h.Filter(func(k string, v pgtype.Text) bool { return k != "a" })
+	expected := pgxtypefaster.Hstore{"b": pgtype.Text{}, "c": pgxtypefaster.NewText("3")}
+	if !reflect.DeepEqual(filtered, expected) {
+		t.Errorf("Filter=%#v; expected %#v", filtered, expected)
+	}
+	if filtered := h.Filter(func(string, pgtype.Text) bool { return false }); filtered == nil || len(filtered) != 0 {
+		t.Errorf("Filter removing all pairs=%#v; expected empty", filtered)
+	}
+
+	selected := h.Select("b", "c", "missing")
+	if !reflect.DeepEqual(selected, expected) {
+		t.Errorf("Select=%#v; expected %#v", selected, expected)
+	}
+	if len(h) != 3 {
+		t.Errorf("Filter or Select modified h: %#v", h)
+	}
+
+	var null pgxtypefaster.Hstore
+	if null.Filter(func(string, pgtype.Text) bool { return true }) != nil || null.Select("a") != nil {
+		t.Error("Filter and Select of nil must return nil")
+	}
+	null.DeleteNulls()
+
+	h.DeleteNulls()
+	expected = pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "c": pgxtypefaster.NewText("3")}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("DeleteNulls=%#v; expected %#v", h, expected)
+	}
+}
@@ -0,0 +1,140 @@
+package pgxtypefaster
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CodecEvent describes one value encoded or scanned by a codec wrapped with MetricsMiddleware.
+type CodecEvent struct {
+	OID    uint32
+	Format int16
+	// Scan is true when scanning a value, and false when encoding one.
+	Scan bool
+	// Null is true if the value is NULL.
+	Null bool
+	// Bytes is the length of the value in format.
+	Bytes int
+	// Pairs is the number of pairs. If a value in the text format is not one of this package's
+	// hstore types, it is estimated by counting '>' characters, which may count too many.
+	Pairs int
+	// Escaped is true if the value in the text format contains backslash escapes, which are slower
+	// to parse and encode than values without them. It is false for the binary format.
+	Escaped bool
+	// Duration is the time spent encoding or scanning, including any wrapped middlewares.
+	Duration time.Duration
+	// Err is the error returned by encoding or scanning.
+	Err error
+}
+
+// CodecMetrics receives a CodecEvent for each value encoded or scanned by a codec wrapped with
+// MetricsMiddleware, for example to update Prometheus counters and histograms. Observe is called
+// by the goroutine using the connection, so it must be fast and safe to use concurrently.
+type CodecMetrics interface {
+	Observe(event CodecEvent)
+}
+
+// CodecMetricsFunc is a function that implements CodecMetrics.
+type CodecMetricsFunc func(event CodecEvent)
+
+// Observe calls f(event).
+func (f CodecMetricsFunc) Observe(event CodecEvent) {
+	f(event)
+}
+
+// MetricsMiddleware returns a CodecMiddleware that reports each value encoded or scanned to
+// metrics. Use it with Chain. It measures the time with time.Now, which adds some overhead.
+func MetricsMiddleware(metrics CodecMetrics) CodecMiddleware {
+	return metricsMiddleware{metrics}
+}
+
+type metricsMiddleware struct {
+	metrics CodecMetrics
+}
+
+func (mw metricsMiddleware) WrapEncodePlan(oid uint32, format int16, value any, next pgtype.EncodePlan) pgtype.EncodePlan {
+	return &encodePlanMetrics{mw.metrics, oid, format, next}
+}
+
+func (mw metricsMiddleware) WrapScanPlan(oid uint32, format int16, target any, next pgtype.ScanPlan) pgtype.ScanPlan {
+	return &scanPlanMetrics{mw.metrics, oid, format, next}
+}
+
+type encodePlanMetrics struct {
+	metrics CodecMetrics
+	oid     uint32
+	format  int16
+	next    pgtype.EncodePlan
+}
+
+func (p *encodePlanMetrics) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	start := time.Now()
+	newBuf, err = p.next.Encode(value, buf)
+	duration := time.Since(start)
+	event := CodecEvent{OID: p.oid, Format: p.format, Null: newBuf == nil, Duration: duration, Err: err}
+	if err == nil && newBuf != nil {
+		encoded := newBuf[len(buf):]
+		event.Bytes = len(encoded)
+		event.Pairs = metricsPairs(p.format, encoded, value)
+		event.Escaped = p.format == pgtype.TextFormatCode && bytes.IndexByte(encoded, '\\') >= 0
+	}
+	p.metrics.Observe(event)
+	return newBuf, err
+}
+
+type scanPlanMetrics struct {
+	metrics CodecMetrics
+	oid     uint32
+	format  int16
+	next    pgtype.ScanPlan
+}
+
+func (p *scanPlanMetrics) Scan(src []byte, dst any) error {
+	start := time.Now()
+	err := p.next.Scan(src, dst)
+	duration := time.Since(start)
+	event := CodecEvent{OID: p.oid, Format: p.format, Scan: true, Null: src == nil, Bytes: len(src),
+		Duration: duration, Err: err}
+	if err == nil && src != nil {
+		event.Pairs = metricsPairs(p.format, src, dst)
+		event.Escaped = p.format == pgtype.TextFormatCode && bytes.IndexByte(src, '\\') >= 0
+	}
+	p.metrics.Observe(event)
+	return err
+}
+
+// metricsPairs returns the number of pairs of src in format, which was encoded from or scanned
+// into v.
+func metricsPairs(format int16, src []byte, v any) int {
+	if format == pgtype.BinaryFormatCode {
+		// src was encoded or parsed without error, so the count is correct
+		var r pgio.Reader
+		r.Reset(src)
+		return r.ReadCount(minBinaryPairLen)
+	}
+	if dst, ok := v.(*any); ok {
+		v = *dst
+	}
+	switch v := v.(type) {
+	case Hstore:
+		return len(v)
+	case *Hstore:
+		return len(*v)
+	case HstoreCompat:
+		return len(v)
+	case *HstoreCompat:
+		return len(*v)
+	case OrderedHstore:
+		return len(v)
+	case *OrderedHstore:
+		return len(*v)
+	case HstorePairs:
+		return len(v)
+	case *HstorePairs:
+		return len(*v)
+	}
+	return estimatePairCount(format, src)
+}
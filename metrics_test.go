@@ -0,0 +1,71 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	var events []pgxtypefaster.CodecEvent
+	metrics := pgxtypefaster.CodecMetricsFunc(func(event pgxtypefaster.CodecEvent) {
+		events = append(events, event)
+	})
+	codec := pgxtypefaster.Chain(pgxtypefaster.NewHstoreCodec(pgxtypefaster.WithMaxPairs(2)),
+		pgxtypefaster.MetricsMiddleware(metrics))
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: codec, Name: "hstore", OID: testHstoreOID})
+
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText(`x"y`), "b>c": pgtype.Text{}}
+	for _, format := range formats {
+		events = nil
+		encoded, err := m.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var scanned pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, encoded, &scanned); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("format %d: %d events; expected 2", format, len(events))
+		}
+		escaped := format == pgtype.TextFormatCode
+		for i, event := range events {
+			if event.Scan != (i == 1) || event.OID != testHstoreOID || event.Format != format ||
+				event.Null || event.Bytes != len(encoded) || event.Pairs != 2 ||
+				event.Escaped != escaped || event.Err != nil || event.Duration < 0 {
+				t.Errorf("format %d: event %d=%#v", format, i, event)
+			}
+		}
+
+		// DecodeValue reports the pairs of the decoded value
+		events = nil
+		if _, err := codec.DecodeValue(m, testHstoreOID, format, encoded); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Pairs != 2 {
+			t.Errorf("format %d: DecodeValue events=%#v", format, events)
+		}
+	}
+
+	events = nil
+	var scanned pgxtypefaster.Hstore
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, nil, &scanned); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || !events[0].Null || events[0].Pairs != 0 {
+		t.Errorf("NULL events=%#v", events)
+	}
+
+	events = nil
+	err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(`a=>b, c=>d, e=>f`), &scanned)
+	if err == nil {
+		t.Fatal("expected WithMaxPairs error")
+	}
+	if len(events) != 1 || !errors.Is(events[0].Err, err) {
+		t.Errorf("error events=%#v", events)
+	}
+}
@@ -0,0 +1,133 @@
+package pgxtypefaster
+
+import (
+	"database/sql/driver"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CodecMiddleware wraps the encode and scan plans created by a codec, to add behavior such as
+// metrics, validation, or size limits to any codec. Use Chain to apply middlewares to a codec.
+// Either method can return next unchanged to only wrap one direction.
+type CodecMiddleware interface {
+	// WrapEncodePlan returns a plan that encodes value, usually by calling next.
+	WrapEncodePlan(oid uint32, format int16, value any, next pgtype.EncodePlan) pgtype.EncodePlan
+	// WrapScanPlan returns a plan that scans into target, usually by calling next. For DecodeValue
+	// and DecodeDatabaseSQLValue, target is an *any or a *driver.Value.
+	WrapScanPlan(oid uint32, format int16, target any, next pgtype.ScanPlan) pgtype.ScanPlan
+}
+
+// ChainedCodec is a pgtype.Codec that applies middlewares to the plans created by Codec. Create
+// it with Chain.
+type ChainedCodec struct {
+	Codec       pgtype.Codec
+	Middlewares []CodecMiddleware
+}
+
+// Chain returns a codec that wraps codec's plans with middlewares. The first middleware is the
+// outermost, so it is called first when encoding or scanning. If codec is a *ChainedCodec,
+// middlewares are added outside its existing middlewares.
+func Chain(codec pgtype.Codec, middlewares ...CodecMiddleware) *ChainedCodec {
+	if chained, ok := codec.(*ChainedCodec); ok {
+		// flatten so the plans are only wrapped once per middleware
+		combined := make([]CodecMiddleware, 0, len(middlewares)+len(chained.Middlewares))
+		combined = append(combined, middlewares...)
+		combined = append(combined, chained.Middlewares...)
+		return &ChainedCodec{chained.Codec, combined}
+	}
+	return &ChainedCodec{codec, middlewares}
+}
+
+// Unwrap returns the codec without middlewares.
+func (c *ChainedCodec) Unwrap() pgtype.Codec {
+	return c.Codec
+}
+
+func (c *ChainedCodec) FormatSupported(format int16) bool {
+	return c.Codec.FormatSupported(format)
+}
+
+func (c *ChainedCodec) PreferredFormat() int16 {
+	return c.Codec.PreferredFormat()
+}
+
+func (c *ChainedCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	plan := c.Codec.PlanEncode(m, oid, format, value)
+	if plan == nil {
+		return nil
+	}
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		plan = c.Middlewares[i].WrapEncodePlan(oid, format, value, plan)
+	}
+	return plan
+}
+
+func (c *ChainedCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	plan := c.Codec.PlanScan(m, oid, format, target)
+	if plan == nil {
+		return nil
+	}
+	return c.wrapScanPlan(oid, format, target, plan)
+}
+
+func (c *ChainedCodec) wrapScanPlan(oid uint32, format int16, target any, plan pgtype.ScanPlan) pgtype.ScanPlan {
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		plan = c.Middlewares[i].WrapScanPlan(oid, format, target, plan)
+	}
+	return plan
+}
+
+func (c *ChainedCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	var value driver.Value
+	plan := c.wrapScanPlan(oid, format, &value, &scanPlanDecodeDatabaseSQLValue{c.Codec, m, oid, format})
+	err := plan.Scan(src, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *ChainedCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	var value any
+	plan := c.wrapScanPlan(oid, format, &value, &scanPlanDecodeValue{c.Codec, m, oid, format})
+	err := plan.Scan(src, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// scanPlanDecodeValue calls the codec's DecodeValue, so middlewares can wrap it like a scan plan.
+type scanPlanDecodeValue struct {
+	codec  pgtype.Codec
+	m      *pgtype.Map
+	oid    uint32
+	format int16
+}
+
+func (p *scanPlanDecodeValue) Scan(src []byte, dst any) error {
+	value, err := p.codec.DecodeValue(p.m, p.oid, p.format, src)
+	if err != nil {
+		return err
+	}
+	*(dst.(*any)) = value
+	return nil
+}
+
+// scanPlanDecodeDatabaseSQLValue calls the codec's DecodeDatabaseSQLValue, so middlewares can wrap
+// it like a scan plan.
+type scanPlanDecodeDatabaseSQLValue struct {
+	codec  pgtype.Codec
+	m      *pgtype.Map
+	oid    uint32
+	format int16
+}
+
+func (p *scanPlanDecodeDatabaseSQLValue) Scan(src []byte, dst any) error {
+	value, err := p.codec.DecodeDatabaseSQLValue(p.m, p.oid, p.format, src)
+	if err != nil {
+		return err
+	}
+	*(dst.(*driver.Value)) = value
+	return nil
+}
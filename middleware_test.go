@@ -0,0 +1,124 @@
+package pgxtypefaster_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// recordingMiddleware appends its name to calls when its plans are used.
+type recordingMiddleware struct {
+	name  string
+	calls *[]string
+}
+
+func (r recordingMiddleware) WrapEncodePlan(oid uint32, format int16, value any, next pgtype.EncodePlan) pgtype.EncodePlan {
+	return encodePlanFunc(func(value any, buf []byte) ([]byte, error) {
+		*r.calls = append(*r.calls, "encode "+r.name)
+		return next.Encode(value, buf)
+	})
+}
+
+func (r recordingMiddleware) WrapScanPlan(oid uint32, format int16, target any, next pgtype.ScanPlan) pgtype.ScanPlan {
+	return scanPlanFunc(func(src []byte, target any) error {
+		*r.calls = append(*r.calls, fmt.Sprintf("scan %s %T", r.name, target))
+		return next.Scan(src, target)
+	})
+}
+
+type encodePlanFunc func(value any, buf []byte) ([]byte, error)
+
+func (f encodePlanFunc) Encode(value any, buf []byte) ([]byte, error) { return f(value, buf) }
+
+type scanPlanFunc func(src []byte, target any) error
+
+func (f scanPlanFunc) Scan(src []byte, target any) error { return f(src, target) }
+
+// sizeLimit rejects encoded or scanned values larger than the limit.
+type sizeLimit int
+
+func (l sizeLimit) WrapEncodePlan(oid uint32, format int16, value any, next pgtype.EncodePlan) pgtype.EncodePlan {
+	return encodePlanFunc(func(value any, buf []byte) ([]byte, error) {
+		start := len(buf)
+		buf, err := next.Encode(value, buf)
+		if err == nil && len(buf)-start > int(l) {
+			return nil, fmt.Errorf("encoded value is %d bytes; limit %d", len(buf)-start, int(l))
+		}
+		return buf, err
+	})
+}
+
+func (l sizeLimit) WrapScanPlan(oid uint32, format int16, target any, next pgtype.ScanPlan) pgtype.ScanPlan {
+	return scanPlanFunc(func(src []byte, target any) error {
+		if len(src) > int(l) {
+			return fmt.Errorf("value is %d bytes; limit %d", len(src), int(l))
+		}
+		return next.Scan(src, target)
+	})
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	codec := pgxtypefaster.Chain(pgxtypefaster.HstoreCodec{},
+		recordingMiddleware{"outer", &calls}, recordingMiddleware{"inner", &calls})
+	codec = pgxtypefaster.Chain(codec, sizeLimit(20))
+	if codec.Unwrap() != (pgxtypefaster.HstoreCodec{}) || len(codec.Middlewares) != 3 {
+		t.Fatalf("Chain did not flatten: %#v", codec)
+	}
+
+	m := pgtype.NewMap()
+	const oid = 100000
+	m.RegisterType(&pgtype.Type{Codec: codec, Name: "hstore", OID: oid})
+
+	small := pgxtypefaster.Hstore{"k": pgxtypefaster.NewText("v")}
+	buf, err := m.Encode(oid, pgtype.TextFormatCode, small, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output pgxtypefaster.Hstore
+	err = m.Scan(oid, pgtype.TextFormatCode, buf, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(output, small) {
+		t.Errorf("output=%#v; expected %#v", output, small)
+	}
+	decoded, err := codec.DecodeValue(m, oid, pgtype.TextFormatCode, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, small) {
+		t.Errorf("DecodeValue=%#v; expected %#v", decoded, small)
+	}
+	sqlValue, err := codec.DecodeDatabaseSQLValue(m, oid, pgtype.TextFormatCode, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlValue != driver.Value(string(buf)) {
+		t.Errorf("DecodeDatabaseSQLValue=%#v; expected %#v", sqlValue, string(buf))
+	}
+
+	expectedCalls := []string{
+		"encode outer", "encode inner",
+		"scan outer *pgxtypefaster.Hstore", "scan inner *pgxtypefaster.Hstore",
+		"scan outer *interface {}", "scan inner *interface {}",
+		"scan outer *driver.Value", "scan inner *driver.Value",
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("calls=%#v; expected %#v", calls, expectedCalls)
+	}
+
+	large := pgxtypefaster.Hstore{"key": pgxtypefaster.NewText("a value that is too long")}
+	_, err = m.Encode(oid, pgtype.BinaryFormatCode, large, nil)
+	if err == nil {
+		t.Error("expected Encode to fail the size limit")
+	}
+	_, err = codec.DecodeValue(m, oid, pgtype.TextFormatCode, []byte(`"key"=>"a value that is too long"`))
+	if err == nil {
+		t.Error("expected DecodeValue to fail the size limit")
+	}
+}
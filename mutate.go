@@ -0,0 +1,98 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var errEmptyWhere = errors.New("where must not be empty: use \"true\" to update all rows")
+
+// HstoreColumn is an hstore column in a table. Its methods generate and execute parameterized
+// UPDATE statements for common changes. The where argument of each method is a SQL condition that
+// selects the rows to update, with parameters $1, $2, ... for args. The hstore parameter follows
+// args. To run the statements in a transaction, pass tx.Conn().
+type HstoreColumn struct {
+	Table  pgx.Identifier
+	Column string
+}
+
+// update returns an UPDATE statement setting the column to exprPrefix + "$N" + exprSuffix, where
+// $N is the parameter for value, after args.
+func (c HstoreColumn) update(exprPrefix string, exprSuffix string, where string, args []any, value any) (string, []any, error) {
+	if where == "" {
+		return "", nil, errEmptyWhere
+	}
+	column := pgx.Identifier{c.Column}.Sanitize()
+	param := "$" + strconv.Itoa(len(args)+1)
+	sql := "update " + c.Table.Sanitize() + " set " + column + " = " +
+		exprPrefix + param + exprSuffix + " where " + where
+
+	allArgs := make([]any, len(args)+1)
+	copy(allArgs, args)
+	allArgs[len(args)] = value
+	return sql, allArgs, nil
+}
+
+// SetKeysStatement returns the statement and arguments used by SetKeys.
+func (c HstoreColumn) SetKeysStatement(values Hstore, where string, args ...any) (string, []any, error) {
+	if values == nil {
+		// hstore || NULL is NULL, which would clear the column
+		values = Hstore{}
+	}
+	column := pgx.Identifier{c.Column}.Sanitize()
+	return c.update("coalesce("+column+", ''::hstore) || ", "::hstore", where, args, values)
+}
+
+// SetKeys adds or replaces the keys in values in the rows matching where. A NULL column is
+// treated as an empty hstore. A nil values is treated as empty, and does not change the keys.
+func (c HstoreColumn) SetKeys(
+	ctx context.Context, conn *pgx.Conn, values Hstore, where string, args ...any,
+) (pgconn.CommandTag, error) {
+	sql, allArgs, err := c.SetKeysStatement(values, where, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return conn.Exec(ctx, sql, allArgs...)
+}
+
+// DeleteKeysStatement returns the statement and arguments used by DeleteKeys.
+func (c HstoreColumn) DeleteKeysStatement(keys []string, where string, args ...any) (string, []any, error) {
+	if keys == nil {
+		// delete(hstore, NULL) is NULL, which would clear the column
+		keys = []string{}
+	}
+	column := pgx.Identifier{c.Column}.Sanitize()
+	return c.update("delete("+column+", ", "::text[])", where, args, keys)
+}
+
+// DeleteKeys removes keys from the rows matching where. A NULL column remains NULL. A nil keys is
+// treated as empty, and does not change the column.
+func (c HstoreColumn) DeleteKeys(
+	ctx context.Context, conn *pgx.Conn, keys []string, where string, args ...any,
+) (pgconn.CommandTag, error) {
+	sql, allArgs, err := c.DeleteKeysStatement(keys, where, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return conn.Exec(ctx, sql, allArgs...)
+}
+
+// ReplaceStatement returns the statement and arguments used by Replace.
+func (c HstoreColumn) ReplaceStatement(value Hstore, where string, args ...any) (string, []any, error) {
+	return c.update("", "::hstore", where, args, value)
+}
+
+// Replace sets the column to value in the rows matching where. A nil value sets it to NULL.
+func (c HstoreColumn) Replace(
+	ctx context.Context, conn *pgx.Conn, value Hstore, where string, args ...any,
+) (pgconn.CommandTag, error) {
+	sql, allArgs, err := c.ReplaceStatement(value, where, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return conn.Exec(ctx, sql, allArgs...)
+}
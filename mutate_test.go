@@ -0,0 +1,134 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestHstoreColumnStatements(t *testing.T) {
+	c := pgxtypefaster.HstoreColumn{Table: pgx.Identifier{"public", "items"}, Column: "attrs"}
+	values := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}
+
+	sql, args, err := c.SetKeysStatement(values, "id = $1", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `update "public"."items" set "attrs" = coalesce("attrs", ''::hstore) || $2::hstore where id = $1`
+	if sql != expected {
+		t.Errorf("SetKeysStatement=%s; expected %s", sql, expected)
+	}
+	if !reflect.DeepEqual(args, []any{5, values}) {
+		t.Errorf("SetKeysStatement args=%#v", args)
+	}
+
+	sql, args, err = c.DeleteKeysStatement([]string{"a"}, "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `update "public"."items" set "attrs" = delete("attrs", $1::text[]) where true`
+	if sql != expected {
+		t.Errorf("DeleteKeysStatement=%s; expected %s", sql, expected)
+	}
+	if !reflect.DeepEqual(args, []any{[]string{"a"}}) {
+		t.Errorf("DeleteKeysStatement args=%#v", args)
+	}
+
+	sql, _, err = c.ReplaceStatement(values, "id = $1 and kind = $2", 1, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `update "public"."items" set "attrs" = $3::hstore where id = $1 and kind = $2`
+	if sql != expected {
+		t.Errorf("ReplaceStatement=%s; expected %s", sql, expected)
+	}
+
+	// nil must not be sent as NULL, which sets the column to NULL
+	_, args, err = c.SetKeysStatement(nil, "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []any{pgxtypefaster.Hstore{}}) {
+		t.Errorf("SetKeysStatement(nil) args=%#v", args)
+	}
+	_, args, err = c.DeleteKeysStatement(nil, "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, []any{[]string{}}) {
+		t.Errorf("DeleteKeysStatement(nil) args=%#v", args)
+	}
+
+	if _, _, err := c.ReplaceStatement(values, ""); err == nil {
+		t.Error("empty where expected error")
+	}
+}
+
+func TestHstoreColumnExec(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+	_, err := conn.Exec(ctx, `create table items (id int primary key, attrs hstore);
+		insert into items values (1, 'a=>1, b=>2'), (2, null)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := pgxtypefaster.HstoreColumn{Table: pgx.Identifier{"items"}, Column: "attrs"}
+	query := func(id int) pgxtypefaster.Hstore {
+		t.Helper()
+		h, err := pgxtypefaster.QueryHstore(ctx, conn, `select attrs from items where id = $1`, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	tag, err := c.SetKeys(ctx, conn, pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("3"), "c": {}}, "true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.RowsAffected() != 2 {
+		t.Errorf("SetKeys RowsAffected=%d", tag.RowsAffected())
+	}
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("3"), "c": {}}
+	if h := query(1); !reflect.DeepEqual(h, expected) {
+		t.Errorf("after SetKeys: %#v", h)
+	}
+	if h := query(2); !reflect.DeepEqual(h, pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("3"), "c": {}}) {
+		t.Errorf("after SetKeys on NULL: %#v", h)
+	}
+
+	if _, err := c.DeleteKeys(ctx, conn, []string{"a", "c"}, "id = $1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if h := query(1); !reflect.DeepEqual(h, pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("3")}) {
+		t.Errorf("after DeleteKeys: %#v", h)
+	}
+
+	// nil and empty inputs leave the column unchanged
+	for _, values := range []pgxtypefaster.Hstore{nil, {}} {
+		if _, err := c.SetKeys(ctx, conn, values, "id = $1", 1); err != nil {
+			t.Fatal(err)
+		}
+		if h := query(1); !reflect.DeepEqual(h, pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("3")}) {
+			t.Errorf("after SetKeys(%#v): %#v", values, h)
+		}
+	}
+	for _, keys := range [][]string{nil, {}} {
+		if _, err := c.DeleteKeys(ctx, conn, keys, "id = $1", 1); err != nil {
+			t.Fatal(err)
+		}
+		if h := query(1); !reflect.DeepEqual(h, pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("3")}) {
+			t.Errorf("after DeleteKeys(%#v): %#v", keys, h)
+		}
+	}
+
+	if _, err := c.Replace(ctx, conn, nil, "id = $1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if h := query(1); h != nil {
+		t.Errorf("after Replace(nil): %#v", h)
+	}
+}
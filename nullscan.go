@@ -0,0 +1,41 @@
+//go:build go1.22
+
+package pgxtypefaster
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// planScanNull returns a plan for *sql.Null[Hstore], or nil. pgx can scan sql.Null with its
+// sql.Scanner implementation, but that converts binary values to text and ignores the codec
+// options. **Hstore does not need a plan: pgx scans it with the plan for *Hstore.
+func (c HstoreCodec) planScanNull(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(*sql.Null[Hstore]); !ok {
+		return nil
+	}
+	next := c.PlanScan(m, oid, format, (*Hstore)(nil))
+	if next == nil {
+		return nil
+	}
+	return scanPlanNull[Hstore]{next}
+}
+
+// scanPlanNull scans into *sql.Null[T] by scanning non-NULL values into *T with next.
+type scanPlanNull[T any] struct {
+	next pgtype.ScanPlan
+}
+
+func (p scanPlanNull[T]) Scan(src []byte, dst any) error {
+	target := dst.(*sql.Null[T])
+	if src == nil {
+		*target = sql.Null[T]{}
+		return nil
+	}
+	if err := p.next.Scan(src, &target.V); err != nil {
+		return err
+	}
+	target.Valid = true
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !go1.22
+
+package pgxtypefaster
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+// planScanNull returns nil: sql.Null[T] requires Go 1.22.
+func (c HstoreCodec) planScanNull(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build go1.22
+
+package pgxtypefaster_test
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestScanNullHstore(t *testing.T) {
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+	m := newOptionsTypeMap()
+	for _, format := range formats {
+		for _, value := range []pgxtypefaster.Hstore{input, {}} {
+			buf, err := m.Encode(testHstoreOID, format, value, []byte{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var n sql.Null[pgxtypefaster.Hstore]
+			if err := m.Scan(testHstoreOID, format, buf, &n); err != nil {
+				t.Fatal(err)
+			}
+			if !n.Valid || !reflect.DeepEqual(n.V, value) {
+				t.Errorf("format=%d: sql.Null scanned %#v; expected %#v", format, n, value)
+			}
+			var p *pgxtypefaster.Hstore
+			if err := m.Scan(testHstoreOID, format, buf, &p); err != nil {
+				t.Fatal(err)
+			}
+			if p == nil || !reflect.DeepEqual(*p, value) {
+				t.Errorf("format=%d: **Hstore scanned %#v; expected %#v", format, p, value)
+			}
+
+			if err := m.Scan(testHstoreOID, format, nil, &n); err != nil {
+				t.Fatal(err)
+			}
+			if n.Valid || n.V != nil {
+				t.Errorf("format=%d: NULL must scan as invalid: %#v", format, n)
+			}
+			if err := m.Scan(testHstoreOID, format, nil, &p); err != nil || p != nil {
+				t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, p, err)
+			}
+		}
+	}
+
+	// the codec options are applied
+	m = newOptionsTypeMap(pgxtypefaster.WithNullPolicy(pgxtypefaster.NullError))
+	for _, format := range formats {
+		buf, err := newTestTypeMap().Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n sql.Null[pgxtypefaster.Hstore]
+		var nullErr *pgxtypefaster.NullValueError
+		if err := m.Scan(testHstoreOID, format, buf, &n); !errors.As(err, &nullErr) {
+			t.Errorf("format=%d: sql.Null must apply the NULL policy: %v", format, err)
+		}
+		var p *pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &p); !errors.As(err, &nullErr) {
+			t.Errorf("format=%d: **Hstore must apply the NULL policy: %v", format, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package pgxtypefaster
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OIDCache caches the OIDs of types for each database, so registering types on a new connection
+// does not query the catalog. A database is identified by the host, port, database name, user, and
+// search_path of the connection's configuration. The OIDs of extension types change if the
+// extension is dropped and created again, or the database is dropped and created again, which the
+// cache cannot detect: call Clear after doing that, or connections register the old OIDs. A nil
+// *OIDCache caches nothing. It is safe to use concurrently.
+type OIDCache struct {
+	mu   sync.Mutex
+	oids map[oidCacheKey]uint32
+}
+
+type oidCacheKey struct {
+	host       string
+	port       uint16
+	database   string
+	user       string
+	searchPath string
+	name       string
+}
+
+// DefaultOIDCache is used by the Register functions, such as RegisterHstore, RegisterHstoreCompat,
+// and RegisterTypes, and by HstoreTracer. It is nil, so OIDs are not cached. To cache them, set it
+// before connecting, for programs that open many short-lived connections:
+//
+//	pgxtypefaster.DefaultOIDCache = pgxtypefaster.NewOIDCache()
+var DefaultOIDCache *OIDCache
+
+// NewOIDCache returns an empty OIDCache.
+func NewOIDCache() *OIDCache {
+	return &OIDCache{oids: map[oidCacheKey]uint32{}}
+}
+
+func newOIDCacheKey(conn *pgx.Conn, name string) oidCacheKey {
+	config := conn.Config()
+	return oidCacheKey{config.Host, config.Port, config.Database, config.User,
+		config.RuntimeParams["search_path"], name}
+}
+
+// Lookup returns the OID of the type name for conn's database, if it is cached.
+func (c *OIDCache) Lookup(conn *pgx.Conn, name string) (uint32, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	oid, ok := c.oids[newOIDCacheKey(conn, name)]
+	return oid, ok
+}
+
+// Store caches the OID of the type name for conn's database.
+func (c *OIDCache) Store(conn *pgx.Conn, name string, oid uint32) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.oids[newOIDCacheKey(conn, name)] = oid
+}
+
+// Clear removes all cached OIDs.
+func (c *OIDCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.oids = map[oidCacheKey]uint32{}
+}
@@ -0,0 +1,120 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestOIDCacheNil(t *testing.T) {
+	// a nil cache caches nothing, so the Register functions can always use DefaultOIDCache
+	var cache *pgxtypefaster.OIDCache
+	cache.Store(nil, "hstore", 42)
+	if _, ok := cache.Lookup(nil, "hstore"); ok {
+		t.Error("nil cache must miss")
+	}
+	cache.Clear()
+	if pgxtypefaster.DefaultOIDCache != nil {
+		t.Error("DefaultOIDCache must be nil so caching is opt-in")
+	}
+}
+
+// enableDefaultOIDCache sets DefaultOIDCache to a new cache for the test.
+func enableDefaultOIDCache(t *testing.T) *pgxtypefaster.OIDCache {
+	cache := pgxtypefaster.NewOIDCache()
+	pgxtypefaster.DefaultOIDCache = cache
+	t.Cleanup(func() { pgxtypefaster.DefaultOIDCache = nil })
+	return cache
+}
+
+func TestOIDCache(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+	hstoreType, ok := conn.TypeMap().TypeForName("hstore")
+	if !ok {
+		t.Fatal("hstore not registered")
+	}
+
+	cache := pgxtypefaster.NewOIDCache()
+	if _, ok := cache.Lookup(conn, "hstore"); ok {
+		t.Error("new cache must be empty")
+	}
+	cache.Store(conn, "hstore", 42)
+	if oid, ok := cache.Lookup(conn, "hstore"); !ok || oid != 42 {
+		t.Errorf("Lookup after Store=%d, %t", oid, ok)
+	}
+	if _, ok := cache.Lookup(conn, "other"); ok {
+		t.Error("Lookup(other) must miss")
+	}
+	cache.Clear()
+	if _, ok := cache.Lookup(conn, "hstore"); ok {
+		t.Error("Lookup after Clear must miss")
+	}
+
+	// the Register functions use DefaultOIDCache if it is set
+	cache = enableDefaultOIDCache(t)
+	if err := pgxtypefaster.RegisterHstore(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	if oid, ok := cache.Lookup(conn, "hstore"); !ok || oid != hstoreType.OID {
+		t.Errorf("DefaultOIDCache.Lookup(hstore)=%d, %t; expected %d", oid, ok, hstoreType.OID)
+	}
+	cache.Clear()
+	if err := pgxtypefaster.RegisterTypes(ctx, conn, "hstore"); err != nil {
+		t.Fatal(err)
+	}
+	if oid, ok := cache.Lookup(conn, "hstore"); !ok || oid != hstoreType.OID {
+		t.Errorf("DefaultOIDCache.Lookup(hstore) after RegisterTypes=%d, %t", oid, ok)
+	}
+}
+
+func TestOIDCacheInvalidation(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+	cache := enableDefaultOIDCache(t)
+	oldOID, err := pgxtypefaster.RegisterHstoreOID(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// creating the extension again changes its OID
+	for _, sql := range []string{"drop extension hstore", "create extension hstore"} {
+		if _, err := conn.Exec(ctx, sql); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var newOID uint32
+	if err := conn.QueryRow(ctx, "select 'hstore'::regtype::oid").Scan(&newOID); err != nil {
+		t.Fatal(err)
+	}
+	if newOID == oldOID {
+		t.Fatalf("hstore OID %d did not change", newOID)
+	}
+
+	// the cache cannot detect it
+	oid, err := pgxtypefaster.RegisterHstoreOID(ctx, conn)
+	if err != nil || oid != oldOID {
+		t.Errorf("RegisterHstoreOID with a stale cache=%d, %v; expected the old OID %d", oid, err, oldOID)
+	}
+	cache.Clear()
+	oid, err = pgxtypefaster.RegisterHstoreOID(ctx, conn)
+	if err != nil || oid != newOID {
+		t.Errorf("RegisterHstoreOID after Clear=%d, %v; expected %d", oid, err, newOID)
+	}
+
+	// without the cache, each call queries the OID
+	pgxtypefaster.DefaultOIDCache = nil
+	for _, sql := range []string{"drop extension hstore", "create extension hstore"} {
+		if _, err := conn.Exec(ctx, sql); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := conn.QueryRow(ctx, "select 'hstore'::regtype::oid").Scan(&newOID); err != nil {
+		t.Fatal(err)
+	}
+	oid, err = pgxtypefaster.RegisterHstoreOID(ctx, conn)
+	if err != nil || oid != newOID {
+		t.Errorf("RegisterHstoreOID without a cache=%d, %v; expected %d", oid, err, newOID)
+	}
+}
@@ -0,0 +1,239 @@
+package pgxtypefaster
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CodecOption configures a codec created by NewHstoreCodec or NewHstoreCompatCodec.
+type CodecOption func(*codecOptions)
+
+// codecOptions is the result of applying CodecOptions: the configuration stored by the codec, and
+// the settings the constructors copy to the codec's exported fields, which are their only copy.
+type codecOptions struct {
+	codecConfig
+	decodeValueAs DecodeValueType
+}
+
+// BufferPool provides temporary buffers, such as sync.Pool storing *[]byte. Get may return nil.
+type BufferPool interface {
+	Get() *[]byte
+	Put(buf *[]byte)
+}
+
+// codecConfig is the configuration shared by all codecs. The codecs store a pointer to it, so they
+// remain comparable. A nil *codecConfig is the default configuration.
+type codecConfig struct {
+	// maxPairs is the maximum number of key/value pairs. Zero means no limit.
+	maxPairs int
+	// maxBytes is the maximum size of a scanned value. Zero means no limit.
+	maxBytes      int
+	nullPolicy    NullPolicy
+	hasNullPolicy bool
+	validate      func(key string, value pgtype.Text) error
+	bufferPool    BufferPool
+	interner      Interner
+	serverVersion ServerVersion
+	alloc         Allocator
+	encodeCache   *encodeCache
+	// textBytesPerPair estimates the number of pairs when parsing the text format. Zero means
+	// counting '>' characters.
+	textBytesPerPair float64
+	sortedKeys       bool
+	strictDuplicates bool
+	onDuplicate      func(key string)
+	separateStrings  bool
+	validateStrings  bool
+	lenientParsing   bool
+}
+
+func newCodecConfig(opts []CodecOption) *codecConfig {
+	cfg, _ := newCodecOptions(opts)
+	return cfg
+}
+
+// newCodecOptions applies opts, and returns the configuration and the DecodeValueAs setting.
+func newCodecOptions(opts []CodecOption) (*codecConfig, DecodeValueType) {
+	if len(opts) == 0 {
+		return nil, DecodeValueDefault
+	}
+	o := &codecOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &o.codecConfig, o.decodeValueAs
+}
+
+// WithDecodeValueAs sets the codec's DecodeValueAs field, which selects the type returned by its
+// DecodeValue method.
+func WithDecodeValueAs(t DecodeValueType) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.decodeValueAs = t
+	}
+}
+
+// WithMaxPairs returns an error when encoding or scanning an hstore with more than n key/value
+// pairs. For the binary format, the count is checked before parsing.
+func WithMaxPairs(n int) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.maxPairs = n
+	}
+}
+
+// WithMaxBytes returns an error when scanning an hstore larger than n bytes in the wire format,
+// before it is parsed. This protects against giant allocations from untrusted or corrupted data.
+func WithMaxBytes(n int) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.maxBytes = n
+	}
+}
+
+// LimitError is returned when an hstore exceeds a limit set by WithMaxPairs or WithMaxBytes.
+type LimitError struct {
+	// Limit is "pairs" or "bytes".
+	Limit  string
+	Max    int
+	Actual int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("hstore has %d %s; exceeds maximum %d", e.Actual, e.Limit, e.Max)
+}
+
+// WithNullPolicy applies policy to NULL values when encoding and scanning. NullSkip removes keys
+// with NULL values, NullEmpty replaces them with the empty string, and NullError returns a
+// *NullValueError. By default NULL values are preserved.
+func WithNullPolicy(policy NullPolicy) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.nullPolicy = policy
+		cfg.hasNullPolicy = true
+	}
+}
+
+// WithValidation calls validate for each key/value pair when encoding and scanning, after the
+// NULL policy is applied. An error from validate is returned from Encode or Scan.
+func WithValidation(validate func(key string, value pgtype.Text) error) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.validate = validate
+	}
+}
+
+// WithBufferPool uses pool for the temporary buffer used to convert binary values to text in
+// DecodeDatabaseSQLValue, which is used by database/sql.
+func WithBufferPool(pool BufferPool) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.bufferPool = pool
+	}
+}
+
+// WithInternKeys interns the keys of scanned hstores, so equal keys from different rows share
+// memory. This reduces memory use for applications that retain many values with the same keys, at
+// the cost of slower scans. It uses the unique package with Go 1.23 and later. With older
+// versions interned keys are never freed, so it should only be used when the set of keys is
+// bounded. Enum types generated by fastertypegen do not need this, since they return constants.
+// See WithInterner to use a separate table of keys.
+func WithInternKeys() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.interner = processInterner{}
+	}
+}
+
+// WithServerVersion configures the codec for the server version v. Servers before 9.0 do not
+// support the binary format for hstore, so the codec only supports the text format.
+// RegisterHstore and RegisterHstoreCompat set this automatically.
+func WithServerVersion(v ServerVersion) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.serverVersion = v
+	}
+}
+
+// WithAllocator allocates scanned values with alloc instead of the heap.
+func WithAllocator(alloc Allocator) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.alloc = alloc
+	}
+}
+
+// NewHstoreCodec returns an HstoreCodec configured with opts.
+func NewHstoreCodec(opts ...CodecOption) HstoreCodec {
+	cfg, decodeValueAs := newCodecOptions(opts)
+	return HstoreCodec{DecodeValueAs: decodeValueAs, cfg: cfg}
+}
+
+// NewHstoreCompatCodec returns an HstoreCompatCodec configured with opts.
+func NewHstoreCompatCodec(opts ...CodecOption) HstoreCompatCodec {
+	cfg, decodeValueAs := newCodecOptions(opts)
+	return HstoreCompatCodec{DecodeValueAs: decodeValueAs, cfg: cfg}
+}
+
+// transformsPairs returns true if encoding or scanning must check or change the key/value pairs.
+func (cfg *codecConfig) transformsPairs() bool {
+	return cfg != nil && (cfg.maxPairs > 0 || cfg.maxBytes > 0 || cfg.hasNullPolicy || cfg.validate != nil || cfg.interner != nil)
+}
+
+// scansWithHstorePlan returns true if scan plans that parse directly must instead scan an Hstore
+// with the codec's plan, to apply the configuration.
+func (cfg *codecConfig) scansWithHstorePlan() bool {
+	return cfg.transformsPairs() || cfg.checksDuplicates() || cfg.parsesLeniently()
+}
+
+// formatSupported returns true if the codec supports format with the configured server version.
+func (cfg *codecConfig) formatSupported(format int16) bool {
+	if format == pgtype.BinaryFormatCode {
+		return cfg == nil || cfg.serverVersion.Supports(CapabilityHstoreBinary)
+	}
+	return format == pgtype.TextFormatCode
+}
+
+func (cfg *codecConfig) preferredFormat() int16 {
+	if cfg.formatSupported(pgtype.BinaryFormatCode) {
+		return pgtype.BinaryFormatCode
+	}
+	return pgtype.TextFormatCode
+}
+
+func (cfg *codecConfig) cache() *encodeCache {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.encodeCache
+}
+
+func (cfg *codecConfig) allocator() Allocator {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.alloc
+}
+
+func (cfg *codecConfig) pool() BufferPool {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.bufferPool
+}
+
+func (cfg *codecConfig) checkPairCount(count int) error {
+	if cfg.maxPairs > 0 && count > cfg.maxPairs {
+		return &LimitError{"pairs", cfg.maxPairs, count}
+	}
+	return nil
+}
+
+// checkScanLimits checks the size of src, and the pair count in the binary format, before it is
+// parsed.
+func (cfg *codecConfig) checkScanLimits(format int16, src []byte) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.maxBytes > 0 && len(src) > cfg.maxBytes {
+		return &LimitError{"bytes", cfg.maxBytes, len(src)}
+	}
+	const uint32Len = 4
+	if format != pgtype.BinaryFormatCode || len(src) < uint32Len {
+		return nil
+	}
+	return cfg.checkPairCount(int(int32(binary.BigEndian.Uint32(src))))
+}
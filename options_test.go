@@ -0,0 +1,300 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// newOptionsTypeMap returns a type map with both codecs configured with opts.
+func newOptionsTypeMap(opts ...pgxtypefaster.CodecOption) *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.NewHstoreCodec(opts...), Name: "hstore", OID: testHstoreOID})
+	m.RegisterType(&pgtype.Type{
+		Codec: pgxtypefaster.NewHstoreCompatCodec(opts...), Name: "hstore_compat", OID: testHstoreOID + 1})
+	return m
+}
+
+var formats = []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode}
+
+func TestNewCodecDefaults(t *testing.T) {
+	if pgxtypefaster.NewHstoreCodec() != (pgxtypefaster.HstoreCodec{}) {
+		t.Error("NewHstoreCodec() must equal the zero value")
+	}
+	if pgxtypefaster.NewHstoreCompatCodec() != (pgxtypefaster.HstoreCompatCodec{}) {
+		t.Error("NewHstoreCompatCodec() must equal the zero value")
+	}
+	c := pgxtypefaster.NewHstoreCodec(pgxtypefaster.WithDecodeValueAs(pgxtypefaster.DecodeValuePointerMap))
+	if c.DecodeValueAs != pgxtypefaster.DecodeValuePointerMap {
+		t.Errorf("DecodeValueAs=%s", c.DecodeValueAs)
+	}
+	// the field is the only copy of the setting, so changing it takes effect
+	c.DecodeValueAs = pgxtypefaster.DecodeValueStringMap
+	v, err := c.DecodeValue(nil, testHstoreOID, pgtype.TextFormatCode, []byte(`"a"=>"b"`))
+	if _, ok := v.(map[string]string); !ok || err != nil {
+		t.Errorf("DecodeValue after changing DecodeValueAs=%#v, %v", v, err)
+	}
+}
+
+func TestWithMaxPairs(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithMaxPairs(1))
+	unlimited := newOptionsTypeMap()
+	one := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1")}
+	two := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2")}
+
+	for _, format := range formats {
+		if _, err := m.Encode(testHstoreOID, format, one, nil); err != nil {
+			t.Errorf("format=%d: Encode one pair failed: %s", format, err)
+		}
+		if _, err := m.Encode(testHstoreOID, format, two, nil); err == nil {
+			t.Errorf("format=%d: Encode two pairs expected error", format)
+		}
+
+		buf, err := unlimited.Encode(testHstoreOID, format, two, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &h); err == nil || !strings.Contains(err.Error(), "maximum") {
+			t.Errorf("format=%d: Scan expected maximum error; err=%v", format, err)
+		}
+		var compat pgxtypefaster.HstoreCompat
+		if err := m.Scan(testHstoreOID+1, format, buf, &compat); err == nil {
+			t.Errorf("format=%d: Scan HstoreCompat expected error", format)
+		}
+	}
+}
+
+func TestWithMaxBytes(t *testing.T) {
+	const maxBytes = 20
+	m := newOptionsTypeMap(pgxtypefaster.WithMaxBytes(maxBytes))
+	small := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1")}
+	large := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText(strings.Repeat("x", maxBytes))}
+
+	for _, format := range formats {
+		for i, oid := range []uint32{testHstoreOID, testHstoreOID + 1} {
+			targets := []any{&pgxtypefaster.Hstore{}, &pgxtypefaster.HstoreCompat{}}
+			buf, err := m.Encode(testHstoreOID, format, small, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Scan(oid, format, buf, targets[i]); err != nil {
+				t.Errorf("format=%d oid=%d: small value failed: %s", format, oid, err)
+			}
+
+			buf, err = m.Encode(testHstoreOID, format, large, nil)
+			if err != nil {
+				t.Fatalf("format=%d: encoding is not limited: %s", format, err)
+			}
+			err = m.Scan(oid, format, buf, targets[i])
+			var limitErr *pgxtypefaster.LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != "bytes" || limitErr.Max != maxBytes || limitErr.Actual != len(buf) {
+				t.Errorf("format=%d oid=%d: expected bytes LimitError; err=%v", format, oid, err)
+			}
+		}
+	}
+
+	// the pair limit returns the same error type
+	two := pgxtypefaster.NewHstoreFromPairs("a", "1", "b", "2")
+	buf, err := newOptionsTypeMap().Encode(testHstoreOID, pgtype.BinaryFormatCode, two, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h pgxtypefaster.Hstore
+	err = newOptionsTypeMap(pgxtypefaster.WithMaxPairs(1)).Scan(testHstoreOID, pgtype.BinaryFormatCode, buf, &h)
+	var limitErr *pgxtypefaster.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "pairs" || limitErr.Actual != 2 {
+		t.Errorf("expected pairs LimitError; err=%v", err)
+	}
+}
+
+func TestWithNullPolicy(t *testing.T) {
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": {}}
+	unlimited := newOptionsTypeMap()
+
+	tests := []struct {
+		policy   pgxtypefaster.NullPolicy
+		expected pgxtypefaster.Hstore
+	}{
+		{pgxtypefaster.NullSkip, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1")}},
+		{pgxtypefaster.NullEmpty, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": pgxtypefaster.NewText("")}},
+		{pgxtypefaster.NullError, nil},
+	}
+	for _, test := range tests {
+		m := newOptionsTypeMap(pgxtypefaster.WithNullPolicy(test.policy))
+		for _, format := range formats {
+			// encoding applies the policy without changing the input
+			buf, err := m.Encode(testHstoreOID, format, input, nil)
+			if test.expected == nil {
+				var nullErr *pgxtypefaster.NullValueError
+				if !errors.As(err, &nullErr) || nullErr.Key != "null" {
+					t.Errorf("%s format=%d: Encode expected NullValueError; err=%v", test.policy, format, err)
+				}
+			} else {
+				var output pgxtypefaster.Hstore
+				if err := unlimited.Scan(testHstoreOID, format, buf, &output); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(output, test.expected) {
+					t.Errorf("%s format=%d: encoded=%#v; expected %#v", test.policy, format, output, test.expected)
+				}
+			}
+			if len(input) != 2 || input["null"].Valid {
+				t.Fatalf("%s: Encode modified the input: %#v", test.policy, input)
+			}
+
+			buf, err = unlimited.Encode(testHstoreOID, format, input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output pgxtypefaster.Hstore
+			err = m.Scan(testHstoreOID, format, buf, &output)
+			if test.expected == nil {
+				if err == nil {
+					t.Errorf("%s format=%d: Scan expected error", test.policy, format)
+				}
+			} else if err != nil || !reflect.DeepEqual(output, test.expected) {
+				t.Errorf("%s format=%d: Scan=%#v, %v; expected %#v", test.policy, format, output, err, test.expected)
+			}
+
+			var compat pgxtypefaster.HstoreCompat
+			err = m.Scan(testHstoreOID+1, format, buf, &compat)
+			if test.expected == nil {
+				if err == nil {
+					t.Errorf("%s format=%d: Scan HstoreCompat expected error", test.policy, format)
+				}
+			} else if err != nil || !reflect.DeepEqual(pgxtypefaster.PGXToFasterHstore(compat), test.expected) {
+				t.Errorf("%s format=%d: Scan HstoreCompat=%#v, %v", test.policy, format, compat, err)
+			}
+
+			// derived types use the same options
+			var derived derivedHstore
+			err = m.Scan(testHstoreOID, format, buf, &derived)
+			if test.expected != nil && (err != nil || !reflect.DeepEqual(pgxtypefaster.Hstore(derived), test.expected)) {
+				t.Errorf("%s format=%d: Scan derived=%#v, %v", test.policy, format, derived, err)
+			}
+		}
+	}
+}
+
+func TestWithValidation(t *testing.T) {
+	errUpper := errors.New("keys must be lower case")
+	m := newOptionsTypeMap(pgxtypefaster.WithValidation(func(key string, value pgtype.Text) error {
+		if strings.ToLower(key) != key {
+			return errUpper
+		}
+		return nil
+	}))
+	unlimited := newOptionsTypeMap()
+	valid := pgxtypefaster.Hstore{"key": pgxtypefaster.NewText("Value")}
+	invalid := pgxtypefaster.Hstore{"Key": pgxtypefaster.NewText("value")}
+
+	for _, format := range formats {
+		if _, err := m.Encode(testHstoreOID, format, valid, nil); err != nil {
+			t.Errorf("format=%d: Encode failed: %s", format, err)
+		}
+		if _, err := m.Encode(testHstoreOID, format, invalid, nil); !errors.Is(err, errUpper) {
+			t.Errorf("format=%d: Encode err=%v; expected %v", format, err, errUpper)
+		}
+		if _, err := m.Encode(testHstoreOID+1, format, pgxtypefaster.HstoreCompat{"Key": nil}, nil); !errors.Is(err, errUpper) {
+			t.Errorf("format=%d: Encode HstoreCompat err=%v; expected %v", format, err, errUpper)
+		}
+
+		buf, err := unlimited.Encode(testHstoreOID, format, invalid, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &h); !errors.Is(err, errUpper) {
+			t.Errorf("format=%d: Scan err=%v; expected %v", format, err, errUpper)
+		}
+	}
+}
+
+// countingPool is a BufferPool that counts calls to Put.
+type countingPool struct {
+	pool sync.Pool
+	puts int
+}
+
+func (p *countingPool) Get() *[]byte {
+	buf, _ := p.pool.Get().(*[]byte)
+	return buf
+}
+
+func (p *countingPool) Put(buf *[]byte) {
+	p.puts++
+	p.pool.Put(buf)
+}
+
+func TestWithBufferPool(t *testing.T) {
+	pool := &countingPool{}
+	codec := pgxtypefaster.NewHstoreCodec(pgxtypefaster.WithBufferPool(pool))
+	m := newOptionsTypeMap(pgxtypefaster.WithBufferPool(pool))
+	h := pgxtypefaster.Hstore{"k": pgxtypefaster.NewText("v")}
+	buf, err := m.Encode(testHstoreOID, pgtype.BinaryFormatCode, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		value, err := codec.DecodeDatabaseSQLValue(m, testHstoreOID, pgtype.BinaryFormatCode, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != `"k"=>"v"` {
+			t.Errorf("DecodeDatabaseSQLValue=%#v", value)
+		}
+	}
+	if pool.puts != 2 {
+		t.Errorf("puts=%d; expected 2", pool.puts)
+	}
+}
+
+func TestWithInternKeys(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithInternKeys())
+	h := pgxtypefaster.Hstore{"interned_key": pgxtypefaster.NewText("v"), "other": {}}
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keyData []*byte
+		for i := 0; i < 2; i++ {
+			var output pgxtypefaster.Hstore
+			if err := m.Scan(testHstoreOID, format, buf, &output); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(output, h) {
+				t.Fatalf("format=%d: output=%#v; expected %#v", format, output, h)
+			}
+			for k := range output {
+				if k == "interned_key" {
+					keyData = append(keyData, unsafe.StringData(k))
+				}
+			}
+
+			var compat pgxtypefaster.HstoreCompat
+			if err := m.Scan(testHstoreOID+1, format, buf, &compat); err != nil {
+				t.Fatal(err)
+			}
+			for k := range compat {
+				if k == "interned_key" {
+					keyData = append(keyData, unsafe.StringData(k))
+				}
+			}
+		}
+		for _, data := range keyData[1:] {
+			if data != keyData[0] {
+				t.Errorf("format=%d: scanned keys do not share memory", format)
+			}
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package pgxtypefaster
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type OrderedHstoreScanner interface {
+	ScanOrderedHstore(v OrderedHstore) error
+}
+
+type OrderedHstoreValuer interface {
+	OrderedHstoreValue() (OrderedHstore, error)
+}
+
+// HstorePair is a key/value pair of an hstore.
+type HstorePair struct {
+	Key   string
+	Value pgtype.Text
+}
+
+// OrderedHstore represents an hstore column as a slice of pairs, which preserves the order of the
+// keys: scanning returns the pairs in the order Postgres sent them, and encoding sends them in
+// slice order. This makes output deterministic, for diffs and golden file tests. A nil
+// OrderedHstore is NULL. Keys should be unique: Postgres keeps one of the duplicate keys.
+type OrderedHstore []HstorePair
+
+func (h *OrderedHstore) ScanOrderedHstore(v OrderedHstore) error {
+	*h = v
+	return nil
+}
+
+func (h OrderedHstore) OrderedHstoreValue() (OrderedHstore, error) {
+	return h, nil
+}
+
+// Hstore returns the pairs as an Hstore. If keys are duplicated, the last value is used.
+func (h OrderedHstore) Hstore() Hstore {
+	if h == nil {
+		return nil
+	}
+	out := make(Hstore, len(h))
+	for _, pair := range h {
+		out[pair.Key] = pair.Value
+	}
+	return out
+}
+
+// Ensure OrderedHstore works with database/sql and libraries built on it, such as sqlx.
+var _ sql.Scanner = (*OrderedHstore)(nil)
+var _ driver.Valuer = OrderedHstore(nil)
+
+// Scan implements the database/sql Scanner interface. It accepts the text format as a string, a
+// []byte, or a fmt.Stringer, like Hstore.Scan.
+func (h *OrderedHstore) Scan(src any) error {
+	if src == nil {
+		*h = nil
+		return nil
+	}
+
+	switch src := src.(type) {
+	case string:
+		return scanPlanTextAnyToOrderedHstoreScanner{}.scanString(src, h)
+	case []byte:
+		return scanPlanTextAnyToOrderedHstoreScanner{}.scanString(string(src), h)
+	case fmt.Stringer:
+		return scanPlanTextAnyToOrderedHstoreScanner{}.scanString(src.String(), h)
+	}
+
+	return fmt.Errorf("cannot scan %T", src)
+}
+
+// Value implements the database/sql/driver Valuer interface.
+func (h OrderedHstore) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	buf, err := encodePlanOrderedHstoreCodecText{}.Encode(h, nil)
+	if err != nil {
+		return nil, err
+	}
+	// buf was allocated by Encode and is not used again
+	return ownedBytesToString(buf), nil
+}
+
+// OrderedHstoreCodec is the codec for OrderedHstore. It can be registered for hstore in place of
+// HstoreCodec, when the key order matters more than lookups.
+type OrderedHstoreCodec struct{}
+
+func (OrderedHstoreCodec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func (OrderedHstoreCodec) PreferredFormat() int16 {
+	return pgtype.BinaryFormatCode
+}
+
+func (c OrderedHstoreCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if isValuerPointer(value, orderedHstoreValuerType) {
+		return planDerefPointer(c, m, oid, format, value)
+	}
+	if _, ok := value.(OrderedHstoreValuer); !ok {
+		return nil
+	}
+
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return encodePlanOrderedHstoreCodecBinary{}
+	case pgtype.TextFormatCode:
+		return encodePlanOrderedHstoreCodecText{}
+	}
+	return nil
+}
+
+type encodePlanOrderedHstoreCodecBinary struct{}
+
+func (encodePlanOrderedHstoreCodecBinary) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	hstore, err := value.(OrderedHstoreValuer).OrderedHstoreValue()
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return appendPairsBinary(buf, hstore), nil
+}
+
+// appendPairsBinary appends pairs in the binary format.
+func appendPairsBinary(buf []byte, pairs []HstorePair) []byte {
+	buf = pgio.AppendInt32(buf, int32(len(pairs)))
+	for _, pair := range pairs {
+		buf = pgio.AppendLengthPrefixedString(buf, pair.Key)
+		if pair.Value.Valid {
+			buf = pgio.AppendLengthPrefixedString(buf, pair.Value.String)
+		} else {
+			buf = pgio.AppendInt32(buf, -1)
+		}
+	}
+	return buf
+}
+
+type encodePlanOrderedHstoreCodecText struct{}
+
+func (encodePlanOrderedHstoreCodecText) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	hstore, err := value.(OrderedHstoreValuer).OrderedHstoreValue()
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return appendPairsText(buf, hstore), nil
+}
+
+// appendPairsText appends pairs in the text format, quoted like Postgres does.
+func appendPairsText(buf []byte, pairs []HstorePair) []byte {
+	for i, pair := range pairs {
+		if i > 0 {
+			buf = append(buf, ',', ' ')
+		}
+		buf = appendPairText(buf, pair.Key, pair.Value)
+	}
+	return buf
+}
+
+// appendPairText appends one pair in the text format, without a separator.
+func appendPairText(buf []byte, key string, value pgtype.Text) []byte {
+	buf = append(buf, '"')
+	buf = append(buf, quoteArrayReplacer.Replace(key)...)
+	buf = append(buf, `"=>`...)
+	if value.Valid {
+		buf = append(buf, '"')
+		buf = append(buf, quoteArrayReplacer.Replace(value.String)...)
+		buf = append(buf, '"')
+	} else {
+		buf = append(buf, "NULL"...)
+	}
+	return buf
+}
+
+func (OrderedHstoreCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(OrderedHstoreScanner); !ok {
+		return nil
+	}
+
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return scanPlanBinaryHstoreToOrderedHstoreScanner{}
+	case pgtype.TextFormatCode:
+		return scanPlanTextAnyToOrderedHstoreScanner{}
+	}
+	return nil
+}
+
+type scanPlanBinaryHstoreToOrderedHstoreScanner struct{}
+
+func (scanPlanBinaryHstoreToOrderedHstoreScanner) Scan(src []byte, dst any) error {
+	scanner := (dst).(OrderedHstoreScanner)
+	if src == nil {
+		return scanner.ScanOrderedHstore(OrderedHstore(nil))
+	}
+	pairs, err := parsePairsBinary(src)
+	if err != nil {
+		return err
+	}
+	return scanner.ScanOrderedHstore(pairs)
+}
+
+// parsePairsBinary parses src in the binary format, in order.
+func parsePairsBinary(src []byte) ([]HstorePair, error) {
+	pairs := make([]HstorePair, 0, estimatePairCount(pgtype.BinaryFormatCode, src))
+	err := parsePairsBinaryFunc(src, func(key string, value pgtype.Text) error {
+		pairs = append(pairs, HstorePair{key, value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// parsePairsBinaryFunc calls fn with each pair of src in the binary format, in order. The strings
+// share a single allocation. It returns the first error returned by fn.
+func parsePairsBinaryFunc(src []byte, fn func(key string, value pgtype.Text) error) error {
+	var r pgio.Reader
+	r.Reset(src)
+	pairCount := r.ReadCount(minBinaryPairLen)
+	if r.Err() != nil {
+		return fmt.Errorf("hstore incomplete: %w", r.Err())
+	}
+
+	// one shared string for all key/value strings
+	base := r.Pos()
+	keyValueString := string(r.RemainingBytes())
+
+	for i := 0; i < pairCount; i++ {
+		keyStart, keyLen := r.ReadLengthPrefixedRange()
+		valueStart, valueLen := r.ReadLengthPrefixedRange()
+		if r.Err() != nil {
+			return fmt.Errorf("hstore incomplete: %w", r.Err())
+		}
+		if keyLen < 0 {
+			return errBinaryNullKey
+		}
+		var value pgtype.Text
+		if valueLen >= 0 {
+			value = NewText(keyValueString[valueStart-base : valueStart-base+valueLen])
+		}
+		if err := fn(keyValueString[keyStart-base:keyStart-base+keyLen], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// estimatePairCount returns an estimate of the number of pairs in src, to size the result. For
+// the binary format it is the pair count, limited by the length of src.
+func estimatePairCount(format int16, src []byte) int {
+	if format == pgtype.BinaryFormatCode {
+		var r pgio.Reader
+		r.Reset(src)
+		return initialPairs(r.ReadCount(minBinaryPairLen))
+	}
+	// see codecConfig.estimatePairs
+	estimate := bytes.Count(src, []byte{'>'})
+	if maxPairs := maxTextPairs(len(src)); estimate > maxPairs {
+		return maxPairs
+	}
+	return estimate
+}
+
+// forEachPair calls fn with each pair of src in format, in order.
+func forEachPair(format int16, src []byte, fn func(key string, value pgtype.Text) error) error {
+	if format == pgtype.BinaryFormatCode {
+		return parsePairsBinaryFunc(src, fn)
+	}
+	return parsePairsTextFunc(string(src), fn)
+}
+
+type scanPlanTextAnyToOrderedHstoreScanner struct{}
+
+func (s scanPlanTextAnyToOrderedHstoreScanner) Scan(src []byte, dst any) error {
+	scanner := (dst).(OrderedHstoreScanner)
+	if src == nil {
+		return scanner.ScanOrderedHstore(OrderedHstore(nil))
+	}
+	return s.scanString(string(src), scanner)
+}
+
+// scanString does not return nil hstore values because string cannot be nil.
+func (scanPlanTextAnyToOrderedHstoreScanner) scanString(src string, scanner OrderedHstoreScanner) error {
+	pairs, err := parsePairsText(src)
+	if err != nil {
+		return err
+	}
+	return scanner.ScanOrderedHstore(pairs)
+}
+
+// parsePairsText parses s in the text format, in order. It never returns nil.
+func parsePairsText(s string) ([]HstorePair, error) {
+	// over-estimates the number of pairs; see codecConfig.estimatePairs
+	pairs := make([]HstorePair, 0, (*codecConfig)(nil).estimatePairs(s))
+	err := parsePairsTextFunc(s, func(key string, value pgtype.Text) error {
+		pairs = append(pairs, HstorePair{key, value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// parsePairsTextFunc calls fn with each pair of s in the text format, in order. It returns the
+// first error returned by fn.
+func parsePairsTextFunc(s string, fn func(key string, value pgtype.Text) error) error {
+	p := newHSP(s)
+	first := true
+	for !p.AtEnd() {
+		if !first {
+			if err := p.consumePairSeparator(); err != nil {
+				return err
+			}
+		}
+		first = false
+		if err := p.ConsumeExpectedByte('"'); err != nil {
+			return err
+		}
+		key, err := p.ConsumeDoubleQuoted()
+		if err != nil {
+			return err
+		}
+		if err := p.consumeKVSeparator(); err != nil {
+			return err
+		}
+		value, err := p.consumeDoubleQuotedOrNull()
+		if err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c OrderedHstoreCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return codecDecodeToTextFormat(c, m, oid, format, src, nil)
+}
+
+func (c OrderedHstoreCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	if src == nil {
+		return nil, nil
+	}
+	var hstore OrderedHstore
+	err := codecScan(c, m, oid, format, src, &hstore)
+	if err != nil {
+		return nil, err
+	}
+	return hstore, nil
+}
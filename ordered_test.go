@@ -0,0 +1,88 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func newOrderedTypeMap() *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.OrderedHstoreCodec{}, Name: "hstore", OID: testHstoreOID})
+	return m
+}
+
+func TestOrderedHstoreRoundTrip(t *testing.T) {
+	m := newOrderedTypeMap()
+	inputs := []pgxtypefaster.OrderedHstore{
+		{},
+		{{Key: "z", Value: pgxtypefaster.NewText("1")}, {Key: "a", Value: pgtype.Text{}}},
+		{{Key: `"quoted\"`, Value: pgxtypefaster.NewText(`back\slash`)}, {Key: "", Value: pgxtypefaster.NewText("")}},
+	}
+	for _, format := range formats {
+		for _, input := range inputs {
+			// pgx encodes into a non-nil buffer, so an empty text hstore is not NULL
+			buf, err := m.Encode(testHstoreOID, format, input, []byte{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out pgxtypefaster.OrderedHstore
+			if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, input) {
+				t.Errorf("format=%d: got %#v; expected %#v", format, out, input)
+			}
+
+			// the encoding is compatible with HstoreCodec
+			var h pgxtypefaster.Hstore
+			if err := newTestTypeMap().Scan(testHstoreOID, format, buf, &h); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(h, input.Hstore()) {
+				t.Errorf("format=%d: HstoreCodec scanned %#v; expected %#v", format, h, input.Hstore())
+			}
+		}
+
+		buf, err := m.Encode(testHstoreOID, format, pgxtypefaster.OrderedHstore(nil), nil)
+		if err != nil || buf != nil {
+			t.Errorf("format=%d: nil must encode as NULL: %#v %v", format, buf, err)
+		}
+		out := pgxtypefaster.OrderedHstore{}
+		if err := m.Scan(testHstoreOID, format, nil, &out); err != nil || out != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, out, err)
+		}
+	}
+}
+
+func TestOrderedHstoreText(t *testing.T) {
+	h := pgxtypefaster.OrderedHstore{{Key: "b", Value: pgxtypefaster.NewText("2")}, {Key: "a", Value: pgtype.Text{}}}
+	value, err := h.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != `"b"=>"2", "a"=>NULL` {
+		t.Errorf("Value()=%#v", value)
+	}
+	var out pgxtypefaster.OrderedHstore
+	if err := out.Scan([]byte(value.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, h) {
+		t.Errorf("Scan=%#v; expected %#v", out, h)
+	}
+
+	decoded, err := pgxtypefaster.OrderedHstoreCodec{}.DecodeValue(nil, testHstoreOID, pgtype.TextFormatCode, []byte(`"x"=>"y"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, pgxtypefaster.OrderedHstore{{Key: "x", Value: pgxtypefaster.NewText("y")}}) {
+		t.Errorf("DecodeValue=%#v", decoded)
+	}
+
+	if err := out.Scan(`"a"=>`); err == nil {
+		t.Error("invalid input expected error")
+	}
+}
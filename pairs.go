@@ -0,0 +1,206 @@
+package pgxtypefaster
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type HstorePairsScanner interface {
+	ScanHstorePairs(v HstorePairs) error
+}
+
+type HstorePairsValuer interface {
+	HstorePairsValue() (HstorePairs, error)
+}
+
+// HstorePairs represents an hstore column as a flat slice of pairs, with linear lookups. For
+// hstores with only a few pairs, this is faster to scan than Hstore, since it does not allocate a
+// map. Lookups are O(n), so use Hstore for large values. A nil HstorePairs is NULL.
+type HstorePairs []HstorePair
+
+func (h *HstorePairs) ScanHstorePairs(v HstorePairs) error {
+	*h = v
+	return nil
+}
+
+func (h HstorePairs) HstorePairsValue() (HstorePairs, error) {
+	return h, nil
+}
+
+// Get returns the value of key, and true if key exists.
+func (h HstorePairs) Get(key string) (pgtype.Text, bool) {
+	for _, pair := range h {
+		if pair.Key == key {
+			return pair.Value, true
+		}
+	}
+	return pgtype.Text{}, false
+}
+
+// Set sets the value of key, replacing the existing value or appending a new pair.
+func (h *HstorePairs) Set(key string, value pgtype.Text) {
+	for i := range *h {
+		if (*h)[i].Key == key {
+			(*h)[i].Value = value
+			return
+		}
+	}
+	*h = append(*h, HstorePair{key, value})
+}
+
+// Hstore returns the pairs as an Hstore. If keys are duplicated, the last value is used.
+func (h HstorePairs) Hstore() Hstore {
+	return OrderedHstore(h).Hstore()
+}
+
+// Ensure HstorePairs works with database/sql and libraries built on it, such as sqlx.
+var _ sql.Scanner = (*HstorePairs)(nil)
+var _ driver.Valuer = HstorePairs(nil)
+
+// Scan implements the database/sql Scanner interface. It accepts the text format as a string or
+// a []byte.
+func (h *HstorePairs) Scan(src any) error {
+	if src == nil {
+		*h = nil
+		return nil
+	}
+
+	switch src := src.(type) {
+	case string:
+		return scanPlanTextAnyToHstorePairsScanner{}.scanString(src, h)
+	case []byte:
+		return scanPlanTextAnyToHstorePairsScanner{}.scanString(string(src), h)
+	}
+
+	return fmt.Errorf("cannot scan %T", src)
+}
+
+// Value implements the database/sql/driver Valuer interface.
+func (h HstorePairs) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	// buf was allocated here and is not used again
+	return ownedBytesToString(appendPairsText(nil, h)), nil
+}
+
+// HstorePairsCodec is the codec for HstorePairs. It can be registered for hstore in place of
+// HstoreCodec.
+type HstorePairsCodec struct{}
+
+func (HstorePairsCodec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+func (HstorePairsCodec) PreferredFormat() int16 {
+	return pgtype.BinaryFormatCode
+}
+
+func (c HstorePairsCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	if isValuerPointer(value, hstorePairsValuerType) {
+		return planDerefPointer(c, m, oid, format, value)
+	}
+	if _, ok := value.(HstorePairsValuer); !ok {
+		return nil
+	}
+
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return encodePlanHstorePairsCodecBinary{}
+	case pgtype.TextFormatCode:
+		return encodePlanHstorePairsCodecText{}
+	}
+	return nil
+}
+
+type encodePlanHstorePairsCodecBinary struct{}
+
+func (encodePlanHstorePairsCodecBinary) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	hstore, err := value.(HstorePairsValuer).HstorePairsValue()
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return appendPairsBinary(buf, hstore), nil
+}
+
+type encodePlanHstorePairsCodecText struct{}
+
+func (encodePlanHstorePairsCodecText) Encode(value any, buf []byte) (newBuf []byte, err error) {
+	hstore, err := value.(HstorePairsValuer).HstorePairsValue()
+	if err != nil {
+		return nil, err
+	}
+	if hstore == nil {
+		return nil, nil
+	}
+	return appendPairsText(buf, hstore), nil
+}
+
+func (HstorePairsCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	if _, ok := target.(HstorePairsScanner); !ok {
+		return nil
+	}
+
+	switch format {
+	case pgtype.BinaryFormatCode:
+		return scanPlanBinaryHstoreToHstorePairsScanner{}
+	case pgtype.TextFormatCode:
+		return scanPlanTextAnyToHstorePairsScanner{}
+	}
+	return nil
+}
+
+type scanPlanBinaryHstoreToHstorePairsScanner struct{}
+
+func (scanPlanBinaryHstoreToHstorePairsScanner) Scan(src []byte, dst any) error {
+	scanner := (dst).(HstorePairsScanner)
+	if src == nil {
+		return scanner.ScanHstorePairs(HstorePairs(nil))
+	}
+	pairs, err := parsePairsBinary(src)
+	if err != nil {
+		return err
+	}
+	return scanner.ScanHstorePairs(pairs)
+}
+
+type scanPlanTextAnyToHstorePairsScanner struct{}
+
+func (s scanPlanTextAnyToHstorePairsScanner) Scan(src []byte, dst any) error {
+	scanner := (dst).(HstorePairsScanner)
+	if src == nil {
+		return scanner.ScanHstorePairs(HstorePairs(nil))
+	}
+	return s.scanString(string(src), scanner)
+}
+
+// scanString does not return nil hstore values because string cannot be nil.
+func (scanPlanTextAnyToHstorePairsScanner) scanString(src string, scanner HstorePairsScanner) error {
+	pairs, err := parsePairsText(src)
+	if err != nil {
+		return err
+	}
+	return scanner.ScanHstorePairs(pairs)
+}
+
+func (c HstorePairsCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return codecDecodeToTextFormat(c, m, oid, format, src, nil)
+}
+
+func (c HstorePairsCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	if src == nil {
+		return nil, nil
+	}
+	var hstore HstorePairs
+	err := codecScan(c, m, oid, format, src, &hstore)
+	if err != nil {
+		return nil, err
+	}
+	return hstore, nil
+}
@@ -0,0 +1,111 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstorePairs(t *testing.T) {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstorePairsCodec{}, Name: "hstore", OID: testHstoreOID})
+
+	inputs := []pgxtypefaster.HstorePairs{
+		{},
+		{{Key: "a", Value: pgxtypefaster.NewText("1")}, {Key: "b", Value: pgtype.Text{}}},
+		{{Key: `k"\`, Value: pgxtypefaster.NewText(`v"\`)}},
+	}
+	for _, format := range formats {
+		for _, input := range inputs {
+			buf, err := m.Encode(testHstoreOID, format, input, []byte{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out pgxtypefaster.HstorePairs
+			if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, input) {
+				t.Errorf("format=%d: got %#v; expected %#v", format, out, input)
+			}
+		}
+
+		out := pgxtypefaster.HstorePairs{}
+		if err := m.Scan(testHstoreOID, format, nil, &out); err != nil || out != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, out, err)
+		}
+	}
+
+	var h pgxtypefaster.HstorePairs
+	if err := h.Scan(`"a"=>"1", "b"=>NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := h.Get("a"); !ok || v != pgxtypefaster.NewText("1") {
+		t.Errorf(`Get("a")=%#v, %t`, v, ok)
+	}
+	if v, ok := h.Get("b"); !ok || v.Valid {
+		t.Errorf(`Get("b")=%#v, %t`, v, ok)
+	}
+	if _, ok := h.Get("c"); ok {
+		t.Error(`Get("c") must not exist`)
+	}
+	h.Set("b", pgxtypefaster.NewText("2"))
+	h.Set("c", pgxtypefaster.NewText("3"))
+	value, err := h.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != `"a"=>"1", "b"=>"2", "c"=>"3"` {
+		t.Errorf("Value()=%#v", value)
+	}
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2"), "c": pgxtypefaster.NewText("3")}
+	if !reflect.DeepEqual(h.Hstore(), expected) {
+		t.Errorf("Hstore()=%#v", h.Hstore())
+	}
+}
+
+func BenchmarkHstorePairsScan(b *testing.B) {
+	input := pgxtypefaster.Hstore{
+		"a": pgxtypefaster.NewText("100"),
+		"b": pgxtypefaster.NewText("200"),
+		"c": pgtype.Text{},
+	}
+	m := newTestTypeMap()
+	pairsMap := pgtype.NewMap()
+	pairsMap.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstorePairsCodec{}, Name: "hstore", OID: testHstoreOID})
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		formatName := "text"
+		if format == pgtype.BinaryFormatCode {
+			formatName = "binary"
+		}
+
+		var h pgxtypefaster.Hstore
+		hstorePlan := m.PlanScan(testHstoreOID, format, &h)
+		b.Run("Hstore/"+formatName, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := hstorePlan.Scan(buf, &h); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		var pairs pgxtypefaster.HstorePairs
+		pairsPlan := pairsMap.PlanScan(testHstoreOID, format, &pairs)
+		b.Run("HstorePairs/"+formatName, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := pairsPlan.Scan(buf, &pairs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
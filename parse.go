@@ -0,0 +1,78 @@
+package pgxtypefaster
+
+import (
+	"io"
+
+	"github.com/evanj/pgxtypefaster/internal/parsing"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ParseError is returned for invalid input in the text format, by ParseHstore, scanning, and
+// TextParser. Offset is the byte offset of the error, Expected and Found describe the problem, and
+// Snippet contains the input around the offset. Use errors.As to find it in wrapped errors.
+type ParseError = parsing.Error
+
+// ParseHstore parses s in the Postgres text format, such as the output of pg_dump or COPY. It is
+// the parser used by HstoreCodec, without going through a pgtype scan plan.
+func ParseHstore(s string) (Hstore, error) {
+	return parseHstore(s, nil)
+}
+
+// ParseHstoreFunc parses s in the Postgres text format, calling fn with each pair in order, without
+// building a map. Duplicate keys are passed to fn each time they occur. It stops and returns the
+// first error returned by fn. The strings passed to fn may share memory with s.
+func ParseHstoreFunc(s string, fn func(key string, value pgtype.Text) error) error {
+	return parsePairsTextFunc(s, fn)
+}
+
+// AppendText appends h to dst in the Postgres text format, as used by COPY and pg_dump. The pairs
+// are in map iteration order. A nil (NULL) h appends nothing: the caller must represent NULL, such
+// as with \N for COPY.
+func AppendText(dst []byte, h Hstore) []byte {
+	if h == nil {
+		return dst
+	}
+	// encoding an Hstore cannot fail
+	buf, _ := encodePlanHstoreCodecText{}.Encode(h, dst)
+	return buf
+}
+
+// encodeTextToBufferLen is the size of the buffer used by EncodeTextTo.
+const encodeTextToBufferLen = 4096
+
+// EncodeTextTo writes h to w in the Postgres text format, like AppendText. It writes in small
+// chunks, so it uses a constant amount of memory for hstores with many pairs. A nil (NULL) h writes
+// nothing.
+func EncodeTextTo(w io.Writer, h Hstore) error {
+	return hstoreOps.encodeTextTo(w, h)
+}
+
+// AppendBinary appends h to dst in the Postgres binary format, as used by binary COPY, without the
+// length prefix. The pairs are in map iteration order. A nil (NULL) h appends nothing: the caller
+// must represent NULL, such as with a length of -1 for binary COPY.
+func AppendBinary(dst []byte, h Hstore) []byte {
+	if h == nil {
+		return dst
+	}
+	// encoding an Hstore cannot fail
+	buf, _ := encodePlanHstoreCodecBinary{}.Encode(h, dst)
+	return buf
+}
+
+// AppendTextCompat appends h to dst in the Postgres text format, like AppendText.
+func AppendTextCompat(dst []byte, h HstoreCompat) []byte {
+	return hstoreCompatOps.appendText(dst, h)
+}
+
+// EncodeTextToCompat writes h to w in the Postgres text format, like EncodeTextTo.
+func EncodeTextToCompat(w io.Writer, h HstoreCompat) error {
+	return hstoreCompatOps.encodeTextTo(w, h)
+}
+
+// AppendBinaryCompat appends h to dst in the Postgres binary format, like AppendBinary.
+func AppendBinaryCompat(dst []byte, h HstoreCompat) []byte {
+	if h == nil {
+		return dst
+	}
+	return hstoreCompatOps.appendBinary(dst, h)
+}
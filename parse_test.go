@@ -0,0 +1,221 @@
+package pgxtypefaster_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseHstoreAndAppend(t *testing.T) {
+	m := newTestTypeMap()
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText(`x"\`), "null": pgtype.Text{}}
+
+	text := pgxtypefaster.AppendText([]byte("prefix"), h)
+	if string(text[:6]) != "prefix" {
+		t.Fatalf("AppendText overwrote dst: %#v", string(text))
+	}
+	parsed, err := pgxtypefaster.ParseHstore(string(text[6:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, h) {
+		t.Errorf("ParseHstore(AppendText)=%#v; expected %#v", parsed, h)
+	}
+
+	binary := pgxtypefaster.AppendBinary([]byte("prefix"), h)
+	var scanned pgxtypefaster.Hstore
+	if err := m.Scan(testHstoreOID, pgtype.BinaryFormatCode, binary[6:], &scanned); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, h) {
+		t.Errorf("scanned AppendBinary=%#v; expected %#v", scanned, h)
+	}
+
+	for _, appendFunc := range []func([]byte, pgxtypefaster.Hstore) []byte{pgxtypefaster.AppendText, pgxtypefaster.AppendBinary} {
+		if out := appendFunc([]byte("x"), nil); string(out) != "x" {
+			t.Errorf("appending nil=%#v; expected unchanged", string(out))
+		}
+	}
+
+	if _, err := pgxtypefaster.ParseHstore(`"a"=>`); err == nil {
+		t.Error("ParseHstore expected error")
+	}
+	if parsed, err := pgxtypefaster.ParseHstore(""); err != nil || parsed == nil || len(parsed) != 0 {
+		t.Errorf(`ParseHstore("")=%#v, %v; expected empty`, parsed, err)
+	}
+}
+
+func TestParseHstoreFunc(t *testing.T) {
+	var keys []string
+	var values []pgtype.Text
+	err := pgxtypefaster.ParseHstoreFunc(`"b"=>"1", "a"=>NULL, "b"=>"2"`, func(key string, value pgtype.Text) error {
+		keys = append(keys, key)
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"b", "a", "b"}) {
+		t.Errorf("keys=%#v", keys)
+	}
+	if !reflect.DeepEqual(values, []pgtype.Text{pgxtypefaster.NewText("1"), {}, pgxtypefaster.NewText("2")}) {
+		t.Errorf("values=%#v", values)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = pgxtypefaster.ParseHstoreFunc(`"a"=>"1", "b"=>"2"`, func(key string, value pgtype.Text) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("err=%v calls=%d; expected stop after 1 call", err, calls)
+	}
+
+	err = pgxtypefaster.ParseHstoreFunc(`"a"=>"1", "b"`, func(key string, value pgtype.Text) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestEncodeTextTo(t *testing.T) {
+	h := pgxtypefaster.Hstore{"null": pgtype.Text{}}
+	for i := 0; i < 2000; i++ {
+		h[fmt.Sprintf("key%d", i)] = pgxtypefaster.NewText(fmt.Sprintf(`"value\%d`, i))
+	}
+	var w countingWriter
+	if err := pgxtypefaster.EncodeTextTo(&w, h); err != nil {
+		t.Fatal(err)
+	}
+	if w.writes < 2 {
+		t.Errorf("writes=%d; expected multiple chunks", w.writes)
+	}
+	parsed, err := pgxtypefaster.ParseHstore(w.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(h) {
+		t.Error("parsing the output of EncodeTextTo did not return the input")
+	}
+
+	w.Reset()
+	if err := pgxtypefaster.EncodeTextTo(&w, nil); err != nil || w.Len() != 0 {
+		t.Errorf("EncodeTextTo(nil) wrote %#v, %v", w.String(), err)
+	}
+
+	errWrite := errors.New("write failed")
+	if err := pgxtypefaster.EncodeTextTo(errWriter{errWrite}, h); err != errWrite {
+		t.Errorf("err=%v; expected %v", err, errWrite)
+	}
+}
+
+func TestEncodeCompat(t *testing.T) {
+	h := pgxtypefaster.Hstore{"null": pgtype.Text{}}
+	for i := 0; i < 2000; i++ {
+		h[fmt.Sprintf("key%d", i)] = pgxtypefaster.NewText(fmt.Sprintf(`"value\%d`, i))
+	}
+	compat := pgxtypefaster.HstoreToCompat(h)
+
+	var w countingWriter
+	if err := pgxtypefaster.EncodeTextToCompat(&w, compat); err != nil {
+		t.Fatal(err)
+	}
+	if w.writes < 2 {
+		t.Errorf("writes=%d; expected multiple chunks", w.writes)
+	}
+	texts := map[string]string{
+		"EncodeTextToCompat": w.String(),
+		"AppendTextCompat":   string(pgxtypefaster.AppendTextCompat([]byte("prefix"), compat)[len("prefix"):]),
+	}
+	for name, text := range texts {
+		parsed, err := pgxtypefaster.ParseHstore(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Equal(h) {
+			t.Errorf("parsing the output of %s did not return the input", name)
+		}
+	}
+
+	binary := pgxtypefaster.AppendBinaryCompat([]byte("prefix"), compat)
+	var parsed pgxtypefaster.Hstore
+	err := newTestTypeMap().Scan(testHstoreOID, pgtype.BinaryFormatCode, binary[len("prefix"):], &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(h) {
+		t.Error("scanning the output of AppendBinaryCompat did not return the input")
+	}
+
+	// NULL writes nothing
+	w.Reset()
+	if err := pgxtypefaster.EncodeTextToCompat(&w, nil); err != nil || w.Len() != 0 {
+		t.Errorf("EncodeTextToCompat(nil) wrote %#v, %v", w.String(), err)
+	}
+	if out := pgxtypefaster.AppendTextCompat(nil, nil); len(out) != 0 {
+		t.Errorf("AppendTextCompat(nil)=%#v", out)
+	}
+	if out := pgxtypefaster.AppendBinaryCompat(nil, nil); len(out) != 0 {
+		t.Errorf("AppendBinaryCompat(nil)=%#v", out)
+	}
+}
+
+type errWriter struct{ err error }
+
+func (w errWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected pgxtypefaster.ParseError
+	}{
+		{`"a"=>"1" "b"=>"2"`, pgxtypefaster.ParseError{Offset: 8, Expected: `','`, Found: `' '`, Snippet: `"a"=>"1" "b"=>"2"`}},
+		{`"a"=>"1", "b"=`, pgxtypefaster.ParseError{Offset: 14, Expected: `'>'`, Found: "end", Snippet: `"a"=>"1", "b"=`}},
+		{`"a"=>`, pgxtypefaster.ParseError{Offset: 5, Expected: `'"' or NULL`, Found: "end", Snippet: `"a"=>`}},
+		{`"a"=>x`, pgxtypefaster.ParseError{Offset: 5, Expected: `'"' or NULL`, Found: `'x'`, Snippet: `"a"=>x`}},
+		{`"a"=>NUL`, pgxtypefaster.ParseError{Offset: 8, Expected: `'L'`, Found: "end", Snippet: `"a"=>NUL`}},
+		{`"a"=>"unterminated`, pgxtypefaster.ParseError{Offset: 18, Expected: `closing '"'`, Found: "end", Snippet: `"=>"unterminated`}},
+		{`"a"=>"\x"`, pgxtypefaster.ParseError{Offset: 7, Expected: `'\\' or '"' after '\\'`, Found: `'x'`, Snippet: `"a"=>"\x"`}},
+		{
+			`"key01"=>"value01", "key02"=>"value02", "key03"=>value03`,
+			pgxtypefaster.ParseError{Offset: 49, Expected: `'"' or NULL`, Found: `'v'`, Snippet: `ue02", "key03"=>value03`},
+		},
+	}
+	m := newTestTypeMap()
+	for _, test := range tests {
+		_, err := pgxtypefaster.ParseHstore(test.input)
+		var parseErr *pgxtypefaster.ParseError
+		if !errors.As(err, &parseErr) || *parseErr != test.expected {
+			t.Errorf("ParseHstore(%#v): err=%#v; expected %#v", test.input, err, test.expected)
+		}
+
+		var h pgxtypefaster.Hstore
+		err = m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(test.input), &h)
+		if !errors.As(err, &parseErr) || *parseErr != test.expected {
+			t.Errorf("Scan(%#v): err=%#v; expected %#v", test.input, err, test.expected)
+		}
+	}
+
+	err := &pgxtypefaster.ParseError{Offset: 5, Expected: `'"' or NULL`, Found: `'x'`, Snippet: `"a"=>x`}
+	if err.Error() != `parse error at offset 5: expected '"' or NULL; found 'x' near "\"a\"=>x"` {
+		t.Errorf("Error()=%s", err.Error())
+	}
+}
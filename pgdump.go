@@ -0,0 +1,250 @@
+package pgxtypefaster
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DumpColumn identifies a column in a plain-format pg_dump file. Table must match the name in the
+// COPY statement exactly, including the schema and any quotes (e.g. "public.items").
+type DumpColumn struct {
+	Table  string
+	Column string
+}
+
+// DumpHstoreFunc is called for each value of a selected hstore column. NULL columns are passed as
+// a nil Hstore. The returned Hstore replaces the value; returning nil writes NULL.
+type DumpHstoreFunc func(col DumpColumn, h Hstore) (Hstore, error)
+
+// copyNull is the COPY text format representation of NULL.
+const copyNull = `\N`
+
+// copyEnd is the line that ends a COPY block in a plain-format dump.
+const copyEnd = `\.`
+
+// parseDumpCopyHeader parses a COPY statement line from a plain-format dump, like:
+//
+//	COPY public.items (id, attrs) FROM stdin;
+//
+// It returns false if the line is not a COPY statement.
+func parseDumpCopyHeader(line string) (table string, columns []string, ok bool) {
+	rest, ok := strings.CutPrefix(line, "COPY ")
+	if !ok {
+		return "", nil, false
+	}
+	rest, ok = strings.CutSuffix(rest, " FROM stdin;")
+	if !ok {
+		return "", nil, false
+	}
+	table, columnList, ok := strings.Cut(rest, " (")
+	if !ok {
+		return "", nil, false
+	}
+	columnList, ok = strings.CutSuffix(columnList, ")")
+	if !ok {
+		return "", nil, false
+	}
+	columns = strings.Split(columnList, ", ")
+	return table, columns, true
+}
+
+// unescapeCopyText decodes a single field in the COPY text format. It does not handle NULL.
+func unescapeCopyText(field string) (string, error) {
+	firstBackslash := strings.IndexByte(field, '\\')
+	if firstBackslash == -1 {
+		return field, nil
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(field))
+	builder.WriteString(field[:firstBackslash])
+	for i := firstBackslash; i < len(field); i++ {
+		b := field[i]
+		if b != '\\' {
+			builder.WriteByte(b)
+			continue
+		}
+		i++
+		if i >= len(field) {
+			return "", errors.New("COPY field ends with a backslash")
+		}
+		b = field[i]
+		switch b {
+		case 'b':
+			builder.WriteByte('\b')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case 'v':
+			builder.WriteByte('\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// up to 3 octal digits
+			value := b - '0'
+			for j := 0; j < 2 && i+1 < len(field) && field[i+1] >= '0' && field[i+1] <= '7'; j++ {
+				i++
+				value = value*8 + (field[i] - '0')
+			}
+			builder.WriteByte(value)
+		case 'x':
+			// up to 2 hex digits; without any digits it is a literal x
+			value := byte(0)
+			digits := 0
+			for digits < 2 && i+1 < len(field) && isHexDigit(field[i+1]) {
+				i++
+				value = value*16 + hexDigitValue(field[i])
+				digits++
+			}
+			if digits == 0 {
+				builder.WriteByte('x')
+			} else {
+				builder.WriteByte(value)
+			}
+		default:
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String(), nil
+}
+
+func isHexDigit(b byte) bool {
+	return ('0' <= b && b <= '9') || ('a' <= b && b <= 'f') || ('A' <= b && b <= 'F')
+}
+
+func hexDigitValue(b byte) byte {
+	switch {
+	case '0' <= b && b <= '9':
+		return b - '0'
+	case 'a' <= b && b <= 'f':
+		return b - 'a' + 10
+	}
+	return b - 'A' + 10
+}
+
+var copyTextReplacer = strings.NewReplacer(
+	`\`, `\\`, "\b", `\b`, "\f", `\f`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\v", `\v`)
+
+// ExtractDumpHstore reads a plain-format pg_dump file from r, and calls fn for each value of the
+// selected hstore columns. The returned Hstore is ignored.
+func ExtractDumpHstore(r io.Reader, columns []DumpColumn, fn DumpHstoreFunc) error {
+	return processDumpHstore(r, nil, columns, fn)
+}
+
+// RewriteDumpHstore copies a plain-format pg_dump file from r to w, replacing each value of the
+// selected hstore columns with the value returned by fn. All other lines are copied unchanged.
+// This can be used to redact or rekey hstore values without loading the dump into a database.
+func RewriteDumpHstore(r io.Reader, w io.Writer, columns []DumpColumn, fn DumpHstoreFunc) error {
+	return processDumpHstore(r, w, columns, fn)
+}
+
+// processDumpHstore implements ExtractDumpHstore and RewriteDumpHstore. If w is nil, the output is
+// discarded.
+func processDumpHstore(r io.Reader, w io.Writer, columns []DumpColumn, fn DumpHstoreFunc) error {
+	reader := bufio.NewReader(r)
+	var writer *bufio.Writer
+	if w != nil {
+		writer = bufio.NewWriter(w)
+	}
+
+	// selected column indexes in the current COPY block; empty when not in a COPY block or when no
+	// columns are selected
+	var selected []int
+	var selectedColumns []DumpColumn
+	inCopy := false
+	lineNumber := 0
+	var buf []byte
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line == "" && err == io.EOF {
+			break
+		}
+		lineNumber++
+
+		content := strings.TrimSuffix(line, "\n")
+		if !inCopy {
+			if table, copyColumns, ok := parseDumpCopyHeader(content); ok {
+				inCopy = true
+				selected = selected[:0]
+				selectedColumns = selectedColumns[:0]
+				for i, column := range copyColumns {
+					for _, col := range columns {
+						if col.Table == table && col.Column == column {
+							selected = append(selected, i)
+							selectedColumns = append(selectedColumns, col)
+						}
+					}
+				}
+			}
+		} else if content == copyEnd {
+			inCopy = false
+		} else if len(selected) > 0 {
+			line, buf, err = rewriteDumpRow(content, line[len(content):], selected, selectedColumns, fn, buf[:0])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		}
+
+		if writer != nil {
+			if _, err := writer.WriteString(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if writer != nil {
+		return writer.Flush()
+	}
+	return nil
+}
+
+// rewriteDumpRow calls fn for the selected fields in the COPY row content and returns the
+// rewritten row followed by lineEnd. It may use buf as temporary storage.
+func rewriteDumpRow(
+	content string, lineEnd string, selected []int, selectedColumns []DumpColumn, fn DumpHstoreFunc, buf []byte,
+) (string, []byte, error) {
+	// tabs inside values are always escaped, so splitting the raw line is safe
+	fields := strings.Split(content, "\t")
+	for i, fieldIndex := range selected {
+		if fieldIndex >= len(fields) {
+			return "", buf, fmt.Errorf("COPY row has %d fields; expected column %#v at index %d",
+				len(fields), selectedColumns[i].Column, fieldIndex)
+		}
+
+		var h Hstore
+		if fields[fieldIndex] != copyNull {
+			text, err := unescapeCopyText(fields[fieldIndex])
+			if err != nil {
+				return "", buf, err
+			}
+			h, err = parseHstore(text, nil)
+			if err != nil {
+				return "", buf, fmt.Errorf("column %#v: %w", selectedColumns[i].Column, err)
+			}
+		}
+
+		out, err := fn(selectedColumns[i], h)
+		if err != nil {
+			return "", buf, err
+		}
+		if out == nil {
+			fields[fieldIndex] = copyNull
+			continue
+		}
+		buf, err = encodePlanHstoreCodecText{}.Encode(out, buf[:0])
+		if err != nil {
+			return "", buf, err
+		}
+		fields[fieldIndex] = copyTextReplacer.Replace(string(buf))
+	}
+	return strings.Join(fields, "\t") + lineEnd, buf, nil
+}
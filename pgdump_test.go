@@ -0,0 +1,90 @@
+package pgxtypefaster_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const testDump = `--
+-- Data for Name: items; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.items (id, attrs, note) FROM stdin;
+1	"password"=>"secret"	first\tnote
+2	\N	\N
+3	"k\\\\ey"=>"a\\"b", "password"=>NULL	x
+\.
+
+
+COPY public.other (attrs) FROM stdin;
+"password"=>"untouched"
+\.
+`
+
+func TestRewriteDumpHstore(t *testing.T) {
+	columns := []pgxtypefaster.DumpColumn{{Table: "public.items", Column: "attrs"}}
+	var seen []pgxtypefaster.Hstore
+	redact := func(col pgxtypefaster.DumpColumn, h pgxtypefaster.Hstore) (pgxtypefaster.Hstore, error) {
+		seen = append(seen, h)
+		if _, ok := h["password"]; ok {
+			h["password"] = pgxtypefaster.NewText("REDACTED")
+		}
+		return h, nil
+	}
+
+	var out strings.Builder
+	err := pgxtypefaster.RewriteDumpHstore(strings.NewReader(testDump), &out, columns, redact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("expected 3 values; seen=%#v", seen)
+	}
+	if seen[1] != nil {
+		t.Errorf("NULL column must be passed as nil; found %#v", seen[1])
+	}
+	if seen[2][`k\ey`] != pgxtypefaster.NewText(`a"b`) {
+		t.Errorf("escapes were not decoded: %#v", seen[2])
+	}
+
+	expected := strings.Replace(testDump, `"password"=>"secret"`, `"password"=>"REDACTED"`, 1)
+	// map iteration order is random: accept either order for the row with two keys
+	row3a := `3	"k\\\\ey"=>"a\\"b", "password"=>"REDACTED"	x`
+	row3b := `3	"password"=>"REDACTED", "k\\\\ey"=>"a\\"b"	x`
+	expected = strings.Replace(expected, `3	"k\\\\ey"=>"a\\"b", "password"=>NULL	x`, row3a, 1)
+	if out.String() != expected && out.String() != strings.Replace(expected, row3a, row3b, 1) {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+
+	err = pgxtypefaster.ExtractDumpHstore(strings.NewReader("COPY t (attrs) FROM stdin;\n\"bad\n\\.\n"),
+		[]pgxtypefaster.DumpColumn{{Table: "t", Column: "attrs"}},
+		func(col pgxtypefaster.DumpColumn, h pgxtypefaster.Hstore) (pgxtypefaster.Hstore, error) {
+			return h, nil
+		})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("expected parse error on line 2; err=%v", err)
+	}
+}
+
+func TestExtractDumpHstoreUnescape(t *testing.T) {
+	// octal and hex escapes are valid COPY text format
+	dump := "COPY t (attrs) FROM stdin;\n\"\\141\"=>\"\\x62\", \"c\"=>NULL\n\\.\n"
+	var found pgxtypefaster.Hstore
+	err := pgxtypefaster.ExtractDumpHstore(strings.NewReader(dump),
+		[]pgxtypefaster.DumpColumn{{Table: "t", Column: "attrs"}},
+		func(col pgxtypefaster.DumpColumn, h pgxtypefaster.Hstore) (pgxtypefaster.Hstore, error) {
+			found = h
+			return h, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": pgtype.Text{}}
+	if len(found) != 2 || found["a"] != expected["a"] || found["c"] != expected["c"] {
+		t.Errorf("found=%#v; expected=%#v", found, expected)
+	}
+}
@@ -0,0 +1,91 @@
+package pgio_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+)
+
+func TestReaderWriter(t *testing.T) {
+	var buf []byte
+	buf = pgio.AppendInt16(buf, -2)
+	buf = pgio.AppendInt32(buf, -3)
+	buf = pgio.AppendInt64(buf, -4)
+	buf = append(buf, 5)
+	buf = pgio.AppendLengthPrefixedString(buf, "abc")
+	buf = pgio.AppendLengthPrefixed(buf, nil)
+	buf = pgio.AppendLengthPrefixed(buf, []byte{})
+
+	r := pgio.NewReader(buf)
+	if v := r.ReadInt16(); v != -2 {
+		t.Errorf("ReadInt16()=%d", v)
+	}
+	if v := r.ReadInt32(); v != -3 {
+		t.Errorf("ReadInt32()=%d", v)
+	}
+	if v := r.ReadInt64(); v != -4 {
+		t.Errorf("ReadInt64()=%d", v)
+	}
+	if v := r.ReadUint8(); v != 5 {
+		t.Errorf("ReadUint8()=%d", v)
+	}
+	if v := r.ReadLengthPrefixed(); !bytes.Equal(v, []byte("abc")) {
+		t.Errorf("ReadLengthPrefixed()=%#v", v)
+	}
+	if v := r.ReadLengthPrefixed(); v != nil {
+		t.Errorf("ReadLengthPrefixed()=%#v; expected nil for NULL", v)
+	}
+	if v := r.ReadLengthPrefixed(); v == nil || len(v) != 0 {
+		t.Errorf("ReadLengthPrefixed()=%#v; expected empty", v)
+	}
+	if r.Err() != nil || r.Remaining() != 0 || r.Pos() != len(buf) {
+		t.Errorf("Err()=%v Remaining()=%d Pos()=%d", r.Err(), r.Remaining(), r.Pos())
+	}
+
+	// reading past the end sets the error and later reads return zero
+	if v := r.ReadInt32(); v != 0 || !errors.Is(r.Err(), pgio.ErrShortBuffer) {
+		t.Errorf("ReadInt32()=%d Err()=%v; expected ErrShortBuffer", v, r.Err())
+	}
+	firstErr := r.Err()
+	r.ReadN(-1)
+	if r.Err() != firstErr {
+		t.Errorf("Err() changed to %v; expected the first error", r.Err())
+	}
+
+	r.Reset(pgio.AppendInt32(nil, 1<<30))
+	var countErr *pgio.CountError
+	if n := r.ReadCount(4); n != 0 || !errors.As(r.Err(), &countErr) || !errors.Is(r.Err(), pgio.ErrShortBuffer) {
+		t.Errorf("ReadCount()=%d Err()=%v; expected error for a count that cannot fit", n, r.Err())
+	}
+	if *countErr != (pgio.CountError{Count: 1 << 30, MinSize: 4, Remaining: 0}) {
+		t.Errorf("CountError=%#v", countErr)
+	}
+	r.Reset(pgio.AppendInt32(nil, -1))
+	if n := r.ReadCount(4); n != 0 || !errors.As(r.Err(), &countErr) || countErr.Count != -1 {
+		t.Errorf("ReadCount()=%d Err()=%v; expected error for a negative count", n, r.Err())
+	}
+	if r.Err().Error() != "pgio: invalid negative count -1" {
+		t.Errorf("Err()=%s", r.Err())
+	}
+	// reads after an invalid count return zero, like other errors
+	r.Reset(pgio.AppendInt32(pgio.AppendInt32(nil, -1), 7))
+	r.ReadCount(4)
+	if v := r.ReadInt32(); v != 0 || r.Pos() != 0 || r.Remaining() != 0 {
+		t.Errorf("ReadInt32()=%d Pos()=%d Remaining()=%d after an invalid count; expected 0", v, r.Pos(), r.Remaining())
+	}
+	if !errors.As(r.Err(), &countErr) {
+		t.Errorf("Err()=%v; expected the CountError", r.Err())
+	}
+	r.Reset(pgio.AppendLengthPrefixedString(pgio.AppendInt32(nil, 7), "xy"))
+	r.ReadInt32()
+	if start, n := r.ReadLengthPrefixedRange(); start != 8 || n != 2 || r.Err() != nil {
+		t.Errorf("ReadLengthPrefixedRange()=%d, %d Err()=%v; expected 8, 2", start, n, r.Err())
+	}
+
+	r.Reset(pgio.AppendInt32(nil, 2))
+	if v := r.ReadLengthPrefixed(); v != nil || r.Err() == nil {
+		t.Errorf("ReadLengthPrefixed()=%#v Err()=%v; expected error for a truncated value", v, r.Err())
+	}
+}
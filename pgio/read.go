@@ -0,0 +1,212 @@
+package pgio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrShortBuffer is wrapped by the error from Reader.Err when a read needs more bytes than remain.
+var ErrShortBuffer = errors.New("pgio: unexpected end of data")
+
+// CountError is the error from Reader.Err when ReadCount reads a count that is negative, or too
+// large for the remaining bytes to contain, which means the data is corrupted. It wraps
+// ErrShortBuffer.
+type CountError struct {
+	Count     int32
+	MinSize   int
+	Remaining int
+}
+
+func (e *CountError) Error() string {
+	if e.Count < 0 {
+		return fmt.Sprintf("pgio: invalid negative count %d", e.Count)
+	}
+	return fmt.Sprintf("%s: count %d of %d byte elements with %d bytes remaining",
+		ErrShortBuffer, e.Count, e.MinSize, e.Remaining)
+}
+
+func (e *CountError) Unwrap() error {
+	return ErrShortBuffer
+}
+
+// Reader reads values from a binary buffer, checking bounds. After a read fails, all later reads
+// return zero values and Err returns the first error, so callers can check the error once after a
+// sequence of reads. The zero value reads from an empty buffer.
+type Reader struct {
+	src []byte
+	pos int
+	err error
+}
+
+// NewReader returns a Reader for src.
+func NewReader(src []byte) *Reader {
+	return &Reader{src: src}
+}
+
+// Reset changes r to read from src, clearing any error, so a Reader can be reused without
+// allocating.
+func (r *Reader) Reset(src []byte) {
+	*r = Reader{src: src}
+}
+
+// Err returns the first error from a read, or nil.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Pos returns the offset of the next byte to be read. It returns 0 after an error.
+func (r *Reader) Pos() int {
+	return r.pos
+}
+
+// Remaining returns the number of bytes that have not been read. It returns 0 after an error.
+func (r *Reader) Remaining() int {
+	return len(r.src) - r.pos
+}
+
+// RemainingBytes returns the bytes that have not been read, without consuming them. It returns nil
+// after an error.
+func (r *Reader) RemainingBytes() []byte {
+	if r.src == nil {
+		return nil
+	}
+	return r.src[r.pos:]
+}
+
+// fail records an error for a read of n bytes, if there is not already an error. It empties the
+// buffer, so later reads fail the bounds check without checking the error.
+func (r *Reader) fail(n int) {
+	var err error
+	if r.err == nil {
+		err = fmt.Errorf("%w: reading %d bytes at offset %d with %d remaining",
+			ErrShortBuffer, n, r.pos, len(r.src)-r.pos)
+	}
+	r.stop(err)
+}
+
+// stop records err, if there is not already an error, and empties the buffer.
+func (r *Reader) stop(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	r.src = nil
+	r.pos = 0
+}
+
+// ReadN returns the next n bytes, which are a sub-slice of the buffer. It returns nil and sets the
+// error if n is negative or more than the remaining bytes.
+func (r *Reader) ReadN(n int) []byte {
+	if n < 0 || n > len(r.src)-r.pos {
+		r.fail(n)
+		return nil
+	}
+	out := r.src[r.pos : r.pos+n]
+	r.pos += n
+	return out
+}
+
+// ReadUint8 reads one byte.
+func (r *Reader) ReadUint8() uint8 {
+	b := r.ReadN(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// ReadUint16 reads a big-endian uint16.
+func (r *Reader) ReadUint16() uint16 {
+	if len(r.src)-r.pos < 2 {
+		r.fail(2)
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.src[r.pos:])
+	r.pos += 2
+	return v
+}
+
+// ReadInt16 reads a big-endian int16.
+func (r *Reader) ReadInt16() int16 {
+	return int16(r.ReadUint16())
+}
+
+// ReadUint32 reads a big-endian uint32.
+func (r *Reader) ReadUint32() uint32 {
+	if len(r.src)-r.pos < 4 {
+		r.fail(4)
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.src[r.pos:])
+	r.pos += 4
+	return v
+}
+
+// ReadInt32 reads a big-endian int32.
+func (r *Reader) ReadInt32() int32 {
+	return int32(r.ReadUint32())
+}
+
+// ReadUint64 reads a big-endian uint64.
+func (r *Reader) ReadUint64() uint64 {
+	if len(r.src)-r.pos < 8 {
+		r.fail(8)
+		return 0
+	}
+	v := binary.BigEndian.Uint64(r.src[r.pos:])
+	r.pos += 8
+	return v
+}
+
+// ReadInt64 reads a big-endian int64.
+func (r *Reader) ReadInt64() int64 {
+	return int64(r.ReadUint64())
+}
+
+// ReadLengthPrefixed reads an int32 length followed by that many bytes. It returns nil without an
+// error for a negative length, which is how the binary format represents NULL.
+func (r *Reader) ReadLengthPrefixed() []byte {
+	n := r.ReadInt32()
+	if r.err != nil || n < 0 {
+		return nil
+	}
+	return r.ReadN(int(n))
+}
+
+// ReadLengthPrefixedRange reads an int32 length followed by that many bytes, and returns the offset
+// of the bytes in the buffer and the length. It returns a negative length for NULL or if the read
+// fails. It is faster than ReadLengthPrefixed for callers that convert the buffer to a string once,
+// then use substrings of it.
+func (r *Reader) ReadLengthPrefixedRange() (start int, length int) {
+	if len(r.src)-r.pos < 4 {
+		r.fail(4)
+		return 0, -1
+	}
+	length = int(int32(binary.BigEndian.Uint32(r.src[r.pos:])))
+	r.pos += 4
+	if length < 0 {
+		return 0, length
+	}
+	if length > len(r.src)-r.pos {
+		r.fail(length)
+		return 0, -1
+	}
+	start = r.pos
+	r.pos += length
+	return start, length
+}
+
+// ReadCount reads an int32 count of elements that are each at least minSize bytes. It sets the
+// error to a *CountError if the count is negative or the remaining bytes cannot contain that many
+// elements, so callers can safely allocate the result. minSize must be at least 1.
+func (r *Reader) ReadCount(minSize int) int {
+	n := r.ReadInt32()
+	if r.err != nil {
+		return 0
+	}
+	if n < 0 || int(n) > r.Remaining()/minSize {
+		r.stop(&CountError{n, minSize, r.Remaining()})
+		return 0
+	}
+	return int(n)
+}
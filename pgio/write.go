@@ -0,0 +1,67 @@
+// Package pgio reads and writes the big-endian integers and length-prefixed values used by the
+// Postgres binary wire format. The Append functions are copied from jackc/pgx, where they are
+// internal. Reader is a bounds-checked cursor for parsing binary values.
+package pgio
+
+import "encoding/binary"
+
+// AppendUint16 appends n to buf in big-endian order.
+func AppendUint16(buf []byte, n uint16) []byte {
+	wp := len(buf)
+	buf = append(buf, 0, 0)
+	binary.BigEndian.PutUint16(buf[wp:], n)
+	return buf
+}
+
+// AppendUint32 appends n to buf in big-endian order.
+func AppendUint32(buf []byte, n uint32) []byte {
+	wp := len(buf)
+	buf = append(buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(buf[wp:], n)
+	return buf
+}
+
+// AppendUint64 appends n to buf in big-endian order.
+func AppendUint64(buf []byte, n uint64) []byte {
+	wp := len(buf)
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(buf[wp:], n)
+	return buf
+}
+
+// AppendInt16 appends n to buf in big-endian order.
+func AppendInt16(buf []byte, n int16) []byte {
+	return AppendUint16(buf, uint16(n))
+}
+
+// AppendInt32 appends n to buf in big-endian order.
+func AppendInt32(buf []byte, n int32) []byte {
+	return AppendUint32(buf, uint32(n))
+}
+
+// AppendInt64 appends n to buf in big-endian order.
+func AppendInt64(buf []byte, n int64) []byte {
+	return AppendUint64(buf, uint64(n))
+}
+
+// SetInt32 writes n to the first 4 bytes of buf in big-endian order. It is used to fill in a length
+// after the value has been appended.
+func SetInt32(buf []byte, n int32) {
+	binary.BigEndian.PutUint32(buf, uint32(n))
+}
+
+// AppendLengthPrefixed appends the length of value as an int32 followed by value, or -1 if value
+// is nil, which is how the binary format represents NULL.
+func AppendLengthPrefixed(buf []byte, value []byte) []byte {
+	if value == nil {
+		return AppendInt32(buf, -1)
+	}
+	buf = AppendInt32(buf, int32(len(value)))
+	return append(buf, value...)
+}
+
+// AppendLengthPrefixedString appends the length of s as an int32 followed by s.
+func AppendLengthPrefixedString(buf []byte, s string) []byte {
+	buf = AppendInt32(buf, int32(len(s)))
+	return append(buf, s...)
+}
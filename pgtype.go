@@ -15,7 +15,9 @@ func codecScan(codec pgtype.Codec, m *pgtype.Map, oid uint32, format int16, src
 	return scanPlan.Scan(src, dst)
 }
 
-func codecDecodeToTextFormat(codec pgtype.Codec, m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+// codecDecodeToTextFormat returns src in the text format. If pool is not nil, it is used for the
+// temporary buffer used to convert from the binary format.
+func codecDecodeToTextFormat(codec pgtype.Codec, m *pgtype.Map, oid uint32, format int16, src []byte, pool BufferPool) (driver.Value, error) {
 	if src == nil {
 		return nil, nil
 	}
@@ -27,10 +29,28 @@ func codecDecodeToTextFormat(codec pgtype.Codec, m *pgtype.Map, oid uint32, form
 		if err != nil {
 			return nil, err
 		}
-		buf, err := m.Encode(oid, pgtype.TextFormatCode, value, nil)
+		var pooled *[]byte
+		var buf []byte
+		if pool != nil {
+			pooled = pool.Get()
+			if pooled != nil {
+				buf = (*pooled)[:0]
+			}
+		}
+		buf, err = m.Encode(oid, pgtype.TextFormatCode, value, buf)
 		if err != nil {
 			return nil, err
 		}
-		return string(buf), nil
+		if pool == nil {
+			// buf was allocated by Encode and is not used again
+			return ownedBytesToString(buf), nil
+		}
+		out := string(buf)
+		if pooled == nil {
+			pooled = new([]byte)
+		}
+		*pooled = buf
+		pool.Put(pooled)
+		return out, nil
 	}
 }
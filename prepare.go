@@ -0,0 +1,88 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// errHstoreNotRegistered is returned by helpers that require the hstore type to be registered.
+var errHstoreNotRegistered = errors.New("hstore type is not registered on the connection: call RegisterHstore first")
+
+// PrepareWithHstoreParams prepares sql as the statement name on conn, where the parameters with
+// the 1-based indexes in hstoreParams (e.g. 2 for $2) must have type hstore. The hstore type must
+// already be registered on conn (see RegisterHstore). The statement is first prepared unnamed with
+// explicit parameter OIDs, so the server reports type mismatches (e.g. using an hstore parameter
+// with a text operator) at prepare time. It is then prepared as name with pgx's statement cache,
+// so it can be executed by name with conn.Exec or conn.Query. It returns an error if the server
+// infers a different type for an hstore parameter, which can be fixed with a cast (e.g. $1::hstore),
+// and deallocates the statement.
+//
+// If name is empty, the statement is only checked, which is useful to validate statements that
+// will be run with QueryExecModeExec or through a pooler that does not support named statements.
+func PrepareWithHstoreParams(
+	ctx context.Context, conn *pgx.Conn, name string, sql string, hstoreParams ...int,
+) (*pgconn.StatementDescription, error) {
+	hstoreType, ok := conn.TypeMap().TypeForName("hstore")
+	if !ok {
+		return nil, errHstoreNotRegistered
+	}
+
+	maxParam := 0
+	for _, param := range hstoreParams {
+		if param < 1 {
+			return nil, fmt.Errorf("invalid hstore parameter index %d: must be >= 1", param)
+		}
+		if param > maxParam {
+			maxParam = param
+		}
+	}
+	// zero OIDs are unspecified: the server infers their types
+	paramOIDs := make([]uint32, maxParam)
+	for _, param := range hstoreParams {
+		paramOIDs[param-1] = hstoreType.OID
+	}
+
+	sd, err := conn.PgConn().Prepare(ctx, "", sql, paramOIDs)
+	if err != nil {
+		return nil, fmt.Errorf("prepare with hstore parameters %v: %w", hstoreParams, err)
+	}
+	if name == "" {
+		return sd, nil
+	}
+
+	sd, err = conn.Prepare(ctx, name, sql)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHstoreParams(conn, sd, hstoreType.OID, hstoreParams); err != nil {
+		// do not leave a statement that will be executed with the wrong parameter types
+		if deallocateErr := conn.Deallocate(ctx, name); deallocateErr != nil {
+			return nil, errors.Join(err, deallocateErr)
+		}
+		return nil, err
+	}
+	return sd, nil
+}
+
+// checkHstoreParams returns an error if the server did not infer hstoreOID for all hstoreParams.
+func checkHstoreParams(conn *pgx.Conn, sd *pgconn.StatementDescription, hstoreOID uint32, hstoreParams []int) error {
+	for _, param := range hstoreParams {
+		if param > len(sd.ParamOIDs) {
+			return fmt.Errorf("hstore parameter $%d: statement only has %d parameters", param, len(sd.ParamOIDs))
+		}
+		inferredOID := sd.ParamOIDs[param-1]
+		if inferredOID != hstoreOID {
+			inferredName := fmt.Sprintf("OID %d", inferredOID)
+			if inferredType, ok := conn.TypeMap().TypeForOID(inferredOID); ok {
+				inferredName = inferredType.Name
+			}
+			return fmt.Errorf("hstore parameter $%d: server inferred type %s; use an explicit cast ($%d::hstore)",
+				param, inferredName, param)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestPrepareWithHstoreParams(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+	hstoreType, ok := conn.TypeMap().TypeForName("hstore")
+	if !ok {
+		t.Fatal("hstore not registered")
+	}
+	// preparedCount returns the number of server prepared statements named name.
+	preparedCount := func(name string) int {
+		t.Helper()
+		var count int
+		err := conn.QueryRow(ctx, `select count(*) from pg_prepared_statements where name = $1`, name).Scan(&count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	sd, err := pgxtypefaster.PrepareWithHstoreParams(ctx, conn, "keys", `select akeys($1) where $2`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sd.ParamOIDs) != 2 || sd.ParamOIDs[0] != hstoreType.OID {
+		t.Errorf("ParamOIDs=%v; expected hstore for $1", sd.ParamOIDs)
+	}
+	var keys []string
+	err = conn.QueryRow(ctx, "keys", pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}, true).Scan(&keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "a" {
+		t.Errorf("keys=%#v", keys)
+	}
+
+	// an empty name only checks the statement
+	sd, err = pgxtypefaster.PrepareWithHstoreParams(ctx, conn, "", `select akeys($1)`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sd.ParamOIDs) != 1 || sd.ParamOIDs[0] != hstoreType.OID {
+		t.Errorf("ParamOIDs=%v; expected hstore", sd.ParamOIDs)
+	}
+	_, err = pgxtypefaster.PrepareWithHstoreParams(ctx, conn, "", `select upper($1)`, 1)
+	if err == nil {
+		t.Error("using an hstore parameter as text expected error")
+	}
+	if count := preparedCount("keys"); count != 1 {
+		t.Errorf("found %d statements named keys; expected 1", count)
+	}
+
+	for _, index := range []int{0, -1} {
+		_, err = pgxtypefaster.PrepareWithHstoreParams(ctx, conn, "invalid", `select akeys($1)`, index)
+		if err == nil || !strings.Contains(err.Error(), "invalid hstore parameter index") {
+			t.Errorf("index %d: err=%v; expected invalid index", index, err)
+		}
+	}
+
+	// errors after preparing the named statement must deallocate it
+	for _, test := range []struct {
+		sql          string
+		hstoreParams []int
+		expectedErr  string
+	}{
+		{`select $1`, []int{1}, "server inferred type text"},
+		{`select akeys($1)`, []int{1, 2}, "statement only has 1 parameters"},
+	} {
+		_, err = pgxtypefaster.PrepareWithHstoreParams(ctx, conn, "failed", test.sql, test.hstoreParams...)
+		if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("%s: err=%v; expected %#v", test.sql, err, test.expectedErr)
+		}
+		if count := preparedCount("failed"); count != 0 {
+			t.Errorf("%s: the failed statement was not deallocated", test.sql)
+		}
+	}
+
+	// a connection without RegisterHstore
+	unregistered, err := pgx.Connect(ctx, conn.Config().ConnString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unregistered.Close(ctx)
+	_, err = pgxtypefaster.PrepareWithHstoreParams(ctx, unregistered, "keys", `select akeys($1)`, 1)
+	if err == nil || !strings.Contains(err.Error(), "RegisterHstore") {
+		t.Errorf("unregistered err=%v; expected not registered", err)
+	}
+}
@@ -0,0 +1,141 @@
+package pgxtypefaster
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// HstoreProfile describes a sample of hstore values, to tune the codecs for an application's data.
+// Create one with ProfileHstores, for example with the pgxtypefasterbench profile command, save it
+// as JSON, then load it at startup with LoadHstoreProfile and use it with WithProfile.
+type HstoreProfile struct {
+	// Samples is the number of non-NULL values in the sample.
+	Samples int `json:"samples"`
+	// MeanPairs is the mean number of key/value pairs.
+	MeanPairs float64 `json:"mean_pairs"`
+	// MaxPairs is the largest number of key/value pairs.
+	MaxPairs int `json:"max_pairs"`
+	// TextBytesPerPair is the mean length of the text format per key/value pair, including the
+	// separators.
+	TextBytesPerPair float64 `json:"text_bytes_per_pair"`
+	// MeanKeyBytes and MeanValueBytes are the mean lengths of keys and non-NULL values.
+	MeanKeyBytes   float64 `json:"mean_key_bytes"`
+	MeanValueBytes float64 `json:"mean_value_bytes"`
+	// NullFraction is the fraction of values that are NULL.
+	NullFraction float64 `json:"null_fraction"`
+	// EscapeFraction is the fraction of hstores whose text format contains escapes.
+	EscapeFraction float64 `json:"escape_fraction"`
+}
+
+// ProfileHstores returns a profile of samples, which are hstores in the text format.
+func ProfileHstores(samples []string) (HstoreProfile, error) {
+	var profile HstoreProfile
+	var pairs, keyBytes, valueBytes, nonNullValues, nullValues, textBytes, escaped int
+	for _, sample := range samples {
+		h, err := parseHstore(sample, nil)
+		if err != nil {
+			return HstoreProfile{}, err
+		}
+		profile.Samples++
+		pairs += len(h)
+		if len(h) > profile.MaxPairs {
+			profile.MaxPairs = len(h)
+		}
+		if len(h) > 0 {
+			textBytes += len(sample)
+		}
+		if strings.IndexByte(sample, '\\') >= 0 {
+			escaped++
+		}
+		for k, v := range h {
+			keyBytes += len(k)
+			if v.Valid {
+				valueBytes += len(v.String)
+				nonNullValues++
+			} else {
+				nullValues++
+			}
+		}
+	}
+
+	if profile.Samples > 0 {
+		profile.MeanPairs = float64(pairs) / float64(profile.Samples)
+		profile.EscapeFraction = float64(escaped) / float64(profile.Samples)
+	}
+	if pairs > 0 {
+		profile.TextBytesPerPair = float64(textBytes) / float64(pairs)
+		profile.MeanKeyBytes = float64(keyBytes) / float64(pairs)
+		profile.NullFraction = float64(nullValues) / float64(pairs)
+	}
+	if nonNullValues > 0 {
+		profile.MeanValueBytes = float64(valueBytes) / float64(nonNullValues)
+	}
+	return profile, nil
+}
+
+// LoadHstoreProfile reads a profile saved as JSON from path.
+func LoadHstoreProfile(path string) (HstoreProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return HstoreProfile{}, err
+	}
+	var profile HstoreProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return HstoreProfile{}, err
+	}
+	return profile, nil
+}
+
+// WithProfile tunes the codec for data like profile. When parsing the text format, it estimates
+// the number of pairs from the length of the value and profile.TextBytesPerPair, instead of
+// counting the '>' characters. This avoids a pass over the value, and reduces the memory
+// allocated for data that contains many '>' characters.
+func WithProfile(profile HstoreProfile) CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.textBytesPerPair = profile.TextBytesPerPair
+	}
+}
+
+// minTextPairLen is the length of the shortest pair in the text format that Postgres outputs,
+// including the separator: `""=>"", `.
+const minTextPairLen = 8
+
+// maxTextPairs returns the maximum number of pairs to allocate for a value of textLen bytes in the
+// text format.
+func maxTextPairs(textLen int) int {
+	return initialPairs((textLen + len(", ")) / minTextPairLen)
+}
+
+// estimatePairs returns the capacity to allocate for the pairs of s, in the text format. It is
+// never more than the number of pairs that fit in len(s).
+func (cfg *codecConfig) estimatePairs(s string) int {
+	maxPairs := maxTextPairs(len(s))
+	if cfg != nil && cfg.textBytesPerPair > 0 {
+		// limit the float before converting it, since a tiny textBytesPerPair could overflow int
+		estimate := float64(len(s))/cfg.textBytesPerPair + 1
+		if estimate > float64(maxPairs) {
+			return maxPairs
+		}
+		return int(estimate)
+	}
+	// This is an over-estimate of the number of key/value pairs. Use '>' because I am guessing it
+	// is less likely to occur in keys/values than '=' or ','.
+	estimate := strings.Count(s, ">")
+	if estimate > maxPairs {
+		return maxPairs
+	}
+	return estimate
+}
+
+// shrinkMinPairs and shrinkRatio select parsed hstores to copy to smaller maps: if the estimate was
+// at least shrinkMinPairs and more than shrinkRatio times the actual number of pairs. Go maps do
+// not shrink, so otherwise a value with many '>' characters would retain a much larger map.
+const (
+	shrinkMinPairs = 64
+	shrinkRatio    = 4
+)
+
+func needsShrink(estimate int, actual int) bool {
+	return estimate >= shrinkMinPairs && estimate > shrinkRatio*actual
+}
@@ -0,0 +1,120 @@
+package pgxtypefaster_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestProfileHstores(t *testing.T) {
+	samples := []string{
+		`"a"=>"1", "b"=>NULL`,
+		``,
+		`"key"=>"va\"lue"`,
+	}
+	profile, err := pgxtypefaster.ProfileHstores(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pgxtypefaster.HstoreProfile{
+		Samples:          3,
+		MeanPairs:        1,
+		MaxPairs:         2,
+		TextBytesPerPair: float64(len(samples[0])+len(samples[2])) / 3,
+		MeanKeyBytes:     5.0 / 3,
+		MeanValueBytes:   3.5,
+		NullFraction:     1.0 / 3,
+		EscapeFraction:   1.0 / 3,
+	}
+	if profile != expected {
+		t.Errorf("ProfileHstores=%#v; expected %#v", profile, expected)
+	}
+
+	if _, err := pgxtypefaster.ProfileHstores([]string{"invalid"}); err == nil {
+		t.Error("invalid sample expected error")
+	}
+
+	// save and load
+	path := filepath.Join(t.TempDir(), "profile.json")
+	data, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := pgxtypefaster.LoadHstoreProfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != profile {
+		t.Errorf("LoadHstoreProfile=%#v; expected %#v", loaded, profile)
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	// a profile that underestimates and one that overestimates the pairs must both parse correctly
+	for _, bytesPerPair := range []float64{1000, 1} {
+		m := newOptionsTypeMap(pgxtypefaster.WithProfile(pgxtypefaster.HstoreProfile{TextBytesPerPair: bytesPerPair}))
+		for _, input := range []string{``, `"a"=>"1"`, `"a>"=>"1>", "b"=>NULL, "c"=>""`} {
+			var h pgxtypefaster.Hstore
+			if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(input), &h); err != nil {
+				t.Fatal(err)
+			}
+			var compat pgxtypefaster.HstoreCompat
+			if err := m.Scan(testHstoreOID+1, pgtype.TextFormatCode, []byte(input), &compat); err != nil {
+				t.Fatal(err)
+			}
+			var expected pgxtypefaster.Hstore
+			if err := expected.Scan(input); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(h, expected) || !reflect.DeepEqual(pgxtypefaster.PGXToFasterHstore(compat), expected) {
+				t.Errorf("bytesPerPair=%f input=%#v: got %#v %#v; expected %#v",
+					bytesPerPair, input, h, compat, expected)
+			}
+		}
+	}
+}
+
+func TestParseManyGreaterThan(t *testing.T) {
+	// '>' in values makes the default estimate far too large: the parsed maps must not retain it
+	input := `"a"=>"` + strings.Repeat(">", 10000) + `", "b"=>NULL`
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText(strings.Repeat(">", 10000)), "b": pgtype.Text{}}
+	m := newOptionsTypeMap()
+
+	const numValues = 50
+	hstores := make([]pgxtypefaster.Hstore, numValues)
+	compats := make([]pgxtypefaster.HstoreCompat, numValues)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := range hstores {
+		if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(input), &hstores[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Scan(testHstoreOID+1, pgtype.TextFormatCode, []byte(input), &compats[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	if !reflect.DeepEqual(hstores[0], expected) || !reflect.DeepEqual(compats[0], fasterToCompat(expected)) {
+		t.Errorf("parsed %#v %#v", hstores[0], compats[0])
+	}
+	// each value retains its text, and small maps
+	const maxRetained = 2 * numValues * 2 * 10000
+	if retained := int64(after.HeapAlloc) - int64(before.HeapAlloc); retained > maxRetained {
+		t.Errorf("parsed values retained %d bytes; expected at most %d", retained, maxRetained)
+	}
+	runtime.KeepAlive(hstores)
+	runtime.KeepAlive(compats)
+}
@@ -0,0 +1,131 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NullPolicy controls how NULL hstore values are converted to types that cannot represent them,
+// such as map[string]string.
+type NullPolicy int
+
+const (
+	// NullSkip omits keys with NULL values.
+	NullSkip NullPolicy = iota
+	// NullEmpty converts NULL values to the empty string.
+	NullEmpty
+	// NullError returns an error if any value is NULL.
+	NullError
+)
+
+func (p NullPolicy) String() string {
+	switch p {
+	case NullSkip:
+		return "NullSkip"
+	case NullEmpty:
+		return "NullEmpty"
+	case NullError:
+		return "NullError"
+	}
+	return fmt.Sprintf("NullPolicy(%d)", int(p))
+}
+
+// NullValueError is returned when a NULL value is found and the NullPolicy is NullError.
+type NullValueError struct {
+	Key string
+}
+
+func (e *NullValueError) Error() string {
+	return fmt.Sprintf("hstore key %#v has a NULL value", e.Key)
+}
+
+// toStringMap converts h to a map[string]string, handling NULLs according to policy.
+func toStringMap(h Hstore, policy NullPolicy) (map[string]string, error) {
+	if h == nil {
+		return nil, nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if v.Valid {
+			out[k] = v.String
+			continue
+		}
+		switch policy {
+		case NullSkip:
+		case NullEmpty:
+			out[k] = ""
+		case NullError:
+			return nil, &NullValueError{k}
+		default:
+			return nil, fmt.Errorf("unsupported %s", policy)
+		}
+	}
+	return out, nil
+}
+
+// fromStringMap converts m to an Hstore with no NULL values.
+func fromStringMap(m map[string]string) Hstore {
+	if m == nil {
+		return nil
+	}
+	h := make(Hstore, len(m))
+	for k, v := range m {
+		h[k] = NewText(v)
+	}
+	return h
+}
+
+// ToProtoMap converts h to a map suitable for a protobuf map<string, string> field. Protobuf maps
+// cannot contain NULL values, so they are handled according to policy.
+func ToProtoMap(h Hstore, policy NullPolicy) (map[string]string, error) {
+	return toStringMap(h, policy)
+}
+
+// FromProtoMap converts a protobuf map<string, string> field to an Hstore. The result never
+// contains NULL values.
+func FromProtoMap(m map[string]string) Hstore {
+	return fromStringMap(m)
+}
+
+// ToProtoNullable converts h to the fields of a protobuf message that preserves NULL values:
+//
+//	message NullableHstore {
+//	  map<string, string> values = 1;
+//	  repeated string null_keys = 2;
+//	}
+//
+// Keys with non-NULL values are returned in values, and keys with NULL values are returned in
+// nullKeys in sorted order, so the output is deterministic.
+func ToProtoNullable(h Hstore) (values map[string]string, nullKeys []string) {
+	if h == nil {
+		return nil, nil
+	}
+	values = make(map[string]string, len(h))
+	for k, v := range h {
+		if v.Valid {
+			values[k] = v.String
+		} else {
+			nullKeys = append(nullKeys, k)
+		}
+	}
+	sort.Strings(nullKeys)
+	return values, nullKeys
+}
+
+// FromProtoNullable converts the fields of a NullableHstore protobuf message (see
+// ToProtoNullable) to an Hstore. If a key is in both values and nullKeys, the value is NULL.
+func FromProtoNullable(values map[string]string, nullKeys []string) Hstore {
+	if values == nil && nullKeys == nil {
+		return nil
+	}
+	h := make(Hstore, len(values)+len(nullKeys))
+	for k, v := range values {
+		h[k] = NewText(v)
+	}
+	for _, k := range nullKeys {
+		h[k] = pgtype.Text{}
+	}
+	return h
+}
@@ -0,0 +1,44 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestProtoMap(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+
+	out, err := pgxtypefaster.ToProtoMap(h, pgxtypefaster.NullSkip)
+	if err != nil || !reflect.DeepEqual(out, map[string]string{"a": "1"}) {
+		t.Errorf("NullSkip: out=%#v err=%v", out, err)
+	}
+	out, err = pgxtypefaster.ToProtoMap(h, pgxtypefaster.NullEmpty)
+	if err != nil || !reflect.DeepEqual(out, map[string]string{"a": "1", "b": ""}) {
+		t.Errorf("NullEmpty: out=%#v err=%v", out, err)
+	}
+	_, err = pgxtypefaster.ToProtoMap(h, pgxtypefaster.NullError)
+	var nullErr *pgxtypefaster.NullValueError
+	if !errors.As(err, &nullErr) || nullErr.Key != "b" {
+		t.Errorf("NullError: err=%v", err)
+	}
+
+	roundTrip := pgxtypefaster.FromProtoMap(map[string]string{"a": "1"})
+	if !reflect.DeepEqual(roundTrip, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1")}) {
+		t.Errorf("FromProtoMap=%#v", roundTrip)
+	}
+
+	values, nullKeys := pgxtypefaster.ToProtoNullable(h)
+	if !reflect.DeepEqual(values, map[string]string{"a": "1"}) || !reflect.DeepEqual(nullKeys, []string{"b"}) {
+		t.Errorf("ToProtoNullable: values=%#v nullKeys=%#v", values, nullKeys)
+	}
+	if nullable := pgxtypefaster.FromProtoNullable(values, nullKeys); !reflect.DeepEqual(nullable, h) {
+		t.Errorf("FromProtoNullable=%#v; expected %#v", nullable, h)
+	}
+	if pgxtypefaster.FromProtoNullable(nil, nil) != nil {
+		t.Error("FromProtoNullable(nil, nil) must return nil")
+	}
+}
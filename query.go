@@ -0,0 +1,52 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// checkHstoreRegistered returns errHstoreNotRegistered if hstore is not registered on conn.
+func checkHstoreRegistered(conn *pgx.Conn) error {
+	if _, ok := conn.TypeMap().TypeForName("hstore"); !ok {
+		return errHstoreNotRegistered
+	}
+	return nil
+}
+
+// QueryHstore runs sql, which must return one column of type hstore, and returns the value from
+// the first row. A NULL value is returned as a nil Hstore. It returns pgx.ErrNoRows if the query
+// returns no rows. The hstore type must already be registered on conn (see RegisterHstore).
+func QueryHstore(ctx context.Context, conn *pgx.Conn, sql string, args ...any) (Hstore, error) {
+	if err := checkHstoreRegistered(conn); err != nil {
+		return nil, err
+	}
+	var h Hstore
+	err := conn.QueryRow(ctx, sql, args...).Scan(&h)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// QueryHstores runs sql, which must return one column of type hstore, and returns the values
+// from all rows. NULL values are returned as nil Hstores. It returns an empty slice if the query
+// returns no rows. The hstore type must already be registered on conn (see RegisterHstore).
+func QueryHstores(ctx context.Context, conn *pgx.Conn, sql string, args ...any) ([]Hstore, error) {
+	if err := checkHstoreRegistered(conn); err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	if columns := len(rows.FieldDescriptions()); columns != 1 {
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("QueryHstores: query must return 1 column; returned %d", columns)
+	}
+	return CollectHstoreColumn(rows, 0)
+}
@@ -0,0 +1,96 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evanj/hacks/postgrestest"
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+// newTestConn starts Postgres and returns a connection with the hstore extension created and
+// registered. It skips the test if Postgres is not installed.
+func newTestConn(t *testing.T) *pgx.Conn {
+	t.Helper()
+	bindir, err := exec.Command("pg_config", "--bindir").Output()
+	if err != nil {
+		t.Skipf("Postgres is not installed: pg_config failed: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(strings.TrimSpace(string(bindir)), "initdb")); err != nil {
+		t.Skipf("Postgres is not installed: %s", err)
+	}
+	pgURL := postgrestest.New(t)
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, pgURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(ctx) })
+
+	if _, err := conn.Exec(ctx, "create extension hstore"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pgxtypefaster.RegisterHstore(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestQueryHstore(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	h, err := pgxtypefaster.QueryHstore(ctx, conn, `select $1::hstore`, "a=>b, c=>NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": {}}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("QueryHstore=%#v; expected %#v", h, expected)
+	}
+
+	h, err = pgxtypefaster.QueryHstore(ctx, conn, `select null::hstore`)
+	if err != nil || h != nil {
+		t.Errorf("QueryHstore(NULL)=%#v, %v; expected nil", h, err)
+	}
+
+	_, err = pgxtypefaster.QueryHstore(ctx, conn, `select ''::hstore where false`)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("QueryHstore(no rows) err=%v; expected ErrNoRows", err)
+	}
+
+	hs, err := pgxtypefaster.QueryHstores(ctx, conn,
+		`select v::hstore from (values ('a=>1'), (null), ('')) t (v)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedAll := []pgxtypefaster.Hstore{{"a": pgxtypefaster.NewText("1")}, nil, {}}
+	if !reflect.DeepEqual(hs, expectedAll) {
+		t.Errorf("QueryHstores=%#v; expected %#v", hs, expectedAll)
+	}
+
+	_, err = pgxtypefaster.QueryHstores(ctx, conn, `select ''::hstore, 1`)
+	if err == nil {
+		t.Error("QueryHstores with 2 columns expected error")
+	}
+	_, err = pgxtypefaster.QueryHstores(ctx, conn, `select * from does_not_exist`)
+	if err == nil {
+		t.Error("QueryHstores with invalid query expected error")
+	}
+
+	unregistered, err := pgx.Connect(ctx, conn.Config().ConnString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unregistered.Close(ctx)
+	if _, err := pgxtypefaster.QueryHstore(ctx, unregistered, `select ''::hstore`); err == nil {
+		t.Error("QueryHstore without RegisterHstore expected error")
+	}
+}
@@ -0,0 +1,54 @@
+package pgxtypefaster
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ToRedisHash converts h to a map suitable for a Redis hash (e.g. HSET). Redis hashes cannot
+// contain NULL values, so keys with NULL values are skipped. Use ToRedisHashSentinel to preserve
+// them.
+func ToRedisHash(h Hstore) map[string]string {
+	// cannot fail with NullSkip
+	out, _ := toStringMap(h, NullSkip)
+	return out
+}
+
+// FromRedisHash converts a Redis hash (e.g. from HGETALL) to an Hstore with no NULL values.
+func FromRedisHash(m map[string]string) Hstore {
+	return fromStringMap(m)
+}
+
+// ToRedisHashSentinel converts h to a map suitable for a Redis hash, encoding NULL values as
+// nullSentinel. The sentinel must not be a value that is stored in the Hstore, or it will be
+// decoded as NULL by FromRedisHashSentinel.
+func ToRedisHashSentinel(h Hstore, nullSentinel string) map[string]string {
+	if h == nil {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if v.Valid {
+			out[k] = v.String
+		} else {
+			out[k] = nullSentinel
+		}
+	}
+	return out
+}
+
+// FromRedisHashSentinel converts a Redis hash created by ToRedisHashSentinel to an Hstore,
+// decoding values equal to nullSentinel as NULL.
+func FromRedisHashSentinel(m map[string]string, nullSentinel string) Hstore {
+	if m == nil {
+		return nil
+	}
+	h := make(Hstore, len(m))
+	for k, v := range m {
+		if v == nullSentinel {
+			h[k] = pgtype.Text{}
+		} else {
+			h[k] = NewText(v)
+		}
+	}
+	return h
+}
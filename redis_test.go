@@ -0,0 +1,30 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRedisHash(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+
+	out := pgxtypefaster.ToRedisHash(h)
+	if !reflect.DeepEqual(out, map[string]string{"a": "1"}) {
+		t.Errorf("ToRedisHash=%#v", out)
+	}
+	if fromRedis := pgxtypefaster.FromRedisHash(out); !reflect.DeepEqual(fromRedis, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1")}) {
+		t.Errorf("FromRedisHash=%#v", fromRedis)
+	}
+
+	const sentinel = "\x00NULL"
+	out = pgxtypefaster.ToRedisHashSentinel(h, sentinel)
+	if !reflect.DeepEqual(out, map[string]string{"a": "1", "b": sentinel}) {
+		t.Errorf("ToRedisHashSentinel=%#v", out)
+	}
+	if fromRedis := pgxtypefaster.FromRedisHashSentinel(out, sentinel); !reflect.DeepEqual(fromRedis, h) {
+		t.Errorf("FromRedisHashSentinel=%#v; expected %#v", fromRedis, h)
+	}
+}
@@ -0,0 +1,263 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Registry holds the configured codecs for a database, and the OIDs of their types, so they can
+// be registered on each connection. Codec options that hold state, such as buffer pools, encode
+// caches, and allocators, are shared by all connections. The OIDs are queried by the first call to
+// ApplyConn and reused, so a Registry must only be used with a single database. It is safe to use
+// concurrently, so ApplyConn can be used as pgxpool.Config.AfterConnect.
+type Registry struct {
+	opts  []CodecOption
+	types []registryType
+
+	mu   sync.Mutex
+	oids map[string]uint32
+}
+
+type registryType struct {
+	name  string
+	codec pgtype.Codec
+	// forConn returns the codec for a connection. It is nil if the codec does not depend on it.
+	forConn func(conn *pgx.Conn) pgtype.Codec
+}
+
+// NewRegistry returns an empty Registry. opts are used by AddHstore and AddHstoreCompat.
+func NewRegistry(opts ...CodecOption) *Registry {
+	return &Registry{opts: opts, oids: map[string]uint32{}}
+}
+
+// AddType adds the type name with codec. The name can be schema-qualified. It must be called before
+// the Registry is applied.
+func (r *Registry) AddType(name string, codec pgtype.Codec) {
+	r.types = append(r.types, registryType{name: name, codec: codec})
+}
+
+// AddHstore adds the hstore type with HstoreCodec configured with the Registry's options.
+func (r *Registry) AddHstore() {
+	r.AddHstoreAs("hstore")
+}
+
+// AddHstoreAs adds the type name with HstoreCodec configured with the Registry's options. Use it
+// for types with the same representation as hstore, such as a domain over hstore: parameters cast
+// to the domain have its OID, not the hstore OID. The name can be schema-qualified.
+func (r *Registry) AddHstoreAs(name string) {
+	r.types = append(r.types, registryType{
+		name:  name,
+		codec: NewHstoreCodec(r.opts...),
+		forConn: func(conn *pgx.Conn) pgtype.Codec {
+			if extra := hstoreRegisterOptions(conn); extra != nil {
+				return NewHstoreCodec(append(r.opts[:len(r.opts):len(r.opts)], extra...)...)
+			}
+			return nil
+		},
+	})
+}
+
+// AddHstoreCompat adds the hstore type with HstoreCompatCodec configured with the Registry's
+// options.
+func (r *Registry) AddHstoreCompat() {
+	r.AddHstoreCompatAs("hstore")
+}
+
+// AddHstoreCompatAs adds the type name with HstoreCompatCodec configured with the Registry's
+// options, like AddHstoreAs.
+func (r *Registry) AddHstoreCompatAs(name string) {
+	r.types = append(r.types, registryType{
+		name:  name,
+		codec: NewHstoreCompatCodec(r.opts...),
+		forConn: func(conn *pgx.Conn) pgtype.Codec {
+			if extra := hstoreRegisterOptions(conn); extra != nil {
+				return NewHstoreCompatCodec(append(r.opts[:len(r.opts):len(r.opts)], extra...)...)
+			}
+			return nil
+		},
+	})
+}
+
+// PoolAfterConnect returns a function for pgxpool.Config.AfterConnect that registers HstoreCodec
+// configured with opts on each connection. The hstore OID is queried by the first connection and
+// reused by later connections, so the pool must only connect to a single database. It is a Registry
+// with AddHstore.
+//
+//	config.AfterConnect = pgxtypefaster.PoolAfterConnect()
+func PoolAfterConnect(opts ...CodecOption) func(ctx context.Context, conn *pgx.Conn) error {
+	r := NewRegistry(opts...)
+	r.AddHstore()
+	return r.ApplyConn
+}
+
+// typeCodecs adds the codecs registered by RegisterTypes, by type name.
+var typeCodecs = map[string]func(r *Registry){
+	"hstore": (*Registry).AddHstore,
+}
+
+// RegisterTypes registers the codecs for the types names on conn's type map, querying the OIDs of
+// all of them in one round trip, unless they are cached in DefaultOIDCache. The supported names
+// are "hstore", which registers HstoreCodec. Use a Registry to add other codecs.
+func RegisterTypes(ctx context.Context, conn *pgx.Conn, names ...string) error {
+	r := NewRegistry()
+	for _, name := range names {
+		add, ok := typeCodecs[name]
+		if !ok {
+			return fmt.Errorf("no codec for postgres type %s", name)
+		}
+		add(r)
+	}
+	return r.applyConnCached(ctx, conn, names)
+}
+
+// RegisterHstoreAs registers HstoreCodec for the Postgres type name on conn's type map, such as a
+// domain over hstore, like Registry.AddHstoreAs. The OID is cached in DefaultOIDCache if it is set.
+func RegisterHstoreAs(ctx context.Context, conn *pgx.Conn, name string) error {
+	r := NewRegistry()
+	r.AddHstoreAs(name)
+	return r.applyConnCached(ctx, conn, []string{name})
+}
+
+// applyConnCached calls ApplyConn, using the OIDs of the types names cached in DefaultOIDCache,
+// and caching the OIDs it queries.
+func (r *Registry) applyConnCached(ctx context.Context, conn *pgx.Conn, names []string) error {
+	for _, name := range names {
+		if oid, ok := DefaultOIDCache.Lookup(conn, name); ok {
+			r.SetOID(name, oid)
+		}
+	}
+	if err := r.ApplyConn(ctx, conn); err != nil {
+		return err
+	}
+	for _, name := range names {
+		oid, _ := r.OID(name)
+		DefaultOIDCache.Store(conn, name, oid)
+	}
+	return nil
+}
+
+// SetOID sets the OID of the type name, so it does not need to be queried.
+func (r *Registry) SetOID(name string, oid uint32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.oids[name] = oid
+}
+
+// OID returns the OID of the type name, if it is known.
+func (r *Registry) OID(name string) (uint32, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oid, ok := r.oids[name]
+	return oid, ok
+}
+
+// missingOIDs returns the names of the types with unknown OIDs.
+func (r *Registry) missingOIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var missing []string
+	for _, t := range r.types {
+		if _, ok := r.oids[t.name]; !ok {
+			missing = append(missing, t.name)
+		}
+	}
+	return missing
+}
+
+// resolveOIDs queries the OIDs of types that are not known.
+func (r *Registry) resolveOIDs(ctx context.Context, conn *pgx.Conn) error {
+	missing := r.missingOIDs()
+	if len(missing) == 0 {
+		return nil
+	}
+	resolved, err := queryOIDs(ctx, conn, missing)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, oid := range resolved {
+		r.oids[name] = oid
+	}
+	return nil
+}
+
+// queryOIDs returns the OIDs of the types names, like lookupOIDs. It returns an error if a type
+// does not exist.
+func queryOIDs(ctx context.Context, conn *pgx.Conn, names []string) (map[string]uint32, error) {
+	resolved, err := lookupOIDs(ctx, conn, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, ok := resolved[name]; !ok {
+			if name == "hstore" {
+				return nil, ErrHstoreDoesNotExist
+			}
+			return nil, fmt.Errorf("postgres type %s does not exist", name)
+		}
+	}
+	return resolved, nil
+}
+
+// lookupOIDs returns the OIDs of the types names with one query. It finds each type using
+// search_path, like a type name in a query, except that if hstore is not in a schema on the
+// search_path, it returns the hstore type in any schema. All the Register functions use it, so
+// they find the same types. Types that do not exist are not in the result.
+func lookupOIDs(ctx context.Context, conn *pgx.Conn, names []string) (map[string]uint32, error) {
+	rows, err := conn.Query(ctx, `select name, coalesce(to_regtype(name)::oid,
+		(select oid from pg_type where name = 'hstore' and typname = 'hstore' order by oid limit 1))
+		from unnest($1::text[]) as t (name)`, names)
+	if err != nil {
+		return nil, err
+	}
+	resolved := map[string]uint32{}
+	var name string
+	var oid *uint32
+	_, err = pgx.ForEachRow(rows, []any{&name, &oid}, func() error {
+		if oid != nil {
+			resolved[name] = *oid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// ApplyConn registers the types on conn's type map. It queries the OIDs that are not known.
+func (r *Registry) ApplyConn(ctx context.Context, conn *pgx.Conn) error {
+	if err := r.resolveOIDs(ctx, conn); err != nil {
+		return err
+	}
+	return r.apply(conn.TypeMap(), conn)
+}
+
+// ApplyTypeMap registers the types on m. All OIDs must be known, either from a previous call to
+// ApplyConn, or from SetOID.
+func (r *Registry) ApplyTypeMap(m *pgtype.Map) error {
+	return r.apply(m, nil)
+}
+
+func (r *Registry) apply(m *pgtype.Map, conn *pgx.Conn) error {
+	for _, t := range r.types {
+		oid, ok := r.OID(t.name)
+		if !ok {
+			return fmt.Errorf("OID of type %s is unknown: call ApplyConn or SetOID", t.name)
+		}
+		codec := t.codec
+		if conn != nil && t.forConn != nil {
+			if connCodec := t.forConn(conn); connCodec != nil {
+				codec = connCodec
+			}
+		}
+		m.RegisterType(&pgtype.Type{Codec: codec, Name: t.name, OID: oid})
+	}
+	return nil
+}
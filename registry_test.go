@@ -0,0 +1,329 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRegistryApplyTypeMap(t *testing.T) {
+	r := pgxtypefaster.NewRegistry(pgxtypefaster.WithMaxPairs(1))
+	r.AddHstore()
+	r.AddType("hstore_compat", pgxtypefaster.HstoreCompatCodec{})
+
+	m := pgtype.NewMap()
+	if err := r.ApplyTypeMap(m); err == nil {
+		t.Fatal("ApplyTypeMap with unknown OIDs expected error")
+	}
+
+	r.SetOID("hstore", testHstoreOID)
+	r.SetOID("hstore_compat", testHstoreOID+1)
+	if oid, ok := r.OID("hstore"); !ok || oid != testHstoreOID {
+		t.Errorf("OID(hstore)=%d, %t", oid, ok)
+	}
+	if err := r.ApplyTypeMap(m); err != nil {
+		t.Fatal(err)
+	}
+
+	hstoreType, ok := m.TypeForName("hstore")
+	if !ok || hstoreType.OID != testHstoreOID {
+		t.Fatalf("hstore not registered: %#v", hstoreType)
+	}
+	// the registry's options apply
+	two := pgxtypefaster.Hstore{"a": {}, "b": {}}
+	if _, err := m.Encode(testHstoreOID, pgtype.BinaryFormatCode, two, nil); err == nil {
+		t.Error("expected WithMaxPairs error")
+	}
+	if _, ok := m.TypeForName("hstore_compat"); !ok {
+		t.Error("hstore_compat not registered")
+	}
+}
+
+func TestRegistryApplyConn(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	r := pgxtypefaster.NewRegistry()
+	r.AddHstoreCompat()
+	if err := r.ApplyConn(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	var h pgxtypefaster.HstoreCompat
+	if err := conn.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 1 || *h["a"] != "b" {
+		t.Errorf("scanned %#v", h)
+	}
+
+	missing := pgxtypefaster.NewRegistry()
+	missing.AddType("does_not_exist", pgxtypefaster.HstoreCodec{})
+	if err := missing.ApplyConn(ctx, conn); err == nil {
+		t.Error("missing type expected error")
+	}
+}
+
+func TestPoolAfterConnect(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	afterConnect := pgxtypefaster.PoolAfterConnect(pgxtypefaster.WithMaxPairs(1))
+	// the second call uses the cached OID
+	for i := 0; i < 2; i++ {
+		if err := afterConnect(ctx, conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var h pgxtypefaster.Hstore
+	if err := conn.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+	// the options are applied
+	if err := conn.QueryRow(ctx, `select 'a=>b, c=>d'::hstore`).Scan(&h); err == nil {
+		t.Error("expected WithMaxPairs error")
+	}
+}
+
+func TestRegisterTypes(t *testing.T) {
+	// unsupported names fail before querying
+	err := pgxtypefaster.RegisterTypes(context.Background(), nil, "hstore", "citext")
+	if err == nil || !strings.Contains(err.Error(), "citext") {
+		t.Errorf("unsupported type: err=%v", err)
+	}
+
+	conn := newTestConn(t)
+	ctx := context.Background()
+	if err := pgxtypefaster.RegisterTypes(ctx, conn, "hstore"); err != nil {
+		t.Fatal(err)
+	}
+	var h pgxtypefaster.Hstore
+	if err := conn.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+}
+
+func TestRegisterHstoreWithOID(t *testing.T) {
+	m := pgtype.NewMap()
+	pgxtypefaster.RegisterHstoreMap(m, testHstoreOID)
+	var h pgxtypefaster.Hstore
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(`"a"=>"b"`), &h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+
+	conn := newTestConn(t)
+	ctx := context.Background()
+	hstoreType, _ := conn.TypeMap().TypeForName("hstore")
+	conn.TypeMap().RegisterType(&pgtype.Type{Codec: pgtype.TextCodec{}, Name: "hstore", OID: hstoreType.OID})
+	pgxtypefaster.RegisterHstoreWithOID(conn, hstoreType.OID)
+	if err := conn.QueryRow(ctx, `select 'c=>d'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"c": pgxtypefaster.NewText("d")}) {
+		t.Errorf("scanned %#v", h)
+	}
+}
+
+func TestNewHstoreType(t *testing.T) {
+	m := pgtype.NewMap()
+	m.RegisterType(pgxtypefaster.NewHstoreType(testHstoreOID, pgxtypefaster.WithSortedKeys()))
+	h := pgxtypefaster.Hstore{"b": pgxtypefaster.NewText("2"), "a": pgxtypefaster.NewText("1")}
+	encoded, err := m.Encode(testHstoreOID, pgtype.TextFormatCode, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `"a"=>"1", "b"=>"2"` {
+		t.Errorf("encoded=%q; the options must apply", encoded)
+	}
+
+	compatType := pgxtypefaster.NewHstoreCompatType(testHstoreOID)
+	if compatType.Name != "hstore" || compatType.OID != testHstoreOID {
+		t.Errorf("NewHstoreCompatType=%#v", compatType)
+	}
+	m = pgtype.NewMap()
+	m.RegisterType(compatType)
+	var compat pgxtypefaster.HstoreCompat
+	if err := m.Scan(testHstoreOID, pgtype.TextFormatCode, []byte(`"a"=>NULL`), &compat); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := compat["a"]; len(compat) != 1 || !ok || v != nil {
+		t.Errorf("scanned %#v", compat)
+	}
+}
+
+func TestRegisterHstoreCreateExtension(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	// the extension exists
+	if err := pgxtypefaster.RegisterHstoreCreateExtension(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	// a database without the extension
+	if _, err := conn.Exec(ctx, "create database no_hstore"); err != nil {
+		t.Fatal(err)
+	}
+	config := conn.Config()
+	config.Database = "no_hstore"
+	other, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close(ctx)
+	if err := pgxtypefaster.RegisterHstoreCreateExtension(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	var h pgxtypefaster.Hstore
+	if err := other.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+}
+
+func TestRegisterHstoreInSchema(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	// a database with hstore in a schema that is not on the search_path
+	if _, err := conn.Exec(ctx, "create database hstore_schema"); err != nil {
+		t.Fatal(err)
+	}
+	config := conn.Config()
+	config.Database = "hstore_schema"
+	other, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close(ctx)
+	for _, sql := range []string{
+		"create schema ext",
+		"create extension hstore schema ext",
+	} {
+		if _, err := other.Exec(ctx, sql); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var extOID uint32
+	if err := other.QueryRow(ctx, "select 'ext.hstore'::regtype::oid").Scan(&extOID); err != nil {
+		t.Fatal(err)
+	}
+
+	// not on the search_path: uses the only hstore type
+	if err := pgxtypefaster.RegisterHstore(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if registered, _ := other.TypeMap().TypeForName("hstore"); registered.OID != extOID {
+		t.Errorf("RegisterHstore registered OID %d; expected %d", registered.OID, extOID)
+	}
+	// the functions that query several types find the same type
+	snapshot, err := pgxtypefaster.QueryOIDSnapshot(ctx, other, "hstore")
+	if err != nil || snapshot["hstore"] != extOID {
+		t.Errorf("QueryOIDSnapshot(hstore)=%v, %v; expected OID %d", snapshot, err, extOID)
+	}
+	if err := pgxtypefaster.RegisterTypes(ctx, other, "hstore"); err != nil {
+		t.Fatal(err)
+	}
+	if registered, _ := other.TypeMap().TypeForName("hstore"); registered.OID != extOID {
+		t.Errorf("RegisterTypes registered OID %d; expected %d", registered.OID, extOID)
+	}
+
+	if err := pgxtypefaster.RegisterHstoreInSchema(ctx, other, "public"); err != pgxtypefaster.ErrHstoreDoesNotExist {
+		t.Errorf("RegisterHstoreInSchema(public)=%v; expected ErrHstoreDoesNotExist", err)
+	}
+	if err := pgxtypefaster.RegisterHstoreInSchema(ctx, other, "ext"); err != nil {
+		t.Fatal(err)
+	}
+	var h pgxtypefaster.Hstore
+	if err := other.QueryRow(ctx, `select 'a=>b'::ext.hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+}
+
+func TestRegisterHstoreOID(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	oid, err := pgxtypefaster.RegisterHstoreOID(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected uint32
+	if err := conn.QueryRow(ctx, "select 'hstore'::regtype::oid").Scan(&expected); err != nil {
+		t.Fatal(err)
+	}
+	if oid != expected {
+		t.Errorf("RegisterHstoreOID=%d; expected %d", oid, expected)
+	}
+	if registered, _ := conn.TypeMap().TypeForName("hstore"); registered.OID != expected {
+		t.Errorf("registered OID %d; expected %d", registered.OID, expected)
+	}
+}
+
+func TestRegistryAddHstoreAs(t *testing.T) {
+	r := pgxtypefaster.NewRegistry()
+	r.AddHstoreAs("tag_map")
+	r.AddHstoreCompatAs("public.compat_map")
+	r.SetOID("tag_map", testHstoreOID)
+	r.SetOID("public.compat_map", testHstoreOID+1)
+	m := pgtype.NewMap()
+	if err := r.ApplyTypeMap(m); err != nil {
+		t.Fatal(err)
+	}
+	if tagType, ok := m.TypeForName("tag_map"); !ok || tagType.OID != testHstoreOID || tagType.Codec != (pgxtypefaster.HstoreCodec{}) {
+		t.Errorf("tag_map registered as %#v", tagType)
+	}
+	if compatType, ok := m.TypeForName("public.compat_map"); !ok || compatType.Codec != (pgxtypefaster.HstoreCompatCodec{}) {
+		t.Errorf("public.compat_map registered as %#v", compatType)
+	}
+}
+
+func TestRegisterHstoreAs(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	if _, err := conn.Exec(ctx, "create domain tag_map as hstore"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pgxtypefaster.RegisterHstoreAs(ctx, conn, "tag_map"); err != nil {
+		t.Fatal(err)
+	}
+	var domainOID uint32
+	if err := conn.QueryRow(ctx, "select 'tag_map'::regtype::oid").Scan(&domainOID); err != nil {
+		t.Fatal(err)
+	}
+	if tagType, ok := conn.TypeMap().TypeForName("tag_map"); !ok || tagType.OID != domainOID {
+		t.Errorf("tag_map registered as %#v; expected OID %d", tagType, domainOID)
+	}
+
+	// the parameter has the domain's OID
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}
+	var h pgxtypefaster.Hstore
+	if err := conn.QueryRow(ctx, "select $1::tag_map", input).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(input) {
+		t.Errorf("scanned %#v", h)
+	}
+
+	if err := pgxtypefaster.RegisterHstoreAs(ctx, conn, "does_not_exist"); err == nil {
+		t.Error("RegisterHstoreAs(does_not_exist): expected error")
+	}
+}
@@ -0,0 +1,85 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestReuseHstore(t *testing.T) {
+	first := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgtype.Text{}}
+	second := pgxtypefaster.Hstore{"c": pgxtypefaster.NewText("3")}
+
+	for _, opts := range [][]pgxtypefaster.CodecOption{nil, {pgxtypefaster.WithMaxPairs(10)}} {
+		m := newOptionsTypeMap(opts...)
+		for _, format := range formats {
+			var h pgxtypefaster.Hstore
+			var mapPointer uintptr
+			for i, input := range []pgxtypefaster.Hstore{first, second} {
+				buf, err := m.Encode(testHstoreOID, format, input, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := m.Scan(testHstoreOID, format, buf, pgxtypefaster.ReuseHstore{&h}); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(h, input) {
+					t.Errorf("format=%d: scanned %#v; expected %#v", format, h, input)
+				}
+				if i == 0 {
+					mapPointer = reflect.ValueOf(h).Pointer()
+				} else if reflect.ValueOf(h).Pointer() != mapPointer {
+					t.Errorf("format=%d: scan must reuse the map", format)
+				}
+			}
+
+			if err := m.Scan(testHstoreOID, format, nil, pgxtypefaster.ReuseHstore{&h}); err != nil || h != nil {
+				t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, h, err)
+			}
+		}
+	}
+}
+
+func BenchmarkReuseHstore(b *testing.B) {
+	input := pgxtypefaster.Hstore{
+		"a": pgxtypefaster.NewText("100"),
+		"b": pgxtypefaster.NewText("200"),
+		"c": pgtype.Text{},
+	}
+	m := newTestTypeMap()
+
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		formatName := "text"
+		if format == pgtype.BinaryFormatCode {
+			formatName = "binary"
+		}
+
+		var h pgxtypefaster.Hstore
+		hstorePlan := m.PlanScan(testHstoreOID, format, &h)
+		b.Run("Hstore/"+formatName, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := hstorePlan.Scan(buf, &h); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		reuse := pgxtypefaster.ReuseHstore{&h}
+		reusePlan := m.PlanScan(testHstoreOID, format, reuse)
+		b.Run("ReuseHstore/"+formatName, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := reusePlan.Scan(buf, reuse); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
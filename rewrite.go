@@ -0,0 +1,194 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// InlineHstoreArgs is a pgx.QueryRewriter that replaces the placeholders of Hstore and
+// HstoreCompat arguments with SQL literals from QuoteLiteral, and renumbers the placeholders of
+// the other arguments. Use it with pgx.QueryExecModeSimpleProtocol for proxies that cannot handle
+// parameters of extension types. Pass it before the arguments:
+//
+//	rows, err := conn.Query(ctx, "select id from t where attrs @> $1", pgxtypefaster.InlineHstoreArgs{}, h)
+type InlineHstoreArgs struct{}
+
+var _ pgx.QueryRewriter = InlineHstoreArgs{}
+
+// RewriteQuery implements pgx.QueryRewriter.
+func (InlineHstoreArgs) RewriteQuery(ctx context.Context, conn *pgx.Conn, sql string, args []any) (newSQL string, newArgs []any, err error) {
+	// literals[i] is the literal for argument i, or empty if it is not inlined
+	literals := make([]string, len(args))
+	ordinals := make([]int, len(args))
+	for i, arg := range args {
+		literal, ok, err := inlineHstoreLiteral(arg)
+		if err != nil {
+			return "", nil, fmt.Errorf("argument $%d: %w", i+1, err)
+		}
+		if ok {
+			literals[i] = literal
+		} else {
+			newArgs = append(newArgs, arg)
+			ordinals[i] = len(newArgs)
+		}
+	}
+
+	newSQL, err = replacePlaceholders(sql, func(n int) (string, error) {
+		if n < 1 || n > len(args) {
+			return "", fmt.Errorf("placeholder $%d has no argument: %d arguments", n, len(args))
+		}
+		if literals[n-1] != "" {
+			return literals[n-1], nil
+		}
+		return "$" + strconv.Itoa(ordinals[n-1]), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return newSQL, newArgs, nil
+}
+
+// inlineHstoreLiteral returns the literal for arg, and true if it is an hstore argument.
+func inlineHstoreLiteral(arg any) (string, bool, error) {
+	// check nil pointers first: calling their value methods panics
+	if isValuerPointer(arg, hstoreValuerType) || isValuerPointer(arg, hstoreCompatValuerType) {
+		if reflect.ValueOf(arg).IsNil() {
+			return "NULL::hstore", true, nil
+		}
+	}
+	var h Hstore
+	switch arg := arg.(type) {
+	case HstoreValuer:
+		var err error
+		h, err = arg.HstoreValue()
+		if err != nil {
+			return "", false, err
+		}
+	case HstoreCompatValuer:
+		compat, err := arg.HstoreCompatValue()
+		if err != nil {
+			return "", false, err
+		}
+		h = CompatToHstore(compat)
+	default:
+		return "", false, nil
+	}
+	literal, err := QuoteLiteral(h)
+	return literal, true, err
+}
+
+// replacePlaceholders returns sql with each $n placeholder replaced with replace(n). It does not
+// replace text in strings, quoted identifiers, or comments.
+func replacePlaceholders(sql string, replace func(n int) (string, error)) (string, error) {
+	var out strings.Builder
+	out.Grow(len(sql))
+	for i := 0; i < len(sql); {
+		start := i
+		switch c := sql[i]; {
+		case c == '\'':
+			// E'...' strings use backslash escapes
+			backslashes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i < 2 || !isIdentByte(sql[i-2]))
+			i = skipQuoted(sql, i, '\'', backslashes)
+		case c == '"':
+			i = skipQuoted(sql, i, '"', false)
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = skipBlockComment(sql, i)
+		case c == '$' && (i == 0 || !isIdentByte(sql[i-1])):
+			digits := i + 1
+			for digits < len(sql) && '0' <= sql[digits] && sql[digits] <= '9' {
+				digits++
+			}
+			if digits > i+1 {
+				n, err := strconv.Atoi(sql[i+1 : digits])
+				if err != nil {
+					return "", err
+				}
+				replacement, err := replace(n)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(replacement)
+				i = digits
+				continue
+			}
+			i = skipDollarQuoted(sql, i)
+		default:
+			i++
+		}
+		out.WriteString(sql[start:i])
+	}
+	return out.String(), nil
+}
+
+// isIdentByte returns true if b can be part of an identifier after the first byte.
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b >= 0x80 ||
+		('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+// skipQuoted returns the index after the string starting with the quote at sql[start]. A doubled
+// quote is part of the string.
+func skipQuoted(sql string, start int, quote byte, backslashes bool) int {
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslashes {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// skipBlockComment returns the index after the comment starting at sql[start]. Block comments
+// can be nested.
+func skipBlockComment(sql string, start int) int {
+	depth := 0
+	for i := start; i+1 < len(sql); i++ {
+		if sql[i] == '/' && sql[i+1] == '*' {
+			depth++
+			i++
+		} else if sql[i] == '*' && sql[i+1] == '/' {
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(sql)
+}
+
+// skipDollarQuoted returns the index after the dollar-quoted string starting at sql[start], such
+// as $tag$...$tag$. If it is not a dollar-quoted string, it returns the index after the '$'.
+func skipDollarQuoted(sql string, start int) int {
+	end := start + 1
+	for end < len(sql) && sql[end] != '$' && isIdentByte(sql[end]) {
+		end++
+	}
+	if end >= len(sql) || sql[end] != '$' {
+		return start + 1
+	}
+	tag := sql[start : end+1]
+	if closing := strings.Index(sql[end+1:], tag); closing >= 0 {
+		return end + 1 + closing + len(tag)
+	}
+	return len(sql)
+}
@@ -0,0 +1,76 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestInlineHstoreArgs(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("it's")}
+	value := "v"
+	tests := []struct {
+		sql          string
+		args         []any
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{`select $1, $2, $3`, []any{1, h, "x"}, `select $1, E'"a"=>"it''s"'::hstore, $2`, []any{1, "x"}},
+		{`select $2 || $1, $2`, []any{h, 2}, `select $1 || E'"a"=>"it''s"'::hstore, $1`, []any{2}},
+		{`select $1`, []any{pgxtypefaster.HstoreCompat{"k": &value}}, `select E'"k"=>"v"'::hstore`, nil},
+		{`select $1`, []any{(*pgxtypefaster.Hstore)(nil)}, `select NULL::hstore`, nil},
+		{`select $1`, []any{&h}, `select E'"a"=>"it''s"'::hstore`, nil},
+		// placeholders in strings, identifiers, and comments are not replaced
+		{
+			`select '$1', E'\'$1', "$1", $$ $1 $$, $tag$ $1 $tag$, a$1 -- $1` + "\n" + `/* /* $1 */ $1 */ $1`,
+			[]any{h},
+			`select '$1', E'\'$1', "$1", $$ $1 $$, $tag$ $1 $tag$, a$1 -- $1` + "\n" + `/* /* $1 */ $1 */ E'"a"=>"it''s"'::hstore`,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		sql, args, err := pgxtypefaster.InlineHstoreArgs{}.RewriteQuery(context.Background(), nil, test.sql, test.args)
+		if err != nil {
+			t.Errorf("RewriteQuery(%#v): %s", test.sql, err)
+			continue
+		}
+		if sql != test.expectedSQL || !reflect.DeepEqual(args, test.expectedArgs) {
+			t.Errorf("RewriteQuery(%#v)=%#v, %#v; expected %#v, %#v",
+				test.sql, sql, args, test.expectedSQL, test.expectedArgs)
+		}
+	}
+
+	errorTests := []struct {
+		sql  string
+		args []any
+	}{
+		{`select $2`, []any{h}},
+		{`select $1`, []any{pgxtypefaster.Hstore{"a\x00": {}}}},
+	}
+	for _, test := range errorTests {
+		_, _, err := pgxtypefaster.InlineHstoreArgs{}.RewriteQuery(context.Background(), nil, test.sql, test.args)
+		if err == nil {
+			t.Errorf("RewriteQuery(%#v, %#v): expected error", test.sql, test.args)
+		}
+	}
+}
+
+func TestInlineHstoreArgsSimpleProtocol(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText(`it's \ "quoted"`), "b": {}}
+	var h pgxtypefaster.Hstore
+	var n int
+	err := conn.QueryRow(ctx, `select $1, $2::int`, pgx.QueryExecModeSimpleProtocol,
+		pgxtypefaster.InlineHstoreArgs{}, input, 42).Scan(&h, &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(input) || n != 42 {
+		t.Errorf("scanned %#v, %d", h, n)
+	}
+}
@@ -0,0 +1,139 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// RowToStructFast returns a pgx.RowToFunc that scans rows into a T, which must be a struct. It
+// matches columns to fields like pgx.RowToStructByName: case-insensitive by name, overridden with
+// a "db" struct tag, ignoring fields tagged `db:"-"`. Unlike pgx.RowToStructByName, it computes the
+// mapping once per query and reuses it for each row, which is faster for wide rows. Use a new
+// function for each query, since it is not safe to use concurrently:
+//
+//	structs, err := pgx.CollectRows(rows, pgxtypefaster.RowToStructFast[T]())
+func RowToStructFast[T any]() pgx.RowToFunc[T] {
+	mapper := &structRowMapper{}
+	return func(row pgx.CollectableRow) (T, error) {
+		var value T
+		err := mapper.scan(row, reflect.ValueOf(&value).Elem())
+		return value, err
+	}
+}
+
+// structRowMapper caches the mapping from columns to struct fields.
+type structRowMapper struct {
+	// fieldDescs is the slice used to compute fieldIndexes, used to detect a new query
+	fieldDescs []pgconn.FieldDescription
+	// fieldIndexes[i] is the reflect field index for column i
+	fieldIndexes [][]int
+	scanTargets  []any
+}
+
+func (m *structRowMapper) scan(row pgx.CollectableRow, structValue reflect.Value) error {
+	fieldDescs := row.FieldDescriptions()
+	if !sameFieldDescriptions(m.fieldDescs, fieldDescs) {
+		fieldIndexes, err := structFieldIndexesByName(structValue.Type(), fieldDescs)
+		if err != nil {
+			return err
+		}
+		m.fieldDescs = fieldDescs
+		m.fieldIndexes = fieldIndexes
+		m.scanTargets = make([]any, len(fieldIndexes))
+	}
+
+	for i, index := range m.fieldIndexes {
+		m.scanTargets[i] = structValue.FieldByIndex(index).Addr().Interface()
+	}
+	err := row.Scan(m.scanTargets...)
+	// do not retain pointers into value
+	for i := range m.scanTargets {
+		m.scanTargets[i] = nil
+	}
+	return err
+}
+
+// sameFieldDescriptions returns true if a and b are the same slice. pgx returns the same slice
+// for every row in a query.
+func sameFieldDescriptions(a []pgconn.FieldDescription, b []pgconn.FieldDescription) bool {
+	if len(a) != len(b) || a == nil || b == nil {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
+
+// structFieldIndexesByName returns the field index for each column in fieldDescs. Every column
+// must match exactly one field, and every field must match a column.
+func structFieldIndexesByName(structType reflect.Type, fieldDescs []pgconn.FieldDescription) ([][]int, error) {
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("RowToStructFast requires a struct; found %s", structType)
+	}
+
+	fieldIndexes := make([][]int, len(fieldDescs))
+	err := appendStructFieldIndexes(structType, nil, fieldDescs, fieldIndexes)
+	if err != nil {
+		return nil, err
+	}
+	for i, index := range fieldIndexes {
+		if index == nil {
+			return nil, fmt.Errorf("cannot find struct field for column %s", fieldDescs[i].Name)
+		}
+	}
+	return fieldIndexes, nil
+}
+
+func appendStructFieldIndexes(
+	structType reflect.Type, parentIndex []int, fieldDescs []pgconn.FieldDescription, fieldIndexes [][]int,
+) error {
+	for i := 0; i < structType.NumField(); i++ {
+		sf := structType.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		// embedded structs are flattened, but embedded pointers are not handled, like pgx
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			err := appendStructFieldIndexes(sf.Type, index, fieldDescs, fieldIndexes)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		dbTag, dbTagPresent := sf.Tag.Lookup("db")
+		dbTag, _, _ = strings.Cut(dbTag, ",")
+		if dbTag == "-" {
+			continue
+		}
+		colName := dbTag
+		if !dbTagPresent {
+			colName = sf.Name
+		}
+
+		colIndex := -1
+		for j, desc := range fieldDescs {
+			if strings.EqualFold(desc.Name, colName) {
+				colIndex = j
+				break
+			}
+		}
+		if colIndex == -1 {
+			return fmt.Errorf("cannot find field %s in returned row", colName)
+		}
+		if fieldIndexes[colIndex] != nil {
+			return fmt.Errorf("column %s matches multiple struct fields", fieldDescs[colIndex].Name)
+		}
+		fieldIndexes[colIndex] = index
+	}
+	return nil
+}
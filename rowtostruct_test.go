@@ -0,0 +1,90 @@
+package pgxtypefaster_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// testHstoreOID is an arbitrary OID for hstore in tests that do not use Postgres.
+const testHstoreOID = 100000
+
+func newTestTypeMap() *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.HstoreCodec{}, Name: "hstore", OID: testHstoreOID})
+	return m
+}
+
+// fakeRow implements pgx.CollectableRow with text format values.
+type fakeRow struct {
+	typeMap    *pgtype.Map
+	fieldDescs []pgconn.FieldDescription
+	values     [][]byte
+}
+
+func (r *fakeRow) FieldDescriptions() []pgconn.FieldDescription { return r.fieldDescs }
+func (r *fakeRow) RawValues() [][]byte                          { return r.values }
+func (r *fakeRow) Values() ([]any, error)                       { panic("not implemented") }
+
+func (r *fakeRow) Scan(dest ...any) error {
+	if len(dest) != len(r.values) {
+		return fmt.Errorf("expected %d destinations; found %d", len(r.values), len(dest))
+	}
+	for i, d := range dest {
+		desc := r.fieldDescs[i]
+		err := r.typeMap.Scan(desc.DataTypeOID, desc.Format, r.values[i], d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rowStructEmbedded struct {
+	ID int32
+}
+
+type rowStruct struct {
+	rowStructEmbedded
+	Attrs   pgxtypefaster.Hstore `db:"attributes"`
+	Ignored string               `db:"-"`
+}
+
+func TestRowToStructFast(t *testing.T) {
+	fieldDescs := []pgconn.FieldDescription{
+		{Name: "attributes", DataTypeOID: testHstoreOID, Format: pgtype.TextFormatCode},
+		{Name: "id", DataTypeOID: pgtype.Int4OID, Format: pgtype.TextFormatCode},
+	}
+	typeMap := newTestTypeMap()
+	rowTo := pgxtypefaster.RowToStructFast[rowStruct]()
+
+	for i, input := range []string{`"a"=>"b"`, `"c"=>NULL`} {
+		row := &fakeRow{typeMap, fieldDescs, [][]byte{[]byte(input), []byte(fmt.Sprint(i))}}
+		out, err := rowTo(row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected pgxtypefaster.Hstore
+		err = expected.Scan(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != int32(i) || !reflect.DeepEqual(out.Attrs, expected) {
+			t.Errorf("row %d: out=%#v", i, out)
+		}
+	}
+
+	// a different query with a missing column must fail
+	row := &fakeRow{typeMap, fieldDescs[:1], [][]byte{[]byte(`"a"=>"b"`)}}
+	_, err := rowTo(row)
+	if err == nil {
+		t.Error("expected error for missing column")
+	}
+
+	var _ pgx.RowToFunc[rowStruct] = rowTo
+}
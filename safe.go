@@ -0,0 +1,20 @@
+//go:build !pgxtypefasterunsafe
+
+package pgxtypefaster
+
+// UnsafeOptimizations is true if the package was built with the pgxtypefasterunsafe build tag,
+// which enables optimizations that use the unsafe package. By default it is false, and the package
+// does not use unsafe.
+const UnsafeOptimizations = false
+
+// ownedBytesToString returns b as a string. With the pgxtypefasterunsafe build tag it does not
+// copy, so b must not be modified or reused after this call.
+func ownedBytesToString(b []byte) string {
+	return string(b)
+}
+
+// borrowedBytesToString returns b as a string for WithZeroCopy. With the pgxtypefasterunsafe build
+// tag it does not copy, so the string is only valid while b is not modified.
+func borrowedBytesToString(b []byte) string {
+	return string(b)
+}
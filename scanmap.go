@@ -0,0 +1,114 @@
+package pgxtypefaster
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// planScanMap returns a plan for map types, JSON, HstoreSubset, ReuseHstore, LazyHstore, and
+// sql.Null[Hstore], which HstoreCodec scans directly, or nil.
+func (c HstoreCodec) planScanMap(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	switch target.(type) {
+	case *map[string]string:
+		return newScanPlanHstoreToMap(c, m, oid, format, stringFromText)
+	case *map[string]sql.NullString:
+		return newScanPlanHstoreToMap(c, m, oid, format, nullStringFromText)
+	case *map[string]any:
+		return newScanPlanHstoreToMap(c, m, oid, format, anyFromText)
+	case *json.RawMessage:
+		return c.planScanJSON(m, oid, format)
+	case *HstoreSubset:
+		return c.planScanSubset(m, oid, format)
+	case ReuseHstore:
+		return c.planScanReuse(m, oid, format)
+	case *LazyHstore:
+		if format == pgtype.BinaryFormatCode || format == pgtype.TextFormatCode {
+			return scanPlanHstoreToLazy{c.cfg, format}
+		}
+	}
+
+	if plan := c.planScanNull(m, oid, format, target); plan != nil {
+		return plan
+	}
+
+	// *pgtype.Hstore, *HstoreCompat, and other pointers to map[string]*string use the compatible
+	// codec, so call sites can migrate from pgtype.Hstore incrementally
+	if isConvertibleMapPointer(target, hstoreCompatPtrType) {
+		return HstoreCompatCodec{cfg: c.cfg}.PlanScan(m, oid, format, target)
+	}
+	return nil
+}
+
+// stringFromText returns a *NullValueError for NULL values.
+func stringFromText(key string, value pgtype.Text) (string, error) {
+	if !value.Valid {
+		return "", &NullValueError{key}
+	}
+	return value.String, nil
+}
+
+func nullStringFromText(key string, value pgtype.Text) (sql.NullString, error) {
+	return sql.NullString{String: value.String, Valid: value.Valid}, nil
+}
+
+// scanPlanHstoreToMap scans into *map[string]V, converting each value with fromText. If next is
+// nil, it parses the pairs directly into the map without building an Hstore. Otherwise, it scans
+// an Hstore with next, which applies the codec options, then converts it.
+type scanPlanHstoreToMap[V any] struct {
+	format   int16
+	fromText func(key string, value pgtype.Text) (V, error)
+	next     pgtype.ScanPlan
+}
+
+func newScanPlanHstoreToMap[V any](
+	c HstoreCodec, m *pgtype.Map, oid uint32, format int16, fromText func(string, pgtype.Text) (V, error),
+) pgtype.ScanPlan {
+	if format != pgtype.BinaryFormatCode && format != pgtype.TextFormatCode {
+		return nil
+	}
+	p := &scanPlanHstoreToMap[V]{format: format, fromText: fromText}
+	if c.cfg.scansWithHstorePlan() {
+		p.next = c.PlanScan(m, oid, format, (*Hstore)(nil))
+	}
+	return p
+}
+
+func (p *scanPlanHstoreToMap[V]) Scan(src []byte, dst any) error {
+	target := dst.(*map[string]V)
+	if src == nil {
+		*target = nil
+		return nil
+	}
+
+	var out map[string]V
+	add := func(key string, value pgtype.Text) error {
+		converted, err := p.fromText(key, value)
+		if err != nil {
+			return err
+		}
+		out[key] = converted
+		return nil
+	}
+
+	if p.next != nil {
+		var h Hstore
+		if err := p.next.Scan(src, &h); err != nil {
+			return err
+		}
+		out = make(map[string]V, len(h))
+		for k, v := range h {
+			if err := add(k, v); err != nil {
+				return err
+			}
+		}
+	} else {
+		out = make(map[string]V, estimatePairCount(p.format, src))
+		if err := forEachPair(p.format, src, add); err != nil {
+			return err
+		}
+	}
+	*target = out
+	return nil
+}
@@ -0,0 +1,144 @@
+package pgxtypefaster_test
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestScanStringMap(t *testing.T) {
+	m := newOptionsTypeMap()
+	emptyNulls := newOptionsTypeMap(pgxtypefaster.WithNullPolicy(pgxtypefaster.NullEmpty))
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, pgxtypefaster.NewHstoreFromPairs("a", "1", "b", ""), []byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]string
+		if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, map[string]string{"a": "1", "b": ""}) {
+			t.Errorf("format=%d: scanned %#v", format, out)
+		}
+
+		if err := m.Scan(testHstoreOID, format, nil, &out); err != nil || out != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, out, err)
+		}
+
+		buf, err = m.Encode(testHstoreOID, format, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": pgtype.Text{}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = map[string]string{"unchanged": "x"}
+		err = m.Scan(testHstoreOID, format, buf, &out)
+		var nullErr *pgxtypefaster.NullValueError
+		if !errors.As(err, &nullErr) || nullErr.Key != "null" {
+			t.Errorf("format=%d: expected NullValueError; err=%v", format, err)
+		}
+		if !reflect.DeepEqual(out, map[string]string{"unchanged": "x"}) {
+			t.Errorf("format=%d: failed scan modified the target: %#v", format, out)
+		}
+
+		// codec options are applied
+		if err := emptyNulls.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, map[string]string{"a": "1", "null": ""}) {
+			t.Errorf("format=%d: scanned with NullEmpty %#v", format, out)
+		}
+	}
+}
+
+func TestScanNullStringMap(t *testing.T) {
+	m := newOptionsTypeMap()
+	skipNulls := newOptionsTypeMap(pgxtypefaster.WithNullPolicy(pgxtypefaster.NullSkip))
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": pgtype.Text{}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]sql.NullString
+		if err := m.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]sql.NullString{"a": {String: "1", Valid: true}, "null": {}}
+		if !reflect.DeepEqual(out, expected) {
+			t.Errorf("format=%d: scanned %#v", format, out)
+		}
+
+		if err := skipNulls.Scan(testHstoreOID, format, buf, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, map[string]sql.NullString{"a": {String: "1", Valid: true}}) {
+			t.Errorf("format=%d: scanned with NullSkip %#v", format, out)
+		}
+
+		if err := m.Scan(testHstoreOID, format, nil, &out); err != nil || out != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, out, err)
+		}
+	}
+}
+
+func TestScanPointerMaps(t *testing.T) {
+	m := newOptionsTypeMap()
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "null": pgtype.Text{}}
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var pgxHstore pgtype.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &pgxHstore); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(pgxtypefaster.PGXToFasterHstore(pgxHstore), input) {
+			t.Errorf("format=%d: scanned pgtype.Hstore %#v", format, pgxHstore)
+		}
+
+		var pointerMap map[string]*string
+		if err := m.Scan(testHstoreOID, format, buf, &pointerMap); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(pgxtypefaster.PGXToFasterHstore(pointerMap), input) {
+			t.Errorf("format=%d: scanned map[string]*string %#v", format, pointerMap)
+		}
+
+		if err := m.Scan(testHstoreOID, format, nil, &pgxHstore); err != nil || pgxHstore != nil {
+			t.Errorf("format=%d: NULL must scan as nil: %#v %v", format, pgxHstore, err)
+		}
+	}
+}
+
+func TestEncodePointerMaps(t *testing.T) {
+	// sorted so the encodings can be compared
+	m := newOptionsTypeMap(pgxtypefaster.WithSortedKeys())
+	input := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("1"), "b": pgxtypefaster.NewText("2"), "null": pgtype.Text{}}
+	compat := pgxtypefaster.HstoreToCompat(input)
+	values := []any{compat, pgtype.Hstore(compat), map[string]*string(compat)}
+	for _, format := range formats {
+		expected, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range values {
+			buf, err := m.Encode(testHstoreOID, format, value, nil)
+			if err != nil {
+				t.Fatalf("format=%d: %T: %s", format, value, err)
+			}
+			if !reflect.DeepEqual(buf, expected) {
+				t.Errorf("format=%d: %T encoded %q; expected %q", format, value, buf, expected)
+			}
+		}
+
+		buf, err := m.Encode(testHstoreOID, format, pgxtypefaster.HstoreCompat(nil), nil)
+		if err != nil || buf != nil {
+			t.Errorf("format=%d: nil HstoreCompat must encode as NULL: %#v %v", format, buf, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package pgxtypefaster
+
+import (
+	"strings"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+)
+
+// WithSeparateStrings allocates each scanned key and value separately. By default, all the keys and
+// values of an hstore share one allocation, which is faster, but retaining any one of them keeps
+// the entire hstore in memory. Use this for long-lived values such as caches that keep a few keys
+// of each row. See also Hstore.Compact, which copies the strings of an hstore after it is scanned.
+func WithSeparateStrings() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.separateStrings = true
+	}
+}
+
+func (cfg *codecConfig) separatesStrings() bool {
+	return cfg != nil && cfg.separateStrings
+}
+
+// binaryStrings returns the keys and values of an hstore in the binary format. By default they
+// are substrings of one shared string with the remaining bytes.
+type binaryStrings struct {
+	shared   string
+	base     int
+	src      []byte
+	alloc    Allocator
+	separate bool
+}
+
+// newBinaryStrings returns the strings for the bytes remaining in r.
+func newBinaryStrings(alloc Allocator, r *pgio.Reader, separate bool) binaryStrings {
+	s := binaryStrings{base: r.Pos(), src: r.RemainingBytes(), alloc: alloc, separate: separate}
+	if !separate {
+		// one shared string for all key/value strings
+		s.shared = allocString(alloc, s.src)
+	}
+	return s
+}
+
+// get returns the string at offset start with length n in the original buffer.
+func (s *binaryStrings) get(start int, n int) string {
+	if s.separate {
+		return s.copy(start, n)
+	}
+	return s.shared[start-s.base : start-s.base+n]
+}
+
+// copy is separate from get so get can be inlined.
+func (s *binaryStrings) copy(start int, n int) string {
+	return allocString(s.alloc, s.src[start-s.base:start-s.base+n])
+}
+
+// Compact returns a copy of h where each key and value is a separate allocation. A scanned Hstore
+// shares one allocation for all its strings, so retaining any of them keeps the entire hstore in
+// memory. Call Compact before keeping a scanned Hstore, or values from it, for a long time. A nil
+// h returns nil.
+func (h Hstore) Compact() Hstore {
+	if h == nil {
+		return nil
+	}
+	compacted := make(Hstore, len(h))
+	for k, v := range h {
+		if v.Valid {
+			v.String = strings.Clone(v.String)
+		}
+		compacted[strings.Clone(k)] = v
+	}
+	return compacted
+}
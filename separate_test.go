@@ -0,0 +1,103 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWithSeparateStrings(t *testing.T) {
+	shared := newOptionsTypeMap()
+	separate := newOptionsTypeMap(pgxtypefaster.WithSeparateStrings())
+	input := pgxtypefaster.Hstore{
+		"key1": pgxtypefaster.NewText("value1"),
+		"key2": pgxtypefaster.NewText("value2"),
+		"key3": pgtype.Text{},
+	}
+	// 5 separately allocated strings replace one shared string
+	const extraAllocs = 4
+
+	for _, format := range formats {
+		for i, oid := range []uint32{testHstoreOID, testHstoreOID + 1} {
+			buf, err := shared.Encode(testHstoreOID, format, input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newTarget := func() any {
+				if i == 0 {
+					return &pgxtypefaster.Hstore{}
+				}
+				return &pgxtypefaster.HstoreCompat{}
+			}
+
+			target := newTarget()
+			if err := separate.Scan(oid, format, buf, target); err != nil {
+				t.Fatal(err)
+			}
+			var h pgxtypefaster.Hstore
+			switch target := target.(type) {
+			case *pgxtypefaster.Hstore:
+				h = *target
+			case *pgxtypefaster.HstoreCompat:
+				h = pgxtypefaster.PGXToFasterHstore(*target)
+			}
+			if !reflect.DeepEqual(h, input) {
+				t.Errorf("format=%d oid=%d: scanned %#v; expected %#v", format, oid, h, input)
+			}
+
+			countAllocs := func(m *pgtype.Map) float64 {
+				target := newTarget()
+				plan := m.PlanScan(oid, format, target)
+				return testing.AllocsPerRun(10, func() {
+					if err := plan.Scan(buf, target); err != nil {
+						t.Fatal(err)
+					}
+				})
+			}
+			sharedAllocs := countAllocs(shared)
+			separateAllocs := countAllocs(separate)
+			if separateAllocs < sharedAllocs+extraAllocs {
+				t.Errorf("format=%d oid=%d: separate allocs=%f; shared allocs=%f; expected at least %d more",
+					format, oid, separateAllocs, sharedAllocs, extraAllocs)
+			}
+		}
+	}
+}
+
+func TestHstoreCompact(t *testing.T) {
+	if pgxtypefaster.Hstore(nil).Compact() != nil {
+		t.Error("Compact of nil must return nil")
+	}
+
+	input := pgxtypefaster.Hstore{
+		"key1": pgxtypefaster.NewText("value1"),
+		"key2": pgtype.Text{},
+		"":     pgxtypefaster.NewText(""),
+	}
+	m := newTestTypeMap()
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, []byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h pgxtypefaster.Hstore
+		if err := m.Scan(testHstoreOID, format, buf, &h); err != nil {
+			t.Fatal(err)
+		}
+
+		compacted := h.Compact()
+		if !reflect.DeepEqual(compacted, input) {
+			t.Errorf("format=%d: Compact()=%#v; expected %#v", format, compacted, input)
+		}
+		compacted["key3"] = pgxtypefaster.NewText("value3")
+		if _, ok := h["key3"]; ok {
+			t.Errorf("format=%d: Compact must return a copy", format)
+		}
+		if unsafe.StringData(compacted["key1"].String) == unsafe.StringData(h["key1"].String) {
+			t.Errorf("format=%d: Compact must copy values", format)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build go1.21
+
+package pgxtypefaster
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// redactedValue replaces the values of redacted keys in logs.
+const redactedValue = "REDACTED"
+
+var _ slog.LogValuer = Hstore(nil)
+
+// LogValue implements slog.LogValuer. It logs h as a group with one attribute per key, sorted by
+// key. NULL values are logged as nil. Use Redacted to hide sensitive values.
+func (h Hstore) LogValue() slog.Value {
+	return HstoreLogValue{Hstore: h}.LogValue()
+}
+
+// Redacted returns a slog.LogValuer that logs h with the values of keys replaced by "REDACTED".
+// Keys are compared case-insensitively.
+func (h Hstore) Redacted(keys ...string) HstoreLogValue {
+	return HstoreLogValue{Hstore: h, Redact: keys}
+}
+
+// HstoreLogValue logs an Hstore with slog, with the values of the Redact keys replaced by
+// "REDACTED". Keys are compared case-insensitively, so "Password" and "password" are both
+// redacted.
+type HstoreLogValue struct {
+	Hstore Hstore
+	Redact []string
+}
+
+var _ slog.LogValuer = HstoreLogValue{}
+
+// LogValue implements slog.LogValuer.
+func (l HstoreLogValue) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(l.Hstore))
+	for _, k := range l.Hstore.SortedKeys() {
+		v := l.Hstore[k]
+		switch {
+		case l.redacts(k):
+			attrs = append(attrs, slog.String(k, redactedValue))
+		case v.Valid:
+			attrs = append(attrs, slog.String(k, v.String))
+		default:
+			attrs = append(attrs, slog.Any(k, nil))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (l HstoreLogValue) redacts(key string) bool {
+	for _, redacted := range l.Redact {
+		if strings.EqualFold(key, redacted) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+//go:build go1.21
+
+package pgxtypefaster_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreLogValue(t *testing.T) {
+	h := pgxtypefaster.Hstore{
+		"user":     pgxtypefaster.NewText("alice"),
+		"Password": pgxtypefaster.NewText("hunter2"),
+		"missing":  pgtype.Text{},
+	}
+
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{h, `h.Password=hunter2 h.missing=<nil> h.user=alice`},
+		{h.Redacted("password", "token"), `h.Password=REDACTED h.missing=<nil> h.user=alice`},
+		{pgxtypefaster.Hstore(nil), ``},
+	}
+	for i, test := range tests {
+		var out bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		logger.Info("msg", "h", test.value)
+		expected := test.expected + "\n"
+		if out.String() != expected {
+			t.Errorf("%d: logged %#v; expected %#v", i, out.String(), expected)
+		}
+	}
+}
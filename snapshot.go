@@ -0,0 +1,66 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// OIDSnapshot maps the names of Postgres types to their OIDs in one database. Save it, for example
+// as JSON with encoding/json, and load it when a program starts, so registering types does not
+// query the database. The OIDs of extension types only change if the extension is dropped and
+// created again, or in a different database.
+type OIDSnapshot map[string]uint32
+
+// QueryOIDSnapshot returns the OIDs of the types names, with one query.
+func QueryOIDSnapshot(ctx context.Context, conn *pgx.Conn, names ...string) (OIDSnapshot, error) {
+	oids, err := queryOIDs(ctx, conn, names)
+	if err != nil {
+		return nil, err
+	}
+	return OIDSnapshot(oids), nil
+}
+
+// Snapshot returns the known OIDs of the Registry's types.
+func (r *Registry) Snapshot() OIDSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot := make(OIDSnapshot, len(r.oids))
+	for name, oid := range r.oids {
+		snapshot[name] = oid
+	}
+	return snapshot
+}
+
+// LoadSnapshot sets the OIDs of the types in snapshot, like SetOID.
+func (r *Registry) LoadSnapshot(snapshot OIDSnapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, oid := range snapshot {
+		r.oids[name] = oid
+	}
+}
+
+// RegisterFromSnapshot registers the codecs for the types in snapshot on conn's type map, without
+// querying the database. The supported names are the same as RegisterTypes.
+func RegisterFromSnapshot(conn *pgx.Conn, snapshot OIDSnapshot) error {
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	// register in a consistent order
+	sort.Strings(names)
+
+	r := NewRegistry()
+	for _, name := range names {
+		add, ok := typeCodecs[name]
+		if !ok {
+			return fmt.Errorf("no codec for postgres type %s", name)
+		}
+		add(r)
+	}
+	r.LoadSnapshot(snapshot)
+	return r.apply(conn.TypeMap(), conn)
+}
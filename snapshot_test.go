@@ -0,0 +1,82 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRegistrySnapshot(t *testing.T) {
+	r := pgxtypefaster.NewRegistry()
+	r.AddHstore()
+	r.SetOID("hstore", testHstoreOID)
+	encoded, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"hstore":100000}` {
+		t.Errorf("JSON snapshot=%s", encoded)
+	}
+
+	var snapshot pgxtypefaster.OIDSnapshot
+	if err := json.Unmarshal(encoded, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	loaded := pgxtypefaster.NewRegistry()
+	loaded.AddHstore()
+	loaded.LoadSnapshot(snapshot)
+	if !reflect.DeepEqual(loaded.Snapshot(), snapshot) {
+		t.Errorf("Snapshot()=%#v; expected %#v", loaded.Snapshot(), snapshot)
+	}
+	m := pgtype.NewMap()
+	if err := loaded.ApplyTypeMap(m); err != nil {
+		t.Fatal(err)
+	}
+	if hstoreType, ok := m.TypeForName("hstore"); !ok || hstoreType.OID != testHstoreOID {
+		t.Errorf("hstore not registered: %#v", hstoreType)
+	}
+
+	// unsupported names fail before using the connection
+	err = pgxtypefaster.RegisterFromSnapshot(nil, pgxtypefaster.OIDSnapshot{"citext": 1})
+	if err == nil {
+		t.Error("RegisterFromSnapshot with unsupported type: expected error")
+	}
+}
+
+func TestRegisterFromSnapshot(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	snapshot, err := pgxtypefaster.QueryOIDSnapshot(ctx, conn, "hstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, _ := conn.TypeMap().TypeForName("hstore")
+	if len(snapshot) != 1 || snapshot["hstore"] != registered.OID {
+		t.Errorf("QueryOIDSnapshot=%#v; expected hstore OID %d", snapshot, registered.OID)
+	}
+	if _, err := pgxtypefaster.QueryOIDSnapshot(ctx, conn, "does_not_exist"); err == nil {
+		t.Error("QueryOIDSnapshot with unknown type: expected error")
+	}
+
+	other, err := pgx.ConnectConfig(ctx, conn.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close(ctx)
+	if err := pgxtypefaster.RegisterFromSnapshot(other, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	var h pgxtypefaster.Hstore
+	if err := other.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+}
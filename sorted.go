@@ -0,0 +1,26 @@
+package pgxtypefaster
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WithSortedKeys encodes the pairs sorted by key in both the text and binary formats, so equal
+// values always have the same encoding. This makes binary COPY output and query parameters
+// reproducible, at the cost of slower encoding. Postgres does not depend on the order.
+func WithSortedKeys() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.sortedKeys = true
+	}
+}
+
+func (cfg *codecConfig) sortsKeys() bool {
+	return cfg != nil && cfg.sortedKeys
+}
+
+// appendPairs appends pairs in format.
+func appendPairs(format int16, buf []byte, pairs []HstorePair) []byte {
+	if format == pgtype.BinaryFormatCode {
+		return appendPairsBinary(buf, pairs)
+	}
+	return appendPairsText(buf, pairs)
+}
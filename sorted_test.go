@@ -0,0 +1,55 @@
+package pgxtypefaster_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWithSortedKeys(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithSortedKeys())
+	h := pgxtypefaster.Hstore{}
+	for _, k := range []string{"d", "b", "a", "c", "e", "f", "g", "h"} {
+		h[k] = pgxtypefaster.NewText("v" + k)
+	}
+	h["n"] = pgtype.Text{}
+	ordered := pgxtypefaster.OrderedHstore{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		ordered = append(ordered, pgxtypefaster.HstorePair{Key: k, Value: pgxtypefaster.NewText("v" + k)})
+	}
+	ordered = append(ordered, pgxtypefaster.HstorePair{Key: "n"})
+
+	orderedMap := pgtype.NewMap()
+	orderedMap.RegisterType(&pgtype.Type{Codec: pgxtypefaster.OrderedHstoreCodec{}, Name: "hstore", OID: testHstoreOID})
+
+	for _, format := range formats {
+		expected, err := orderedMap.Encode(testHstoreOID, format, ordered, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// map iteration order is random: encode multiple times
+		for i := 0; i < 10; i++ {
+			buf, err := m.Encode(testHstoreOID, format, h, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf, expected) {
+				t.Fatalf("format=%d: encoded %#v; expected sorted %#v", format, string(buf), string(expected))
+			}
+			buf, err = m.Encode(testHstoreOID+1, format, fasterToCompat(h), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf, expected) {
+				t.Fatalf("format=%d: compat encoded %#v; expected sorted %#v", format, string(buf), string(expected))
+			}
+		}
+
+		buf, err := m.Encode(testHstoreOID, format, pgxtypefaster.Hstore(nil), nil)
+		if err != nil || buf != nil {
+			t.Errorf("format=%d: nil must encode as NULL: %#v %v", format, buf, err)
+		}
+	}
+}
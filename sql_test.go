@@ -0,0 +1,128 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreScanBytes(t *testing.T) {
+	// drivers like lib/pq return []byte and may reuse the buffer
+	src := []byte(`"a"=>"b", "c"=>NULL`)
+	var h pgxtypefaster.Hstore
+	err := h.Scan(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(src, "xxxxxxxxxxxx")
+	expected := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": pgtype.Text{}}
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("Scan([]byte)=%#v; expected %#v", h, expected)
+	}
+
+	err = h.Scan(42)
+	if err == nil {
+		t.Error("expected error scanning int")
+	}
+}
+
+// textStringer is a source that is only a fmt.Stringer.
+type textStringer string
+
+func (s textStringer) String() string {
+	return string(s)
+}
+
+func TestHstoreScanStringer(t *testing.T) {
+	var h pgxtypefaster.Hstore
+	err := h.Scan(textStringer(`"a"=>"b"`))
+	if err != nil || !reflect.DeepEqual(h, pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("Scan(fmt.Stringer)=%#v, %v", h, err)
+	}
+
+	var ordered pgxtypefaster.OrderedHstore
+	err = ordered.Scan(textStringer(`"b"=>"1", "a"=>NULL`))
+	expected := pgxtypefaster.OrderedHstore{{Key: "b", Value: pgxtypefaster.NewText("1")}, {Key: "a"}}
+	if err != nil || !reflect.DeepEqual(ordered, expected) {
+		t.Errorf("OrderedHstore.Scan(fmt.Stringer)=%#v, %v", ordered, err)
+	}
+}
+
+func TestHstoreCompatScan(t *testing.T) {
+	src := []byte(`"a"=>"b", "c"=>NULL`)
+	expected := fasterToCompat(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": pgtype.Text{}})
+	sources := []any{string(src), src, textStringer(src)}
+	for _, source := range sources {
+		var h pgxtypefaster.HstoreCompat
+		err := h.Scan(source)
+		if err != nil {
+			t.Fatalf("Scan(%T): %s", source, err)
+		}
+		if !reflect.DeepEqual(h, expected) {
+			t.Errorf("Scan(%T)=%#v; expected %#v", source, h, expected)
+		}
+	}
+
+	// the driver may reuse the buffer
+	var h pgxtypefaster.HstoreCompat
+	if err := h.Scan(src); err != nil {
+		t.Fatal(err)
+	}
+	copy(src, "xxxxxxxxxxxx")
+	if !reflect.DeepEqual(h, expected) {
+		t.Errorf("Scan([]byte) after reusing the buffer=%#v; expected %#v", h, expected)
+	}
+
+	if err := h.Scan(42); err == nil {
+		t.Error("expected error scanning int")
+	}
+	if err := h.Scan(nil); err != nil || h != nil {
+		t.Errorf("Scan(nil)=%#v, %v; expected nil", h, err)
+	}
+}
+
+// hstoreValuerOnly implements HstoreValuer but not driver.Valuer.
+type hstoreValuerOnly struct {
+	h pgxtypefaster.Hstore
+}
+
+func (v hstoreValuerOnly) HstoreValue() (pgxtypefaster.Hstore, error) {
+	return v.h, nil
+}
+
+func TestSQLArg(t *testing.T) {
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}
+	arg := pgxtypefaster.SQLArg{hstoreValuerOnly{h}}
+
+	// pgx's stdlib driver passes the argument to pgx, which must use the binary format
+	typeMap := newTestTypeMap()
+	if typeMap.FormatCodeForOID(testHstoreOID) != pgtype.BinaryFormatCode {
+		t.Fatal("hstore must prefer the binary format")
+	}
+	encoded, err := typeMap.Encode(testHstoreOID, pgtype.BinaryFormatCode, arg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := typeMap.Encode(testHstoreOID, pgtype.BinaryFormatCode, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("binary encoding=%#v; expected %#v", encoded, expected)
+	}
+
+	// other drivers use the text format
+	value, err := arg.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != `"a"=>"b"` {
+		t.Errorf("Value()=%#v", value)
+	}
+	value, err = pgxtypefaster.SQLArg{hstoreValuerOnly{nil}}.Value()
+	if err != nil || value != nil {
+		t.Errorf("Value() of nil Hstore=%#v, %v; expected nil", value, err)
+	}
+}
@@ -0,0 +1,37 @@
+package pgxtypefaster
+
+import (
+	"database/sql/driver"
+)
+
+// SQLArg wraps an HstoreValuer so it can be used as a database/sql query argument, while still
+// using the binary format with pgx's stdlib driver. The stdlib driver passes arguments directly
+// to pgx, so if the hstore type is registered on the connection (e.g. with
+// stdlib.OptionAfterConnect(pgxtypefaster.RegisterHstore)), pgx encodes it with HstoreCodec,
+// which prefers the binary format. Other drivers call Value, which uses the text format.
+//
+// Hstore and HstoreCompat already behave this way; SQLArg is needed for other types that only
+// implement HstoreValuer.
+type SQLArg struct {
+	Valuer HstoreValuer
+}
+
+func (a SQLArg) HstoreValue() (Hstore, error) {
+	return a.Valuer.HstoreValue()
+}
+
+// Value implements the database/sql/driver Valuer interface using the text format.
+func (a SQLArg) Value() (driver.Value, error) {
+	buf, err := encodePlanHstoreCodecText{}.Encode(a, nil)
+	if err != nil {
+		return nil, err
+	}
+	if buf == nil {
+		return nil, nil
+	}
+	// buf was allocated by Encode and is not used again
+	return ownedBytesToString(buf), nil
+}
+
+var _ HstoreValuer = SQLArg{}
+var _ driver.Valuer = SQLArg{}
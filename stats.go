@@ -0,0 +1,178 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// maxStatsKeys is the maximum number of distinct keys HstoreStatsCollector tracks. Pairs with
+	// other keys are counted in HstoreStats.UntrackedPairs.
+	maxStatsKeys = 1000
+	// maxStatsDistinctValues is the maximum number of distinct values counted for each key.
+	maxStatsDistinctValues = 1000
+)
+
+// SizeHistogram counts sizes in power of two buckets: bucket 0 counts 0, bucket 1 counts 1, and
+// bucket i counts sizes in [2^(i-1), 2^i - 1].
+type SizeHistogram struct {
+	Counts []int
+}
+
+func (h *SizeHistogram) add(size int) {
+	bucket := bits.Len(uint(size))
+	for len(h.Counts) <= bucket {
+		h.Counts = append(h.Counts, 0)
+	}
+	h.Counts[bucket]++
+}
+
+// BucketRange returns the smallest and largest sizes counted in bucket.
+func (h SizeHistogram) BucketRange(bucket int) (min int, max int) {
+	if bucket == 0 {
+		return 0, 0
+	}
+	return 1 << (bucket - 1), 1<<bucket - 1
+}
+
+// KeyStats are the statistics for one hstore key.
+type KeyStats struct {
+	Key string
+	// Rows is the number of rows containing the key.
+	Rows int
+	// NullValues is the number of rows where the key has a NULL value.
+	NullValues int
+	// ValueBytes is the total length of the non-NULL values, and MaxValueBytes is the longest.
+	ValueBytes    int
+	MaxValueBytes int
+	// DistinctValues is the number of distinct non-NULL values, up to 1000. It is approximate,
+	// since it counts hashes of the values.
+	DistinctValues int
+}
+
+// DistinctSaturated returns true if the key has more distinct values than are counted.
+func (s KeyStats) DistinctSaturated() bool {
+	return s.DistinctValues >= maxStatsDistinctValues
+}
+
+// HstoreStats are statistics of an hstore column, to decide which keys should be promoted to
+// columns, and how to configure the codec.
+type HstoreStats struct {
+	// Rows is the number of rows, and NullRows is the number of rows with a NULL hstore.
+	Rows     int
+	NullRows int
+	// PairCounts counts the number of pairs in each non-NULL row.
+	PairCounts SizeHistogram
+	// ValueSizes counts the length of each non-NULL value.
+	ValueSizes SizeHistogram
+	// Keys are the statistics of each key, ordered by the number of rows descending. At most 1000
+	// keys are tracked: UntrackedPairs counts the pairs with other keys.
+	Keys           []KeyStats
+	UntrackedPairs int
+}
+
+// KeyFrequency returns the fraction of non-NULL rows that contain key.
+func (s HstoreStats) KeyFrequency(key KeyStats) float64 {
+	if s.Rows == s.NullRows {
+		return 0
+	}
+	return float64(key.Rows) / float64(s.Rows-s.NullRows)
+}
+
+type keyStatsCollector struct {
+	KeyStats
+	distinct map[uint64]struct{}
+}
+
+// HstoreStatsCollector accumulates HstoreStats. Create it with NewHstoreStatsCollector.
+type HstoreStatsCollector struct {
+	stats HstoreStats
+	seed  maphash.Seed
+	keys  map[string]*keyStatsCollector
+}
+
+// NewHstoreStatsCollector returns an empty HstoreStatsCollector.
+func NewHstoreStatsCollector() *HstoreStatsCollector {
+	return &HstoreStatsCollector{seed: maphash.MakeSeed(), keys: map[string]*keyStatsCollector{}}
+}
+
+// Add adds the statistics of h, which is one row. It does not retain h.
+func (c *HstoreStatsCollector) Add(h Hstore) {
+	c.stats.Rows++
+	if h == nil {
+		c.stats.NullRows++
+		return
+	}
+	c.stats.PairCounts.add(len(h))
+	for k, v := range h {
+		key := c.keys[k]
+		if key == nil {
+			if len(c.keys) >= maxStatsKeys {
+				c.stats.UntrackedPairs++
+				if v.Valid {
+					c.stats.ValueSizes.add(len(v.String))
+				}
+				continue
+			}
+			// copy the key: it may share memory with the entire row
+			key = &keyStatsCollector{KeyStats: KeyStats{Key: strings.Clone(k)}, distinct: map[uint64]struct{}{}}
+			c.keys[key.Key] = key
+		}
+
+		key.Rows++
+		if !v.Valid {
+			key.NullValues++
+			continue
+		}
+		c.stats.ValueSizes.add(len(v.String))
+		key.ValueBytes += len(v.String)
+		if len(v.String) > key.MaxValueBytes {
+			key.MaxValueBytes = len(v.String)
+		}
+		if len(key.distinct) < maxStatsDistinctValues {
+			key.distinct[maphash.String(c.seed, v.String)] = struct{}{}
+		}
+	}
+}
+
+// Stats returns the statistics of the rows added so far.
+func (c *HstoreStatsCollector) Stats() HstoreStats {
+	stats := c.stats
+	stats.PairCounts.Counts = append([]int(nil), stats.PairCounts.Counts...)
+	stats.ValueSizes.Counts = append([]int(nil), stats.ValueSizes.Counts...)
+	stats.Keys = make([]KeyStats, 0, len(c.keys))
+	for _, key := range c.keys {
+		keyStats := key.KeyStats
+		keyStats.DistinctValues = len(key.distinct)
+		stats.Keys = append(stats.Keys, keyStats)
+	}
+	sort.Slice(stats.Keys, func(i, j int) bool {
+		if stats.Keys[i].Rows != stats.Keys[j].Rows {
+			return stats.Keys[i].Rows > stats.Keys[j].Rows
+		}
+		return stats.Keys[i].Key < stats.Keys[j].Key
+	})
+	return stats
+}
+
+// CollectHstoreStats reads column from all rows of table with StreamHstores, and returns its
+// statistics. The hstore type must already be registered on conn (see RegisterHstore).
+func CollectHstoreStats(ctx context.Context, conn *pgx.Conn, table pgx.Identifier, column string) (HstoreStats, error) {
+	sql := fmt.Sprintf("select %s from %s", pgx.Identifier{column}.Sanitize(), table.Sanitize())
+	rowc, errc := StreamHstores(ctx, conn, sql)
+	collector := NewHstoreStatsCollector()
+	for row := range rowc {
+		collector.Add(row.Hstore)
+		row.Release()
+	}
+	if err := <-errc; err != nil {
+		return HstoreStats{}, err
+	}
+	return collector.Stats(), nil
+}
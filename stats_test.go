@@ -0,0 +1,87 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestHstoreStatsCollector(t *testing.T) {
+	c := pgxtypefaster.NewHstoreStatsCollector()
+	c.Add(pgxtypefaster.Hstore{"color": pgxtypefaster.NewText("red"), "size": {}})
+	c.Add(pgxtypefaster.Hstore{"color": pgxtypefaster.NewText("blue")})
+	c.Add(pgxtypefaster.Hstore{"color": pgxtypefaster.NewText("red")})
+	c.Add(nil)
+	c.Add(pgxtypefaster.Hstore{})
+
+	stats := c.Stats()
+	if stats.Rows != 5 || stats.NullRows != 1 || stats.UntrackedPairs != 0 {
+		t.Errorf("Rows=%d NullRows=%d UntrackedPairs=%d", stats.Rows, stats.NullRows, stats.UntrackedPairs)
+	}
+	// pair counts: 2, 1, 1, 0
+	if !reflect.DeepEqual(stats.PairCounts.Counts, []int{1, 2, 1}) {
+		t.Errorf("PairCounts=%v", stats.PairCounts.Counts)
+	}
+	// value sizes: 3, 4, 3
+	if !reflect.DeepEqual(stats.ValueSizes.Counts, []int{0, 0, 2, 1}) {
+		t.Errorf("ValueSizes=%v", stats.ValueSizes.Counts)
+	}
+	if min, max := stats.ValueSizes.BucketRange(3); min != 4 || max != 7 {
+		t.Errorf("BucketRange(3)=%d, %d", min, max)
+	}
+
+	expected := []pgxtypefaster.KeyStats{
+		{Key: "color", Rows: 3, ValueBytes: 10, MaxValueBytes: 4, DistinctValues: 2},
+		{Key: "size", Rows: 1, NullValues: 1},
+	}
+	if !reflect.DeepEqual(stats.Keys, expected) {
+		t.Errorf("Keys=%#v; expected %#v", stats.Keys, expected)
+	}
+	if f := stats.KeyFrequency(stats.Keys[0]); f != 0.75 {
+		t.Errorf("KeyFrequency(color)=%f", f)
+	}
+
+	// the returned stats must not change when more rows are added
+	c.Add(pgxtypefaster.Hstore{"color": pgxtypefaster.NewText("green")})
+	if stats.Keys[0].Rows != 3 || stats.PairCounts.Counts[1] != 2 {
+		t.Error("Stats must return a copy")
+	}
+}
+
+func TestHstoreStatsCollectorLimits(t *testing.T) {
+	c := pgxtypefaster.NewHstoreStatsCollector()
+	for i := 0; i < 2000; i++ {
+		c.Add(pgxtypefaster.Hstore{
+			fmt.Sprintf("key%d", i): pgxtypefaster.NewText("v"),
+			"id":                    pgxtypefaster.NewText(fmt.Sprint(i)),
+		})
+	}
+	stats := c.Stats()
+	if len(stats.Keys) != 1000 || stats.UntrackedPairs != 1001 {
+		t.Errorf("len(Keys)=%d UntrackedPairs=%d", len(stats.Keys), stats.UntrackedPairs)
+	}
+	if stats.Keys[0].Key != "id" || !stats.Keys[0].DistinctSaturated() {
+		t.Errorf("Keys[0]=%#v", stats.Keys[0])
+	}
+}
+
+func TestCollectHstoreStats(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+	_, err := conn.Exec(ctx, `create table items (attrs hstore);
+		insert into items values ('a=>1, b=>2'), ('a=>3'), (null)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := pgxtypefaster.CollectHstoreStats(ctx, conn, pgx.Identifier{"items"}, "attrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Rows != 3 || stats.NullRows != 1 || len(stats.Keys) != 2 || stats.Keys[0].Key != "a" {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
@@ -0,0 +1,179 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// streamBufferSize is the number of rows decoded ahead of the consumer by StreamHstores.
+const streamBufferSize = 16
+
+// HstoreRow is a row sent by StreamHstores and StreamHstoreColumn.
+type HstoreRow struct {
+	// Hstore is the value of the row. NULL is a nil Hstore.
+	Hstore Hstore
+	alloc  *streamAllocator
+}
+
+// Release returns the row's Hstore to be reused for later rows, which reduces allocations. The
+// Hstore must not be used after calling Release, and Release must be called at most once. Calling
+// it is optional.
+func (r HstoreRow) Release() {
+	if r.alloc != nil && r.Hstore != nil {
+		r.alloc.release(r.Hstore)
+	}
+}
+
+// streamAllocator reuses released Hstores. Rows are scanned and released on different goroutines,
+// so it is protected by a mutex.
+type streamAllocator struct {
+	HeapAllocator
+	mu   sync.Mutex
+	free []Hstore
+}
+
+func (a *streamAllocator) AllocHstore(n int) Hstore {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if last := len(a.free) - 1; last >= 0 {
+		h := a.free[last]
+		a.free = a.free[:last]
+		return h
+	}
+	return make(Hstore, n)
+}
+
+func (a *streamAllocator) release(h Hstore) {
+	for k := range h {
+		delete(h, k)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// bound the memory used by released rows
+	if len(a.free) < 2*streamBufferSize {
+		a.free = append(a.free, h)
+	}
+}
+
+// withAllocator returns a copy of c that allocates from alloc.
+func (c HstoreCodec) withAllocator(alloc Allocator) HstoreCodec {
+	cfg := codecConfig{}
+	if c.cfg != nil {
+		cfg = *c.cfg
+	}
+	cfg.alloc = alloc
+	c.cfg = &cfg
+	return c
+}
+
+// StreamHstores runs sql, which must return one column of type hstore, and sends the values on
+// the returned row channel, decoding a bounded number of rows ahead of the consumer. After the row
+// channel is closed, the error channel returns the error, or nil. To stop early, cancel ctx; conn
+// cannot be used until the error channel is closed. The hstore type must already be registered on
+// conn (see RegisterHstore).
+//
+//	rows, errc := pgxtypefaster.StreamHstores(ctx, conn, "select attrs from items")
+//	for row := range rows {
+//		// process row.Hstore
+//		row.Release()
+//	}
+//	if err := <-errc; err != nil {
+//		return err
+//	}
+func StreamHstores(ctx context.Context, conn *pgx.Conn, sql string, args ...any) (<-chan HstoreRow, <-chan error) {
+	if err := checkHstoreRegistered(conn); err != nil {
+		return closedStream(err)
+	}
+	rows, err := conn.Query(ctx, sql, args...)
+	if err != nil {
+		return closedStream(err)
+	}
+	if columns := len(rows.FieldDescriptions()); columns != 1 {
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return closedStream(err)
+		}
+		return closedStream(fmt.Errorf("StreamHstores: query must return 1 column; returned %d", columns))
+	}
+	return StreamHstoreColumn(ctx, rows, 0)
+}
+
+// StreamHstoreColumn sends the Hstore values of the column with index column on the returned row
+// channel, like StreamHstores. It closes rows.
+func StreamHstoreColumn(ctx context.Context, rows pgx.Rows, column int) (<-chan HstoreRow, <-chan error) {
+	rowc := make(chan HstoreRow, streamBufferSize)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(rowc)
+		if err := streamHstoreColumn(ctx, rows, column, rowc); err != nil {
+			errc <- err
+		}
+	}()
+	return rowc, errc
+}
+
+func closedStream(err error) (<-chan HstoreRow, <-chan error) {
+	rowc := make(chan HstoreRow)
+	close(rowc)
+	errc := make(chan error, 1)
+	errc <- err
+	close(errc)
+	return rowc, errc
+}
+
+func streamHstoreColumn(ctx context.Context, rows pgx.Rows, column int, rowc chan<- HstoreRow) error {
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	if column < 0 || column >= len(fieldDescs) {
+		return fmt.Errorf("hstore column index %d out of range: rows have %d columns", column, len(fieldDescs))
+	}
+
+	// scan with a plan that reuses released rows if the column uses HstoreCodec without an allocator
+	var alloc *streamAllocator
+	var plan pgtype.ScanPlan
+	if conn := rows.Conn(); conn != nil {
+		desc := fieldDescs[column]
+		var h Hstore
+		if t, ok := conn.TypeMap().TypeForOID(desc.DataTypeOID); ok {
+			if codec, ok := t.Codec.(HstoreCodec); ok && codec.cfg.allocator() == nil {
+				alloc = &streamAllocator{}
+				plan = codec.withAllocator(alloc).PlanScan(conn.TypeMap(), desc.DataTypeOID, desc.Format, &h)
+			}
+		}
+		if plan == nil {
+			alloc = nil
+			plan = conn.TypeMap().PlanScan(desc.DataTypeOID, desc.Format, &h)
+		}
+	}
+	var scanTargets []any
+
+	for rows.Next() {
+		var h Hstore
+		var err error
+		if plan != nil {
+			err = plan.Scan(rows.RawValues()[column], &h)
+		} else {
+			if scanTargets == nil {
+				scanTargets = make([]any, len(fieldDescs))
+			}
+			scanTargets[column] = &h
+			err = rows.Scan(scanTargets...)
+		}
+		if err != nil {
+			return fmt.Errorf("hstore column %s: %w", fieldDescs[column].Name, err)
+		}
+
+		select {
+		case rowc <- HstoreRow{h, alloc}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
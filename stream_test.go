@@ -0,0 +1,91 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestStreamHstoreColumn(t *testing.T) {
+	rows := newFakeRows(collectFieldDescs, []string{"1", `"a"=>"b"`}, []string{"2", "NULL"})
+	rowc, errc := pgxtypefaster.StreamHstoreColumn(context.Background(), rows, 1)
+	var out []pgxtypefaster.Hstore
+	for row := range rowc {
+		out = append(out, row.Hstore)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	expected := []pgxtypefaster.Hstore{{"a": pgxtypefaster.NewText("b")}, nil}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("StreamHstoreColumn=%#v; expected %#v", out, expected)
+	}
+	if !rows.closed {
+		t.Error("rows must be closed")
+	}
+
+	rowc, errc = pgxtypefaster.StreamHstoreColumn(context.Background(), newFakeRows(collectFieldDescs), 2)
+	for range rowc {
+		t.Error("out of range column must not return rows")
+	}
+	if err := <-errc; err == nil {
+		t.Error("expected error for out of range column")
+	}
+}
+
+func TestStreamHstoreColumnCancel(t *testing.T) {
+	var values [][]string
+	for i := 0; i < 100; i++ {
+		values = append(values, []string{fmt.Sprint(i), `"k"=>"v"`})
+	}
+	rows := newFakeRows(collectFieldDescs, values...)
+	ctx, cancel := context.WithCancel(context.Background())
+	rowc, errc := pgxtypefaster.StreamHstoreColumn(ctx, rows, 1)
+
+	// read one row then stop: the stream must not read all rows
+	<-rowc
+	cancel()
+	for range rowc {
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled; err=%v", err)
+	}
+	if !rows.closed || rows.pos == len(values) {
+		t.Errorf("rows must be closed without reading all rows: closed=%t pos=%d", rows.closed, rows.pos)
+	}
+}
+
+func TestStreamHstores(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	const numRows = 1000
+	rowc, errc := pgxtypefaster.StreamHstores(ctx, conn,
+		`select hstore('k', i::text) from generate_series(0, $1 - 1) i`, numRows)
+	count := 0
+	for row := range rowc {
+		expected := pgxtypefaster.Hstore{"k": pgxtypefaster.NewText(fmt.Sprint(count))}
+		if !reflect.DeepEqual(row.Hstore, expected) {
+			t.Errorf("row %d: %#v; expected %#v", count, row.Hstore, expected)
+		}
+		row.Release()
+		count++
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if count != numRows {
+		t.Errorf("count=%d; expected %d", count, numRows)
+	}
+
+	rowc, errc = pgxtypefaster.StreamHstores(ctx, conn, `select 1, 2`)
+	for range rowc {
+	}
+	if err := <-errc; err == nil {
+		t.Error("expected error for 2 columns")
+	}
+}
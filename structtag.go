@@ -0,0 +1,139 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// hstoreField describes a struct field that is mapped to an hstore key using the "hstore" struct
+// tag. The tag format is the same as encoding/json: `hstore:"name,omitempty"`. Exported fields
+// without a tag use the field name as the key, and the tag `hstore:"-"` ignores the field.
+type hstoreField struct {
+	key       string
+	index     []int
+	omitEmpty bool
+	// nullable is true for pointers and pgtype.Text, which can represent NULL values.
+	nullable bool
+	// kind is the reflect.Kind of the value, after dereferencing pointers. It is reflect.String
+	// for pgtype.Text.
+	kind reflect.Kind
+}
+
+var textType = reflect.TypeOf(pgtype.Text{})
+
+// hstoreFieldCache maps reflect.Type to []hstoreField.
+var hstoreFieldCache sync.Map
+
+// cachedHstoreFields returns the hstore fields for struct type t.
+func cachedHstoreFields(t reflect.Type) ([]hstoreField, error) {
+	if fields, ok := hstoreFieldCache.Load(t); ok {
+		return fields.([]hstoreField), nil
+	}
+	fields, err := hstoreFields(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		if _, exists := keys[field.key]; exists {
+			return nil, fmt.Errorf("struct %s: duplicate hstore key %#v", t, field.key)
+		}
+		keys[field.key] = struct{}{}
+	}
+	fieldsAny, _ := hstoreFieldCache.LoadOrStore(t, fields)
+	return fieldsAny.([]hstoreField), nil
+}
+
+func hstoreFields(t reflect.Type, parentIndex []int) ([]hstoreField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("hstore struct mapping requires a struct; found %s", t)
+	}
+
+	var fields []hstoreField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("hstore")
+		if tag == "-" {
+			continue
+		}
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		// flatten untagged embedded structs like encoding/json
+		if sf.Anonymous && !hasTag && sf.Type.Kind() == reflect.Struct && sf.Type != textType {
+			embedded, err := hstoreFields(sf.Type, index)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		key, opts, _ := strings.Cut(tag, ",")
+		if key == "" {
+			key = sf.Name
+		}
+		field := hstoreField{key: key, index: index}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				field.omitEmpty = true
+			case "":
+			default:
+				return nil, fmt.Errorf("field %s.%s: unsupported hstore tag option %#v", t, sf.Name, opt)
+			}
+		}
+
+		valueType := sf.Type
+		if valueType.Kind() == reflect.Pointer {
+			field.nullable = true
+			valueType = valueType.Elem()
+		}
+		if valueType == textType {
+			field.nullable = true
+			field.kind = reflect.String
+		} else {
+			field.kind = valueType.Kind()
+		}
+		if !isSupportedHstoreFieldKind(field.kind) {
+			return nil, fmt.Errorf("field %s.%s: unsupported hstore field type %s", t, sf.Name, sf.Type)
+		}
+
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func isSupportedHstoreFieldKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// structType returns the struct type of v, which must be a struct or a pointer to a struct.
+func structType(v any) (reflect.Type, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("hstore struct mapping requires a struct; found nil")
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("hstore struct mapping requires a struct; found %s", t)
+	}
+	return t, nil
+}
@@ -0,0 +1,131 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanj/pgxtypefaster/pgio"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// HstoreSubset is a scan target for HstoreCodec that only keeps the pairs with Keys. It skips the
+// allocations for other pairs, which uses much less memory when rows have many keys but only a few
+// are needed. With the binary format it is also faster, but the text format must still be parsed
+// entirely (see BenchmarkHstoreSubset). Scanning sets Out to a new Hstore with the requested keys
+// that exist, or nil for NULL. The keys are compared linearly, so it is intended for a small number
+// of keys.
+type HstoreSubset struct {
+	Keys []string
+	Out  Hstore
+}
+
+// index returns the index of key in s.Keys, or -1.
+func (s *HstoreSubset) index(key string) int {
+	for i, k := range s.Keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// scanPlanHstoreToSubset scans into *HstoreSubset. If next is not nil, it scans an Hstore with
+// next, which applies the codec options, then selects the keys.
+type scanPlanHstoreToSubset struct {
+	format int16
+	next   pgtype.ScanPlan
+}
+
+func (c HstoreCodec) planScanSubset(m *pgtype.Map, oid uint32, format int16) pgtype.ScanPlan {
+	if format != pgtype.BinaryFormatCode && format != pgtype.TextFormatCode {
+		return nil
+	}
+	p := &scanPlanHstoreToSubset{format: format}
+	if c.cfg.scansWithHstorePlan() {
+		p.next = c.PlanScan(m, oid, format, (*Hstore)(nil))
+	}
+	return p
+}
+
+func (p *scanPlanHstoreToSubset) Scan(src []byte, dst any) error {
+	subset := dst.(*HstoreSubset)
+	if src == nil {
+		subset.Out = nil
+		return nil
+	}
+
+	var out Hstore
+	var err error
+	if p.next != nil {
+		var h Hstore
+		if err := p.next.Scan(src, &h); err != nil {
+			return err
+		}
+		out = make(Hstore, len(subset.Keys))
+		for _, k := range subset.Keys {
+			if v, ok := h[k]; ok {
+				out[k] = v
+			}
+		}
+	} else if p.format == pgtype.BinaryFormatCode {
+		out, err = parseBinarySubset(src, subset)
+	} else {
+		out, err = parseTextSubset(src, subset)
+	}
+	if err != nil {
+		return err
+	}
+	subset.Out = out
+	return nil
+}
+
+// parseBinarySubset parses src in the binary format, only allocating strings for subset's keys.
+func parseBinarySubset(src []byte, subset *HstoreSubset) (Hstore, error) {
+	var r pgio.Reader
+	r.Reset(src)
+	pairCount := r.ReadCount(minBinaryPairLen)
+	if r.Err() != nil {
+		return nil, fmt.Errorf("hstore incomplete: %w", r.Err())
+	}
+
+	out := make(Hstore, len(subset.Keys))
+	for i := 0; i < pairCount; i++ {
+		keyStart, keyLen := r.ReadLengthPrefixedRange()
+		valueStart, valueLen := r.ReadLengthPrefixedRange()
+		if r.Err() != nil {
+			return nil, fmt.Errorf("hstore incomplete: %w", r.Err())
+		}
+		if keyLen < 0 {
+			return nil, errBinaryNullKey
+		}
+		// the conversion in the comparison does not allocate
+		keyIndex := subset.index(string(src[keyStart : keyStart+keyLen]))
+		if keyIndex < 0 {
+			continue
+		}
+		var value pgtype.Text
+		if valueLen >= 0 {
+			value = NewText(string(src[valueStart : valueStart+valueLen]))
+		}
+		out[subset.Keys[keyIndex]] = value
+	}
+	return out, nil
+}
+
+// parseTextSubset parses src in the text format. The values are copied, so they do not share
+// memory with the entire text.
+func parseTextSubset(src []byte, subset *HstoreSubset) (Hstore, error) {
+	out := make(Hstore, len(subset.Keys))
+	err := parsePairsTextFunc(string(src), func(key string, value pgtype.Text) error {
+		keyIndex := subset.index(key)
+		if keyIndex >= 0 {
+			value.String = strings.Clone(value.String)
+			out[subset.Keys[keyIndex]] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package pgxtypefaster_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestHstoreSubset(t *testing.T) {
+	m := newOptionsTypeMap()
+	skipNulls := newOptionsTypeMap(pgxtypefaster.WithNullPolicy(pgxtypefaster.NullSkip))
+	input := pgxtypefaster.Hstore{
+		"a":     pgxtypefaster.NewText("1"),
+		"b":     pgxtypefaster.NewText(`quoted"\`),
+		"null":  pgtype.Text{},
+		"other": pgxtypefaster.NewText("ignored"),
+	}
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subset := pgxtypefaster.HstoreSubset{Keys: []string{"a", "b", "null", "missing"}}
+		if err := m.Scan(testHstoreOID, format, buf, &subset); err != nil {
+			t.Fatal(err)
+		}
+		expected := pgxtypefaster.Hstore{"a": input["a"], "b": input["b"], "null": pgtype.Text{}}
+		if !reflect.DeepEqual(subset.Out, expected) {
+			t.Errorf("format=%d: Out=%#v; expected %#v", format, subset.Out, expected)
+		}
+
+		// codec options are applied
+		if err := skipNulls.Scan(testHstoreOID, format, buf, &subset); err != nil {
+			t.Fatal(err)
+		}
+		delete(expected, "null")
+		if !reflect.DeepEqual(subset.Out, expected) {
+			t.Errorf("format=%d: with NullSkip Out=%#v; expected %#v", format, subset.Out, expected)
+		}
+
+		if err := m.Scan(testHstoreOID, format, nil, &subset); err != nil || subset.Out != nil {
+			t.Errorf("format=%d: NULL must set Out to nil: %#v %v", format, subset.Out, err)
+		}
+	}
+
+	subset := pgxtypefaster.HstoreSubset{Keys: []string{"a"}}
+	if err := m.Scan(testHstoreOID, pgtype.BinaryFormatCode, []byte{0, 0, 0, 1}, &subset); err == nil {
+		t.Error("expected error for truncated binary input")
+	}
+}
+
+func BenchmarkHstoreSubset(b *testing.B) {
+	input := pgxtypefaster.Hstore{}
+	for i := 0; i < 50; i++ {
+		input[fmt.Sprintf("key%02d", i)] = pgxtypefaster.NewText(fmt.Sprintf("value%d", i))
+	}
+	m := newTestTypeMap()
+	for _, format := range formats {
+		buf, err := m.Encode(testHstoreOID, format, input, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var h pgxtypefaster.Hstore
+		b.Run(fmt.Sprintf("Hstore/format=%d", format), func(b *testing.B) {
+			plan := m.PlanScan(testHstoreOID, format, &h)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := plan.Scan(buf, &h); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		subset := pgxtypefaster.HstoreSubset{Keys: []string{"key01", "key20", "key40"}}
+		b.Run(fmt.Sprintf("HstoreSubset/format=%d", format), func(b *testing.B) {
+			plan := m.PlanScan(testHstoreOID, format, &subset)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := plan.Scan(buf, &subset); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
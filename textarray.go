@@ -0,0 +1,193 @@
+package pgxtypefaster
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TextArrayCodec is a codec for text[] and varchar[] that scans into *[]string and *[]pgtype.Text
+// with one allocation for the slice and, like the hstore binary scanner, one shared string for all
+// elements, instead of one allocation per element. In the text format, elements with escapes are
+// allocated separately. Multidimensional arrays are flattened. Scanning NULL elements into
+// []string returns an error. Other types are scanned and encoded with pgtype.ArrayCodec. It uses
+// the WithAllocator, WithZeroCopy, and WithSeparateStrings codec options.
+type TextArrayCodec struct {
+	cfg *codecConfig
+}
+
+// NewTextArrayCodec returns a TextArrayCodec configured with opts.
+func NewTextArrayCodec(opts ...CodecOption) TextArrayCodec {
+	return TextArrayCodec{cfg: newCodecConfig(opts)}
+}
+
+func (TextArrayCodec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || format == pgtype.BinaryFormatCode
+}
+
+// PreferredFormat returns the binary format, which is faster to parse.
+func (TextArrayCodec) PreferredFormat() int16 {
+	return pgtype.BinaryFormatCode
+}
+
+// arrayCodec returns the pgtype codec for the array type oid.
+func (TextArrayCodec) arrayCodec(m *pgtype.Map, oid uint32) *pgtype.ArrayCodec {
+	elementOID := uint32(pgtype.TextOID)
+	if oid == pgtype.VarcharArrayOID {
+		elementOID = pgtype.VarcharOID
+	}
+	elementType, ok := m.TypeForOID(elementOID)
+	if !ok {
+		elementType = &pgtype.Type{Codec: pgtype.TextCodec{}, Name: "text", OID: pgtype.TextOID}
+	}
+	return &pgtype.ArrayCodec{ElementType: elementType}
+}
+
+func (c TextArrayCodec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value any) pgtype.EncodePlan {
+	return c.arrayCodec(m, oid).PlanEncode(m, oid, format, value)
+}
+
+func (c TextArrayCodec) PlanScan(m *pgtype.Map, oid uint32, format int16, target any) pgtype.ScanPlan {
+	switch target.(type) {
+	case *[]string, *[]pgtype.Text:
+		switch format {
+		case pgtype.BinaryFormatCode:
+			return scanPlanTextArrayBinary{c.cfg.allocator(), c.cfg.separatesStrings()}
+		case pgtype.TextFormatCode:
+			return scanPlanTextArrayText{c.cfg.allocator(), c.cfg.separatesStrings()}
+		}
+		return nil
+	}
+	return c.arrayCodec(m, oid).PlanScan(m, oid, format, target)
+}
+
+func (c TextArrayCodec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	return c.arrayCodec(m, oid).DecodeDatabaseSQLValue(m, oid, format, src)
+}
+
+func (c TextArrayCodec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (any, error) {
+	return c.arrayCodec(m, oid).DecodeValue(m, oid, format, src)
+}
+
+// textArrayElements stores the scanned elements in either strs or texts, depending on the target.
+type textArrayElements struct {
+	strs  []string
+	texts []pgtype.Text
+}
+
+func newTextArrayElements(dst any, n int) textArrayElements {
+	if _, ok := dst.(*[]string); ok {
+		return textArrayElements{strs: make([]string, 0, n)}
+	}
+	return textArrayElements{texts: make([]pgtype.Text, 0, n)}
+}
+
+func (e *textArrayElements) append(index int, s string, valid bool) error {
+	if e.strs != nil {
+		if !valid {
+			return fmt.Errorf("array element %d: cannot scan NULL into string", index)
+		}
+		e.strs = append(e.strs, s)
+		return nil
+	}
+	e.texts = append(e.texts, pgtype.Text{String: s, Valid: valid})
+	return nil
+}
+
+// store sets dst to the elements, or to nil for NULL.
+func (e *textArrayElements) store(dst any) {
+	switch dst := dst.(type) {
+	case *[]string:
+		*dst = e.strs
+	case *[]pgtype.Text:
+		*dst = e.texts
+	}
+}
+
+// scanPlanTextArrayBinary scans the binary format. Unless separate is true, the elements are
+// substrings of one string with the entire array.
+type scanPlanTextArrayBinary struct {
+	alloc    Allocator
+	separate bool
+}
+
+func (p scanPlanTextArrayBinary) Scan(src []byte, dst any) error {
+	if src == nil {
+		(&textArrayElements{}).store(dst)
+		return nil
+	}
+
+	var header ArrayHeader
+	var shared string
+	if !p.separate {
+		shared = allocString(p.alloc, src)
+	}
+	// pos is the offset of the next element's length
+	pos := 0
+	var elements textArrayElements
+	err := ParseArrayBinary(src, &header, func(index int, elemSrc []byte) error {
+		if index == 0 {
+			elements = newTextArrayElements(dst, header.ElementCount())
+			pos = arrayBinaryHeaderLen + arrayBinaryDimensionLen*len(header.Dimensions)
+		}
+		pos += 4
+		if elemSrc == nil {
+			return elements.append(index, "", false)
+		}
+		var s string
+		if p.separate {
+			s = allocString(p.alloc, elemSrc)
+		} else {
+			s = shared[pos : pos+len(elemSrc)]
+		}
+		pos += len(elemSrc)
+		return elements.append(index, s, true)
+	})
+	if err != nil {
+		return err
+	}
+	if header.ElementCount() == 0 {
+		elements = newTextArrayElements(dst, 0)
+	}
+	elements.store(dst)
+	return nil
+}
+
+// The binary format starts with the number of dimensions, the flags, and the element OID, followed
+// by the length and lower bound of each dimension.
+const (
+	arrayBinaryHeaderLen    = 12
+	arrayBinaryDimensionLen = 8
+)
+
+// scanPlanTextArrayText scans the text format. Unless separate is true, elements without escapes
+// are substrings of one string with the entire array.
+type scanPlanTextArrayText struct {
+	alloc    Allocator
+	separate bool
+}
+
+func (p scanPlanTextArrayText) Scan(src []byte, dst any) error {
+	if src == nil {
+		(&textArrayElements{}).store(dst)
+		return nil
+	}
+
+	// count the delimiters to estimate the number of elements
+	elements := newTextArrayElements(dst, bytes.Count(src, []byte{','})+1)
+	var header ArrayHeader
+	err := ParseArrayText(allocString(p.alloc, src), ',', &header, func(index int, s string, valid bool) error {
+		if p.separate && valid {
+			s = strings.Clone(s)
+		}
+		return elements.append(index, s, valid)
+	})
+	if err != nil {
+		return err
+	}
+	elements.store(dst)
+	return nil
+}
@@ -0,0 +1,188 @@
+package pgxtypefaster_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func newTextArrayTypeMap(opts ...pgxtypefaster.CodecOption) *pgtype.Map {
+	m := pgtype.NewMap()
+	m.RegisterType(&pgtype.Type{Codec: pgxtypefaster.NewTextArrayCodec(opts...), Name: "_text", OID: pgtype.TextArrayOID})
+	return m
+}
+
+func TestTextArrayCodec(t *testing.T) {
+	pgtypeMap := pgtype.NewMap()
+	optionSets := [][]pgxtypefaster.CodecOption{
+		nil,
+		{pgxtypefaster.WithSeparateStrings()},
+		{pgxtypefaster.WithZeroCopy()},
+		{pgxtypefaster.WithAllocator(pgxtypefaster.NewArena(0))},
+	}
+	values := []pgtype.Array[pgtype.Text]{
+		textArray([]pgtype.ArrayDimension{}),
+		textArray([]pgtype.ArrayDimension{{Length: 1, LowerBound: 1}}, "a"),
+		textArray([]pgtype.ArrayDimension{{Length: 4, LowerBound: 1}},
+			`with "quotes", \backslashes\ {braces}`, "", " spaces ", "é"),
+		textArray([]pgtype.ArrayDimension{{Length: 2, LowerBound: 1}, {Length: 3, LowerBound: 0}},
+			"a", "b", "c", "d", "e", "f"),
+	}
+	withNull := textArray([]pgtype.ArrayDimension{{Length: 3, LowerBound: 1}}, "a", "", "NULL")
+	withNull.Elements[1] = pgtype.Text{}
+	values = append(values, withNull)
+
+	for _, opts := range optionSets {
+		m := newTextArrayTypeMap(opts...)
+		for _, value := range values {
+			for _, format := range formats {
+				src, err := pgtypeMap.Encode(pgtype.TextArrayOID, format, value, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// encoding uses pgtype
+				encoded, err := m.Encode(pgtype.TextArrayOID, format, value, nil)
+				if err != nil || string(encoded) != string(src) {
+					t.Errorf("format=%d: Encode(%#v)=%q, %v; expected %q", format, value, encoded, err, src)
+				}
+
+				var texts []pgtype.Text
+				if err := m.Scan(pgtype.TextArrayOID, format, src, &texts); err != nil {
+					t.Fatalf("format=%d: Scan(%q): %s", format, src, err)
+				}
+				expected := value.Elements
+				if expected == nil {
+					expected = []pgtype.Text{}
+				}
+				if !reflect.DeepEqual(texts, expected) {
+					t.Errorf("format=%d: Scan(%q)=%#v; expected %#v", format, src, texts, expected)
+				}
+
+				var strs []string
+				err = m.Scan(pgtype.TextArrayOID, format, src, &strs)
+				hasNull := false
+				for _, element := range value.Elements {
+					hasNull = hasNull || !element.Valid
+				}
+				if hasNull {
+					if err == nil {
+						t.Errorf("format=%d: scanning a NULL element into []string must fail", format)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("format=%d: Scan(%q): %s", format, src, err)
+				}
+				expectedStrs := []string{}
+				for _, element := range value.Elements {
+					expectedStrs = append(expectedStrs, element.String)
+				}
+				if !reflect.DeepEqual(strs, expectedStrs) {
+					t.Errorf("format=%d: Scan(%q)=%#v; expected %#v", format, src, strs, expectedStrs)
+				}
+			}
+		}
+
+		for _, format := range formats {
+			strs := []string{"x"}
+			texts := []pgtype.Text{{String: "x", Valid: true}}
+			for _, target := range []any{&strs, &texts} {
+				if err := m.Scan(pgtype.TextArrayOID, format, nil, target); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if strs != nil || texts != nil {
+				t.Errorf("format=%d: scanning NULL returned %#v %#v", format, strs, texts)
+			}
+		}
+	}
+
+	// other targets use pgtype
+	m := newTextArrayTypeMap()
+	var array pgtype.FlatArray[string]
+	if err := m.Scan(pgtype.TextArrayOID, pgtype.TextFormatCode, []byte(`{a,b}`), &array); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(array, pgtype.FlatArray[string]{"a", "b"}) {
+		t.Errorf("scanned %#v", array)
+	}
+
+	// Postgres keeps escaped trailing whitespace in unquoted elements, but not unescaped whitespace
+	var texts []pgtype.Text
+	if err := m.Scan(pgtype.TextArrayOID, pgtype.TextFormatCode, []byte(`{a\ , b\\ }`), &texts); err != nil {
+		t.Fatal(err)
+	}
+	expectedTexts := []pgtype.Text{{String: "a ", Valid: true}, {String: `b\`, Valid: true}}
+	if !reflect.DeepEqual(texts, expectedTexts) {
+		t.Errorf("scanned %#v; expected %#v", texts, expectedTexts)
+	}
+
+	for _, invalid := range []string{`{a,b`, `{"a}`, `a`} {
+		var strs []string
+		if err := m.Scan(pgtype.TextArrayOID, pgtype.TextFormatCode, []byte(invalid), &strs); err == nil {
+			t.Errorf("Scan(%q): expected error", invalid)
+		}
+	}
+	src, err := m.Encode(pgtype.TextArrayOID, pgtype.BinaryFormatCode, []string{"a", "b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range src {
+		var strs []string
+		if err := m.Scan(pgtype.TextArrayOID, pgtype.BinaryFormatCode, src[:i], &strs); err == nil {
+			t.Errorf("Scan(%q): expected error for truncated input", src[:i])
+		}
+	}
+}
+
+func TestTextArrayCodecAllocs(t *testing.T) {
+	m := newTextArrayTypeMap()
+	values := []string{"first", "second", "third", "fourth", "fifth"}
+	for _, format := range formats {
+		src, err := m.Encode(pgtype.TextArrayOID, format, values, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var strs []string
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := m.Scan(pgtype.TextArrayOID, format, src, &strs); err != nil {
+				t.Fatal(err)
+			}
+		})
+		// the slice, the shared string, and the array dimensions
+		if allocs != 3 {
+			t.Errorf("format=%d: scanning []string allocated %.1f times; expected 3", format, allocs)
+		}
+	}
+}
+
+func BenchmarkTextArrayScan(b *testing.B) {
+	values := []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta", "iota", "kappa"}
+	codecs := []struct {
+		name string
+		m    *pgtype.Map
+	}{
+		{"pgtype", pgtype.NewMap()},
+		{"pgxtypefaster", newTextArrayTypeMap()},
+	}
+	for _, codec := range codecs {
+		for _, format := range formats {
+			src, err := codec.m.Encode(pgtype.TextArrayOID, format, values, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("%s/format=%d", codec.name, format), func(b *testing.B) {
+				var strs []string
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := codec.m.Scan(pgtype.TextArrayOID, format, src, &strs); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
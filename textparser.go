@@ -0,0 +1,65 @@
+package pgxtypefaster
+
+import "github.com/evanj/pgxtypefaster/internal/parsing"
+
+// TextParser parses Postgres text formats using the same techniques as the Hstore parser: strings
+// it returns are substrings of the input unless they contain escapes, so parsing a value with many
+// elements makes one allocation for the input string. It can be used to write fast codecs for
+// other text formats, such as arrays, composite types, and ranges.
+type TextParser struct {
+	p parsing.Parser
+}
+
+// NewTextParser returns a TextParser that parses s.
+func NewTextParser(s string) *TextParser {
+	return &TextParser{parsing.New(s)}
+}
+
+// Pos returns the offset of the next byte to be consumed.
+func (t *TextParser) Pos() int {
+	return t.p.Pos()
+}
+
+// Remaining returns the part of the input that has not been consumed.
+func (t *TextParser) Remaining() string {
+	return t.p.Remaining()
+}
+
+// AtEnd returns true if the entire input has been consumed.
+func (t *TextParser) AtEnd() bool {
+	return t.p.AtEnd()
+}
+
+// Peek returns the next byte without consuming it, or end if the input is done.
+func (t *TextParser) Peek() (b byte, end bool) {
+	return t.p.Peek()
+}
+
+// Consume returns the next byte, or end if the input is done.
+func (t *TextParser) Consume() (b byte, end bool) {
+	return t.p.Consume()
+}
+
+// ConsumeExpectedByte consumes expected, or returns an error if the next byte is different.
+func (t *TextParser) ConsumeExpectedByte(expected byte) error {
+	return t.p.ConsumeExpectedByte(expected)
+}
+
+// ConsumeDoubleQuoted consumes a double-quoted string where the only valid escapes are \\ and \",
+// as in the hstore text format. The opening double quote must have been consumed already.
+func (t *TextParser) ConsumeDoubleQuoted() (string, error) {
+	return t.p.ConsumeDoubleQuoted()
+}
+
+// ConsumeDoubleQuotedAnyEscape consumes a double-quoted string where a backslash escapes any byte,
+// as in the array and composite text formats. The opening double quote must have been consumed
+// already.
+func (t *TextParser) ConsumeDoubleQuotedAnyEscape() (string, error) {
+	return t.p.ConsumeDoubleQuotedAnyEscape()
+}
+
+// ConsumeUnquoted consumes bytes up to the first byte in delimiters or the end of the input. A
+// backslash escapes the following byte.
+func (t *TextParser) ConsumeUnquoted(delimiters string) (string, error) {
+	return t.p.ConsumeUnquoted(delimiters)
+}
@@ -0,0 +1,95 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+// parseTestArray parses a one-dimensional array of text in the Postgres text format.
+func parseTestArray(s string) ([]string, error) {
+	p := pgxtypefaster.NewTextParser(s)
+	if err := p.ConsumeExpectedByte('{'); err != nil {
+		return nil, err
+	}
+	var elements []string
+	for {
+		if b, _ := p.Peek(); b == '}' && len(elements) == 0 {
+			p.Consume()
+			return elements, nil
+		}
+		var element string
+		var err error
+		if b, _ := p.Peek(); b == '"' {
+			p.Consume()
+			element, err = p.ConsumeDoubleQuotedAnyEscape()
+		} else {
+			element, err = p.ConsumeUnquoted(",}")
+		}
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		b, end := p.Consume()
+		if end {
+			return nil, errTestArrayEnd
+		}
+		if b == '}' {
+			return elements, nil
+		}
+	}
+}
+
+var errTestArrayEnd = errors.New("array not terminated")
+
+func TestTextParser(t *testing.T) {
+	input := `{plain,"quoted, with \"escapes\"",esc\,aped,""}`
+	elements, err := parseTestArray(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"plain", `quoted, with "escapes"`, "esc,aped", ""}
+	if len(elements) != len(expected) {
+		t.Fatalf("elements=%#v; expected %#v", elements, expected)
+	}
+	for i := range expected {
+		if elements[i] != expected[i] {
+			t.Errorf("elements[%d]=%#v; expected %#v", i, elements[i], expected[i])
+		}
+	}
+
+	for _, invalid := range []string{`{"unterminated`, `{a,b`, `{a\`, `x`} {
+		if _, err := parseTestArray(invalid); err == nil {
+			t.Errorf("parseTestArray(%#v) expected error", invalid)
+		}
+	}
+
+	// the hstore rules only allow escaping quotes and backslashes
+	p := pgxtypefaster.NewTextParser(`a\bc"`)
+	if _, err := p.ConsumeDoubleQuoted(); err == nil {
+		t.Error("ConsumeDoubleQuoted expected error for invalid escape")
+	}
+	p = pgxtypefaster.NewTextParser(`a\bc"rest`)
+	s, err := p.ConsumeDoubleQuotedAnyEscape()
+	if err != nil || s != "abc" || p.Remaining() != "rest" || p.Pos() != 5 {
+		t.Errorf("ConsumeDoubleQuotedAnyEscape()=%#v, %v; Remaining()=%#v Pos()=%d",
+			s, err, p.Remaining(), p.Pos())
+	}
+
+	// consuming a backslash byte by byte must not confuse the escape search
+	p = pgxtypefaster.NewTextParser(`\"a"`)
+	p.Consume()
+	p.Consume()
+	s, err = p.ConsumeDoubleQuoted()
+	if err != nil || s != "a" || !p.AtEnd() {
+		t.Errorf("ConsumeDoubleQuoted() after consuming a backslash=%#v, %v", s, err)
+	}
+	p = pgxtypefaster.NewTextParser(`\ab\,c`)
+	p.Consume()
+	s, err = p.ConsumeUnquoted(",")
+	if err != nil || s != `ab,c` || !p.AtEnd() {
+		t.Errorf("ConsumeUnquoted() after consuming a backslash=%#v, %v", s, err)
+	}
+}
@@ -0,0 +1,114 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// HstoreTracer is a pgx.QueryTracer that registers HstoreCodec on a connection before its first
+// query, instead of when it connects. This works behind PgBouncer in transaction pooling mode:
+// connecting does not query, and each new connection, such as one replacing a connection that was
+// reset, registers hstore again. Each connection queries the OID, unless it is cached in
+// DefaultOIDCache. Until hstore is registered, or if registering fails, Hstore values use the text
+// format with database/sql interfaces, so errors are ignored and registering is tried again before
+// the next query. If a database does not have hstore, its connections do not try again for
+// MissingHstoreRetry, or until Reset is called, so creating the extension is noticed. Only
+// queries trigger registering: Conn.SendBatch and Conn.CopyFrom do not call a QueryTracer, so
+// they use the text format until a query registers hstore. Set it as pgx.ConnConfig.Tracer. It is
+// safe to use concurrently.
+type HstoreTracer struct {
+	next  pgx.QueryTracer
+	opts  []CodecOption
+	codec HstoreCodec
+
+	mu sync.Mutex
+	// missing records when databases without hstore were queried, so they are not queried before
+	// each query
+	missing map[oidCacheKey]time.Time
+}
+
+// MissingHstoreRetry is how long HstoreTracer waits before querying a database without hstore
+// again.
+const MissingHstoreRetry = time.Minute
+
+// NewHstoreTracer returns an HstoreTracer that registers codecs configured with opts. If next is
+// not nil, it is called for each query, including the query for the hstore OID.
+func NewHstoreTracer(next pgx.QueryTracer, opts ...CodecOption) *HstoreTracer {
+	return &HstoreTracer{next: next, opts: opts, codec: NewHstoreCodec(opts...),
+		missing: map[oidCacheKey]time.Time{}}
+}
+
+// Reset forgets the databases without hstore, so the next query on each of their connections
+// tries to register it again. Call it after creating the hstore extension.
+func (t *HstoreTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.missing = map[oidCacheKey]time.Time{}
+}
+
+// hstoreTracerResolving marks the context of the query for the hstore OID, so it is not traced
+// recursively.
+type hstoreTracerResolving struct{}
+
+// TraceQueryStart registers hstore on conn if it is not registered.
+func (t *HstoreTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if ctx.Value(hstoreTracerResolving{}) == nil {
+		t.register(ctx, conn)
+	}
+	if t.next != nil {
+		return t.next.TraceQueryStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+// TraceQueryEnd calls the next tracer.
+func (t *HstoreTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	if t.next != nil {
+		t.next.TraceQueryEnd(ctx, conn, data)
+	}
+}
+
+func (t *HstoreTracer) register(ctx context.Context, conn *pgx.Conn) {
+	if _, ok := conn.TypeMap().TypeForName("hstore"); ok {
+		return
+	}
+	key := newOIDCacheKey(conn, "hstore")
+	if t.recentlyMissing(key) {
+		return
+	}
+	oid, err := queryHstoreOID(context.WithValue(ctx, hstoreTracerResolving{}, true), conn)
+	if err == ErrHstoreDoesNotExist {
+		t.mu.Lock()
+		t.missing[key] = time.Now()
+		t.mu.Unlock()
+		return
+	}
+	if err != nil {
+		return
+	}
+	codec := t.codec
+	if extra := hstoreRegisterOptions(conn); extra != nil {
+		codec = NewHstoreCodec(append(t.opts[:len(t.opts):len(t.opts)], extra...)...)
+	}
+	conn.TypeMap().RegisterType(&pgtype.Type{Codec: codec, Name: "hstore", OID: oid})
+}
+
+// recentlyMissing returns true if the database for key did not have hstore within
+// MissingHstoreRetry.
+func (t *HstoreTracer) recentlyMissing(key oidCacheKey) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	queried, ok := t.missing[key]
+	if !ok {
+		return false
+	}
+	if time.Since(queried) >= MissingHstoreRetry {
+		delete(t.missing, key)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+)
+
+// countingTracer counts the traced queries.
+type countingTracer struct {
+	starts int
+	ends   int
+}
+
+func (c *countingTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	c.starts++
+	return ctx
+}
+
+func (c *countingTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	c.ends++
+}
+
+func TestHstoreTracer(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+	hstoreType, _ := conn.TypeMap().TypeForName("hstore")
+
+	next := &countingTracer{}
+	config := conn.Config()
+	config.Tracer = pgxtypefaster.NewHstoreTracer(next, pgxtypefaster.WithMaxPairs(1))
+	lazyConn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lazyConn.Close(ctx)
+	if _, ok := lazyConn.TypeMap().TypeForName("hstore"); ok {
+		t.Fatal("hstore must not be registered when connecting")
+	}
+
+	var h pgxtypefaster.Hstore
+	if err := lazyConn.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Equal(pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}) {
+		t.Errorf("scanned %#v", h)
+	}
+	registered, ok := lazyConn.TypeMap().TypeForName("hstore")
+	if !ok || registered.OID != hstoreType.OID {
+		t.Fatalf("hstore not registered: %#v", registered)
+	}
+	// the query for the OID and the query were traced
+	if next.starts != 2 || next.ends != 2 {
+		t.Errorf("next called starts=%d ends=%d; expected 2", next.starts, next.ends)
+	}
+	// the options are applied
+	if err := lazyConn.QueryRow(ctx, `select 'a=>b, c=>d'::hstore`).Scan(&h); err == nil {
+		t.Error("expected WithMaxPairs error")
+	}
+}
+
+func TestHstoreTracerMissing(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	// a database without hstore
+	if _, err := conn.Exec(ctx, "create database tracer_missing"); err != nil {
+		t.Fatal(err)
+	}
+	config := conn.Config()
+	config.Database = "tracer_missing"
+	tracer := pgxtypefaster.NewHstoreTracer(nil)
+	config.Tracer = tracer
+	lazyConn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lazyConn.Close(ctx)
+
+	if _, err := lazyConn.Exec(ctx, "select 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lazyConn.TypeMap().TypeForName("hstore"); ok {
+		t.Fatal("hstore must not be registered without the extension")
+	}
+
+	// the missing database is remembered, until Reset
+	if _, err := lazyConn.Exec(ctx, "create extension hstore"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lazyConn.TypeMap().TypeForName("hstore"); ok {
+		t.Fatal("hstore must not be registered before MissingHstoreRetry or Reset")
+	}
+	tracer.Reset()
+	var h pgxtypefaster.Hstore
+	if err := lazyConn.QueryRow(ctx, `select 'a=>b'::hstore`).Scan(&h); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lazyConn.TypeMap().TypeForName("hstore"); !ok {
+		t.Error("hstore must be registered after Reset")
+	}
+}
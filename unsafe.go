@@ -0,0 +1,21 @@
+//go:build pgxtypefasterunsafe
+
+package pgxtypefaster
+
+import "unsafe"
+
+// UnsafeOptimizations is true if the package was built with the pgxtypefasterunsafe build tag,
+// which enables optimizations that use the unsafe package.
+const UnsafeOptimizations = true
+
+// ownedBytesToString returns b as a string. With the pgxtypefasterunsafe build tag it does not
+// copy, so b must not be modified or reused after this call.
+func ownedBytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// borrowedBytesToString returns b as a string for WithZeroCopy. With the pgxtypefasterunsafe build
+// tag it does not copy, so the string is only valid while b is not modified.
+func borrowedBytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
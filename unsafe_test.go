@@ -0,0 +1,34 @@
+package pgxtypefaster_test
+
+import (
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestValueBothModes(t *testing.T) {
+	t.Logf("UnsafeOptimizations=%t", pgxtypefaster.UnsafeOptimizations)
+
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b"), "c": {}}
+	value, err := h.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out pgxtypefaster.Hstore
+	if err := out.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out["a"] != h["a"] || out["c"].Valid {
+		t.Errorf("round trip: got %#v; expected %#v", out, h)
+	}
+
+	// encoding again must not change the first value
+	first := value.(string)
+	h["a"] = pgxtypefaster.NewText("changed")
+	if _, err := h.Value(); err != nil {
+		t.Fatal(err)
+	}
+	if first != `"a"=>"b", "c"=>NULL` && first != `"c"=>NULL, "a"=>"b"` {
+		t.Errorf("first value changed: %q", first)
+	}
+}
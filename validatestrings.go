@@ -0,0 +1,73 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WithStringValidation checks that keys and values are valid UTF-8 without NUL bytes before they
+// are encoded, and returns an *InvalidStringError if they are not. Postgres rejects these strings,
+// but its errors do not say which key is invalid. This assumes the database encoding is UTF8.
+func WithStringValidation() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.validateStrings = true
+	}
+}
+
+func (cfg *codecConfig) validatesStrings() bool {
+	return cfg != nil && cfg.validateStrings
+}
+
+// InvalidStringError is returned when encoding an hstore with a key or value that Postgres will
+// reject, if the codec was created with WithStringValidation.
+type InvalidStringError struct {
+	Key string
+	// InValue is true if the value of Key is invalid, and false if Key is invalid.
+	InValue bool
+	// Offset is the byte offset of the invalid byte in the key or value.
+	Offset int
+	// Reason is "NUL byte" or "invalid UTF-8".
+	Reason string
+}
+
+func (e *InvalidStringError) Error() string {
+	if e.InValue {
+		return fmt.Sprintf("hstore value for key %#v has %s at offset %d", e.Key, e.Reason, e.Offset)
+	}
+	return fmt.Sprintf("hstore key %#v has %s at offset %d", e.Key, e.Reason, e.Offset)
+}
+
+// checkString returns the offset of the first NUL byte or invalid UTF-8 in s with the reason, or
+// -1 if s is valid.
+func checkString(s string) (int, string) {
+	if utf8.ValidString(s) && strings.IndexByte(s, 0) < 0 {
+		return -1, ""
+	}
+	for i := 0; i < len(s); {
+		if s[i] == 0 {
+			return i, "NUL byte"
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i, "invalid UTF-8"
+		}
+		i += size
+	}
+	return -1, ""
+}
+
+// checkPairStrings returns an *InvalidStringError if key or value is invalid.
+func checkPairStrings(key string, value pgtype.Text) error {
+	if offset, reason := checkString(key); offset >= 0 {
+		return &InvalidStringError{Key: key, Offset: offset, Reason: reason}
+	}
+	if value.Valid {
+		if offset, reason := checkString(value.String); offset >= 0 {
+			return &InvalidStringError{Key: key, InValue: true, Offset: offset, Reason: reason}
+		}
+	}
+	return nil
+}
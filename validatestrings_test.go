@@ -0,0 +1,43 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+)
+
+func TestWithStringValidation(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithStringValidation())
+
+	tests := []struct {
+		input    pgxtypefaster.Hstore
+		expected *pgxtypefaster.InvalidStringError
+	}{
+		{pgxtypefaster.Hstore{"ok": pgxtypefaster.NewText("é"), "null": {}}, nil},
+		{pgxtypefaster.Hstore{"a\x00b": pgxtypefaster.NewText("v")}, &pgxtypefaster.InvalidStringError{Key: "a\x00b", Offset: 1, Reason: "NUL byte"}},
+		{pgxtypefaster.Hstore{"k": pgxtypefaster.NewText("éé\xff")}, &pgxtypefaster.InvalidStringError{Key: "k", InValue: true, Offset: 4, Reason: "invalid UTF-8"}},
+		{pgxtypefaster.Hstore{"k": pgxtypefaster.NewText("\x00")}, &pgxtypefaster.InvalidStringError{Key: "k", InValue: true, Offset: 0, Reason: "NUL byte"}},
+	}
+	for i, test := range tests {
+		for _, format := range formats {
+			for oid, value := range map[uint32]any{testHstoreOID: test.input, testHstoreOID + 1: fasterToCompat(test.input)} {
+				_, err := m.Encode(oid, format, value, nil)
+				var invalidErr *pgxtypefaster.InvalidStringError
+				if test.expected == nil {
+					if err != nil {
+						t.Errorf("%d: format=%d oid=%d: unexpected error: %s", i, format, oid, err)
+					}
+				} else if !errors.As(err, &invalidErr) || !reflect.DeepEqual(invalidErr, test.expected) {
+					t.Errorf("%d: format=%d oid=%d: err=%#v; expected %#v", i, format, oid, err, test.expected)
+				}
+			}
+		}
+	}
+
+	err := &pgxtypefaster.InvalidStringError{Key: "k", InValue: true, Offset: 4, Reason: "invalid UTF-8"}
+	if err.Error() != `hstore value for key "k" has invalid UTF-8 at offset 4` {
+		t.Errorf("Error()=%#v", err.Error())
+	}
+}
@@ -0,0 +1,80 @@
+package pgxtypefaster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// verifySentinel is round-tripped by VerifyRegistration. It has characters that must be escaped
+// and a NULL value.
+var verifySentinel = Hstore{
+	"key":              NewText("value"),
+	`quote"back\slash`: NewText(`a=>b, "c"`),
+	"null":             {},
+	"unicode é世":       NewText(""),
+}
+
+// RegistrationCheck is the result of round-tripping an hstore in one format.
+type RegistrationCheck struct {
+	Format int16
+	// OID is the type OID of the result column, which is the database's hstore OID.
+	OID uint32
+	// Codec is the codec registered for OID that scanned the value, or nil if no codec is
+	// registered.
+	Codec pgtype.Codec
+}
+
+// RegistrationReport is the result of VerifyRegistration, with a check for the text and binary
+// formats.
+type RegistrationReport struct {
+	Checks []RegistrationCheck
+}
+
+// VerifyRegistration checks that hstore is registered on conn with the database's hstore OID, by
+// sending an hstore with escapes and NULL values to the server and reading it back in the text and
+// binary formats. It returns the codec that scanned each format, even if the check fails. Use it in
+// integration tests, or to check pool connections at startup.
+func VerifyRegistration(ctx context.Context, conn *pgx.Conn) (RegistrationReport, error) {
+	var report RegistrationReport
+	registered, ok := conn.TypeMap().TypeForName("hstore")
+	if !ok {
+		return report, errHstoreNotRegistered
+	}
+
+	for _, format := range []int16{pgtype.TextFormatCode, pgtype.BinaryFormatCode} {
+		var h Hstore
+		var check RegistrationCheck
+		rows, err := conn.Query(ctx, `select $1::hstore`, pgx.QueryResultFormats{format}, verifySentinel)
+		if err != nil {
+			return report, err
+		}
+		_, err = pgx.ForEachRow(rows, []any{&h}, func() error {
+			fieldDesc := rows.FieldDescriptions()[0]
+			check = RegistrationCheck{Format: fieldDesc.Format, OID: fieldDesc.DataTypeOID}
+			if columnType, ok := conn.TypeMap().TypeForOID(fieldDesc.DataTypeOID); ok {
+				check.Codec = columnType.Codec
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("hstore in format %d: %w", format, err)
+		}
+		report.Checks = append(report.Checks, check)
+
+		if check.OID != registered.OID {
+			return report, fmt.Errorf("hstore is registered with OID %d; the database's hstore OID is %d",
+				registered.OID, check.OID)
+		}
+		if check.Format != format {
+			return report, fmt.Errorf("hstore requested in format %d; returned in format %d", format, check.Format)
+		}
+		if !h.Equal(verifySentinel) {
+			return report, fmt.Errorf("hstore in format %d: round trip returned %#v; expected %#v",
+				format, h, verifySentinel)
+		}
+	}
+	return report, nil
+}
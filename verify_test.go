@@ -0,0 +1,55 @@
+package pgxtypefaster_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestVerifyRegistration(t *testing.T) {
+	conn := newTestConn(t)
+	ctx := context.Background()
+
+	report, err := pgxtypefaster.VerifyRegistration(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, _ := conn.TypeMap().TypeForName("hstore")
+	if len(report.Checks) != 2 {
+		t.Fatalf("report=%#v; expected 2 checks", report)
+	}
+	for i, check := range report.Checks {
+		if check.Format != formats[i] || check.OID != registered.OID || check.Codec != registered.Codec {
+			t.Errorf("check %d=%#v", i, check)
+		}
+	}
+
+	// a connection without hstore registered
+	other, err := pgx.ConnectConfig(ctx, conn.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close(ctx)
+	if _, err := pgxtypefaster.VerifyRegistration(ctx, other); err == nil {
+		t.Error("VerifyRegistration without hstore registered: expected error")
+	}
+
+	// registered with the wrong OID
+	pgxtypefaster.RegisterHstoreMap(other.TypeMap(), registered.OID+1000)
+	report, err = pgxtypefaster.VerifyRegistration(ctx, other)
+	if err == nil {
+		t.Error("VerifyRegistration with the wrong OID: expected error")
+	}
+	if len(report.Checks) != 1 || report.Checks[0].Codec != nil {
+		t.Errorf("report=%#v; expected one check without a codec", report)
+	}
+
+	// registered with a codec that cannot scan Hstore
+	other.TypeMap().RegisterType(&pgtype.Type{Codec: pgtype.TextCodec{}, Name: "hstore", OID: registered.OID})
+	if _, err := pgxtypefaster.VerifyRegistration(ctx, other); err == nil {
+		t.Error("VerifyRegistration with TextCodec: expected error")
+	}
+}
@@ -0,0 +1,168 @@
+package pgxtypefaster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ServerVersion is a Postgres server version in the server_version_num format: major*10000+minor
+// for 10 and later (e.g. 140005 for 14.5), and major*10000+minor*100+patch before 10 (e.g. 90624
+// for 9.6.24). The zero value means the version is unknown, which is treated as the latest
+// version, so it supports every capability.
+type ServerVersion int
+
+// ParseServerVersion parses the server_version parameter reported by Postgres, such as "14.5",
+// "9.6.24", "16beta1", or "15.3 (Debian 15.3-1.pgdg120+1)".
+func ParseServerVersion(s string) (ServerVersion, error) {
+	version := s
+	if i := strings.IndexFunc(version, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if version == "" || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid server version %#v", s)
+	}
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid server version %#v", s)
+		}
+		nums[i] = n
+	}
+	if nums[0] >= 10 {
+		return ServerVersion(nums[0]*10000 + nums[1]), nil
+	}
+	return ServerVersion(nums[0]*10000 + nums[1]*100 + nums[2]), nil
+}
+
+// ConnServerVersion returns the version of the server conn is connected to, from the
+// server_version parameter reported when the connection was established.
+func ConnServerVersion(conn *pgx.Conn) (ServerVersion, error) {
+	return ParseServerVersion(conn.PgConn().ParameterStatus("server_version"))
+}
+
+func (v ServerVersion) String() string {
+	if v == 0 {
+		return "unknown"
+	}
+	if v >= 100000 {
+		return fmt.Sprintf("%d.%d", v/10000, v%10000)
+	}
+	return fmt.Sprintf("%d.%d.%d", v/10000, v/100%100, v%100)
+}
+
+// Supports returns true if the server supports c. An unknown version supports everything.
+func (v ServerVersion) Supports(c Capability) bool {
+	return v == 0 || v >= c.MinServerVersion()
+}
+
+// Capability is a server feature that changes how types can be registered or encoded.
+type Capability int
+
+const (
+	// CapabilityHstoreBinary is the binary format for hstore (hstore_send and hstore_recv).
+	CapabilityHstoreBinary Capability = iota + 1
+	// CapabilityJSONB is the jsonb type.
+	CapabilityJSONB
+	// CapabilityJSONPath is the jsonpath type.
+	CapabilityJSONPath
+	// CapabilityMultirange is the multirange types.
+	CapabilityMultirange
+)
+
+// capabilityInfo describes each capability. It is indexed by Capability.
+var capabilityInfo = [...]struct {
+	name       string
+	minVersion ServerVersion
+}{
+	CapabilityHstoreBinary: {"hstore binary format", 90000},
+	CapabilityJSONB:        {"jsonb", 90400},
+	CapabilityJSONPath:     {"jsonpath", 120000},
+	CapabilityMultirange:   {"multirange", 140000},
+}
+
+func (c Capability) valid() bool {
+	return c > 0 && int(c) < len(capabilityInfo)
+}
+
+// MinServerVersion returns the first server version that supports c.
+func (c Capability) MinServerVersion() ServerVersion {
+	if !c.valid() {
+		return 0
+	}
+	return capabilityInfo[c].minVersion
+}
+
+func (c Capability) String() string {
+	if !c.valid() {
+		return fmt.Sprintf("Capability(%d)", int(c))
+	}
+	return capabilityInfo[c].name
+}
+
+// UnsupportedError is returned when the server does not support a required capability.
+type UnsupportedError struct {
+	Server     ServerVersion
+	Capability Capability
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("postgres server version %s does not support %s (requires %s)",
+		e.Server, e.Capability, e.Capability.MinServerVersion())
+}
+
+// RequireCapabilities returns an *UnsupportedError for the first capability the server conn is
+// connected to does not support. It can be called before registering types that require them.
+func RequireCapabilities(conn *pgx.Conn, capabilities ...Capability) error {
+	version, err := ConnServerVersion(conn)
+	if err != nil {
+		return err
+	}
+	for _, c := range capabilities {
+		if !version.Supports(c) {
+			return &UnsupportedError{version, c}
+		}
+	}
+	return nil
+}
+
+// WireFormat describes the binary wire format of a type.
+type WireFormat struct {
+	TypeName string
+	// Version is the version byte at the start of the binary format, or 0 if it has none.
+	Version byte
+	// Requires is the capability required for the binary format.
+	Requires Capability
+}
+
+var wireFormats = []WireFormat{
+	{"hstore", 0, CapabilityHstoreBinary},
+	{"jsonb", 1, CapabilityJSONB},
+	{"jsonpath", 1, CapabilityJSONPath},
+}
+
+// BinaryWireFormat returns the binary wire format of the type typeName for the server. It returns
+// false if the type is not known, or the server does not support its binary format.
+func (v ServerVersion) BinaryWireFormat(typeName string) (WireFormat, bool) {
+	for _, f := range wireFormats {
+		if f.TypeName == typeName {
+			return f, v.Supports(f.Requires)
+		}
+	}
+	return WireFormat{}, false
+}
+
+// hstoreRegisterOptions returns the codec options to register hstore on conn. It only returns
+// options for servers that do not support the binary format, so the registered codec is the
+// default on current servers.
+func hstoreRegisterOptions(conn *pgx.Conn) []CodecOption {
+	version, err := ConnServerVersion(conn)
+	if err != nil || version.Supports(CapabilityHstoreBinary) {
+		return nil
+	}
+	return []CodecOption{WithServerVersion(version)}
+}
@@ -0,0 +1,100 @@
+package pgxtypefaster_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected pgxtypefaster.ServerVersion
+		str      string
+	}{
+		{"14.5", 140005, "14.5"},
+		{"16beta1", 160000, "16.0"},
+		{"15.3 (Debian 15.3-1.pgdg120+1)", 150003, "15.3"},
+		{"9.6.24", 90624, "9.6.24"},
+		{"8.4", 80400, "8.4.0"},
+	} {
+		v, err := pgxtypefaster.ParseServerVersion(test.input)
+		if err != nil {
+			t.Errorf("ParseServerVersion(%#v): %s", test.input, err)
+			continue
+		}
+		if v != test.expected || v.String() != test.str {
+			t.Errorf("ParseServerVersion(%#v)=%d %s; expected %d %s", test.input, v, v, test.expected, test.str)
+		}
+	}
+
+	for _, input := range []string{"", "beta", "1.2.3.4", "1..2"} {
+		if _, err := pgxtypefaster.ParseServerVersion(input); err == nil {
+			t.Errorf("ParseServerVersion(%#v) expected error", input)
+		}
+	}
+}
+
+func TestServerVersionSupports(t *testing.T) {
+	var unknown pgxtypefaster.ServerVersion
+	if !unknown.Supports(pgxtypefaster.CapabilityMultirange) {
+		t.Error("unknown version must support everything")
+	}
+	v13 := pgxtypefaster.ServerVersion(130010)
+	if v13.Supports(pgxtypefaster.CapabilityMultirange) || !v13.Supports(pgxtypefaster.CapabilityJSONB) {
+		t.Error("13 must support jsonb and not multirange")
+	}
+	if !pgxtypefaster.ServerVersion(140000).Supports(pgxtypefaster.CapabilityMultirange) {
+		t.Error("14 must support multirange")
+	}
+
+	f, ok := v13.BinaryWireFormat("jsonb")
+	if !ok || f.Version != 1 {
+		t.Errorf("BinaryWireFormat(jsonb)=%#v, %t", f, ok)
+	}
+	if _, ok := pgxtypefaster.ServerVersion(90300).BinaryWireFormat("jsonb"); ok {
+		t.Error("9.3 must not support jsonb")
+	}
+	if _, ok := v13.BinaryWireFormat("unknown_type"); ok {
+		t.Error("unknown type must not be supported")
+	}
+
+	var err error = &pgxtypefaster.UnsupportedError{Server: v13, Capability: pgxtypefaster.CapabilityMultirange}
+	var unsupported *pgxtypefaster.UnsupportedError
+	if !errors.As(err, &unsupported) || err.Error() != "postgres server version 13.10 does not support multirange (requires 14.0)" {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestWithServerVersion(t *testing.T) {
+	oldServer := pgxtypefaster.WithServerVersion(80400)
+	codecs := []pgtype.Codec{
+		pgxtypefaster.NewHstoreCodec(oldServer),
+		pgxtypefaster.NewHstoreCompatCodec(oldServer),
+	}
+	for _, codec := range codecs {
+		if codec.FormatSupported(pgtype.BinaryFormatCode) || !codec.FormatSupported(pgtype.TextFormatCode) {
+			t.Errorf("%T: old servers must only support text", codec)
+		}
+		if codec.PreferredFormat() != pgtype.TextFormatCode {
+			t.Errorf("%T: PreferredFormat()=%d", codec, codec.PreferredFormat())
+		}
+	}
+
+	current := pgxtypefaster.NewHstoreCodec(pgxtypefaster.WithServerVersion(160000))
+	if !current.FormatSupported(pgtype.BinaryFormatCode) || current.PreferredFormat() != pgtype.BinaryFormatCode {
+		t.Error("current servers must prefer binary")
+	}
+
+	m := newOptionsTypeMap(oldServer)
+	h := pgxtypefaster.Hstore{"a": pgxtypefaster.NewText("b")}
+	buf, err := m.Encode(testHstoreOID, m.FormatCodeForOID(testHstoreOID), h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `"a"=>"b"` {
+		t.Errorf("expected text format; got %#v", string(buf))
+	}
+}
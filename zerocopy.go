@@ -0,0 +1,24 @@
+package pgxtypefaster
+
+// WithZeroCopy scans keys and values as views of pgx's read buffer instead of copying them, when
+// the package is built with the pgxtypefasterunsafe build tag. Scanned values are only valid until
+// the next call to Rows.Next or Rows.Close, since pgx then reuses the buffer: the caller must use
+// the entire row before reading the next one, and copy any strings it retains. Strings that contain
+// escapes in the text format are still copied. Without the build tag, this has no effect, so it is
+// safe to use in code that is also built without it; see UnsafeOptimizations. It replaces
+// WithAllocator.
+func WithZeroCopy() CodecOption {
+	return func(cfg *codecOptions) {
+		cfg.alloc = zeroCopyAllocator{}
+	}
+}
+
+// zeroCopyAllocator allocates like HeapAllocator, except strings, which reference the scanned
+// bytes.
+type zeroCopyAllocator struct {
+	HeapAllocator
+}
+
+func (zeroCopyAllocator) AllocString(b []byte) string {
+	return borrowedBytesToString(b)
+}
@@ -0,0 +1,47 @@
+package pgxtypefaster_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/evanj/pgxtypefaster"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestWithZeroCopy(t *testing.T) {
+	m := newOptionsTypeMap(pgxtypefaster.WithZeroCopy())
+	input := pgxtypefaster.Hstore{"key": pgxtypefaster.NewText("value"), "null": pgtype.Text{}}
+	for _, format := range formats {
+		for i, oid := range []uint32{testHstoreOID, testHstoreOID + 1} {
+			buf, err := m.Encode(testHstoreOID, format, input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var h pgxtypefaster.Hstore
+			var compat pgxtypefaster.HstoreCompat
+			targets := []any{&h, &compat}
+			if err := m.Scan(oid, format, buf, targets[i]); err != nil {
+				t.Fatal(err)
+			}
+			if compat != nil {
+				h = pgxtypefaster.PGXToFasterHstore(compat)
+			}
+			if !reflect.DeepEqual(h, input) {
+				t.Errorf("format=%d oid=%d: scanned %#v; expected %#v", format, oid, h, input)
+			}
+
+			// with the unsafe build tag, the values reference the buffer
+			for j := range buf {
+				buf[j] = 'x'
+			}
+			if compat != nil {
+				h = pgxtypefaster.PGXToFasterHstore(compat)
+			}
+			changed := !reflect.DeepEqual(h, input)
+			if changed != pgxtypefaster.UnsafeOptimizations {
+				t.Errorf("format=%d oid=%d: changed=%t after modifying the buffer; UnsafeOptimizations=%t",
+					format, oid, changed, pgxtypefaster.UnsafeOptimizations)
+			}
+		}
+	}
+}